@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/store"
+)
+
+// auditCmd shows the audit log of blocks, unblocks, rewards and reloads
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of blocks, unblocks, rewards and reloads",
+	Long: `Show every block and unblock of a device, every reward and every reload
+through the API with its time, actor and reason. The log is also appended to
+audit.jsonl in the data directory, which keeps entries older than 90 days.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runAudit()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().String("device", "", "Only show actions for this device name or MAC address")
+	auditCmd.Flags().String("from", "", "Start date (YYYY-MM-DD) or RFC 3339 time (default 7 days ago)")
+	auditCmd.Flags().String("to", "", "End date (inclusive) or RFC 3339 time (default now)")
+	auditCmd.Flags().Bool("json", false, "Output as JSON")
+}
+
+func runAudit() {
+	loc := location()
+	from, to, err := store.AuditRange(viper.GetString("from"), viper.GetString("to"), time.Now(), loc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audit error: %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := openStore().Audit(viper.GetString("device"), from, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Audit error: %v\n", err)
+		os.Exit(1)
+	}
+	if viper.GetBool("json") {
+		if entries == nil {
+			entries = []store.AuditEntry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		cobra.CheckErr(enc.Encode(entries))
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TIME\tDEVICE\tACTION\tACTOR\tREASON")
+	for _, e := range entries {
+		reason := e.Reason
+		if e.Error != "" {
+			reason += " (failed: " + e.Error + ")"
+		}
+		device := e.Device
+		if e.Name != "" {
+			device = e.Name
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Time.In(loc).Format("2006-01-02 15:04"), device, e.Action, e.Actor, reason)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/monitor"
+)
+
+// calibrateCmd suggests per-device activity thresholds
+var calibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: "Suggest activity thresholds from learned idle traffic",
+	Long: `Learn each device's idle background traffic from the traffic samples that
+monitor runs store in the data directory, and suggest an activity threshold
+per device: the nightly traffic percentile times a safety margin.
+
+Apply the suggestions automatically with monitor/web --auto-threshold, which
+learns with the default options, or copy them into the activity section of
+the config file, e.g. when calibrating with other options.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runCalibrate()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(calibrateCmd)
+
+	defaults := monitor.DefaultBaselineOptions
+	calibrateCmd.Flags().String("device", "", "Only calibrate this device name")
+	calibrateCmd.Flags().Int("days", defaults.Days, "Days of history to learn from")
+	calibrateCmd.Flags().Int("night-from", defaults.NightFrom, "Hour at which devices are assumed idle")
+	calibrateCmd.Flags().Int("night-to", defaults.NightTo, "Hour until which devices are assumed idle")
+	calibrateCmd.Flags().Float64("percentile", defaults.Percentile, "Percentile of nightly traffic considered idle")
+	calibrateCmd.Flags().Float64("margin", defaults.Margin, "Factor applied to the idle traffic")
+	calibrateCmd.Flags().Bool("json", false, "Output as JSON")
+}
+
+func runCalibrate() {
+	opts := monitor.DefaultBaselineOptions
+	opts.Days = viper.GetInt("days")
+	opts.NightFrom = viper.GetInt("night-from")
+	opts.NightTo = viper.GetInt("night-to")
+	opts.Percentile = viper.GetFloat64("percentile")
+	opts.Margin = viper.GetFloat64("margin")
+	if opts.Days < 1 || opts.NightFrom < 0 || opts.NightTo > 24 || opts.NightFrom >= opts.NightTo ||
+		opts.Percentile <= 0 || opts.Percentile > 1 || opts.Margin < 1 {
+		fmt.Fprintln(os.Stderr, "invalid calibration options: need days >= 1, 0 <= night-from < night-to <= 24, 0 < percentile <= 1, margin >= 1")
+		os.Exit(1)
+	}
+
+	baselines, err := monitor.LearnBaselines(openStore(), viper.GetString("device"), time.Now().In(location()), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Calibration error: %v\n", err)
+		os.Exit(1)
+	}
+	if viper.GetBool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(baselines)
+		return
+	}
+	if len(baselines) == 0 {
+		fmt.Println("No traffic samples yet, run monitor for a few nights first")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DEVICE\tMAC\tSAMPLES\tIDLE RCV (B/s)\tIDLE SND (B/s)\tSUGGESTED RCV\tSUGGESTED SND")
+	for _, b := range baselines {
+		rcv, snd := fmt.Sprintf("%g", b.Suggested.Rcv), fmt.Sprintf("%g", b.Suggested.Snd)
+		if !b.Valid {
+			rcv, snd = "-", "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%.1f\t%s\t%s\n", b.Device, b.MAC, b.Samples, b.Idle.Rcv, b.Idle.Snd, rcv, snd)
+	}
+	_ = tw.Flush()
+	fmt.Printf("\nSuggestions need at least %d nightly samples.\n", opts.MinSamples)
+}
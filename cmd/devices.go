@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/devices"
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/identity"
+)
+
+// devicesCmd lists the landevices known to the Fritz!Box
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "List devices known to the Fritz!Box",
+	Long: `List all Fritz!Box landevices with their MAC address, UIDs and state,
+to find the devices to target with --mac or a policy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runDevices(cmd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(devicesCmd)
+
+	devicesCmd.Flags().String("username", "", "Fritzbox username")
+	devicesCmd.Flags().String("password", "", "Fritzbox password")
+	devicesCmd.Flags().String("name", "", "Only list devices whose name contains this text")
+	devicesCmd.Flags().Bool("active", false, "Only list active (true) or inactive (false) devices")
+	devicesCmd.Flags().Bool("blocked", false, "Only list blocked (true) or unblocked (false) devices")
+	devicesCmd.Flags().Bool("new-randomized", false, "Only list randomized (private) MACs not linked to a device yet")
+	devicesCmd.Flags().Bool("json", false, "Print devices as JSON")
+
+	devicesCmd.AddCommand(devicesLinkCmd)
+	devicesLinkCmd.Flags().String("username", "", "Fritzbox username")
+	devicesLinkCmd.Flags().String("password", "", "Fritzbox password")
+	devicesLinkCmd.Flags().String("uid", "", "Landevice UID to link")
+	devicesLinkCmd.Flags().String("mac", "", "MAC address to link")
+
+	_ = viper.BindEnv("username", "FRITZBOX_USERNAME")
+	_ = viper.BindEnv("password", "FRITZBOX_PASSWORD")
+}
+
+// devicesLinkCmd links a landevice, typically one with a new randomized MAC, to a named device
+var devicesLinkCmd = &cobra.Command{
+	Use:   "link <device name>",
+	Short: "Link a landevice to a named device",
+	Long: `Link a landevice (by --uid or --mac) to a named device, so a phone keeps
+its identity when it rotates its private Wi-Fi MAC address. The name can then
+be used with --mac.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runDevicesLink(args[0])
+	},
+}
+
+// connectFritzbox creates a client from the username and password settings and logs in.
+func connectFritzbox() fritzbox.Client {
+	username := viper.GetString("username")
+	password := viper.GetString("password")
+	if username == "" || password == "" {
+		fmt.Fprintln(os.Stderr, "username and password are required")
+		os.Exit(1)
+	}
+	client := fritzbox.New(username, password)
+	if err := client.Connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	return client
+}
+
+// loadIdentities loads the device identity registry from the data directory.
+func loadIdentities() *identity.Registry {
+	reg, err := identity.Load(dataFile("identities.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load device identities: %v\n", err)
+		os.Exit(1)
+	}
+	return reg
+}
+
+func runDevices(cmd *cobra.Command) {
+	filter := devices.Filter{
+		Name:          viper.GetString("name"),
+		NewRandomized: viper.GetBool("new-randomized"),
+	}
+	if cmd.Flags().Changed("active") {
+		active := viper.GetBool("active")
+		filter.Active = &active
+	}
+	if cmd.Flags().Changed("blocked") {
+		blocked := viper.GetBool("blocked")
+		filter.Blocked = &blocked
+	}
+
+	client := connectFritzbox()
+	list, err := devices.List(client, loadIdentities(), filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Listing error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if viper.GetBool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode devices: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NAME\tMAC\tUID\tUSER UIDS\tACTIVE\tBLOCKED\tMONITORED\tRANDOMIZED\tIDENTITY")
+	for _, d := range list {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%t\t%t\t%t\t%t\t%s\n",
+			d.Name, d.MAC, d.UID, d.UserUIDs, d.Active, d.Blocked, d.Monitored, d.Randomized, d.Identity)
+	}
+	_ = tw.Flush()
+}
+
+func runDevicesLink(name string) {
+	uid := viper.GetString("uid")
+	mac := viper.GetString("mac")
+	if uid == "" && mac == "" {
+		fmt.Fprintln(os.Stderr, "either --uid or --mac is required")
+		os.Exit(1)
+	}
+
+	client := connectFritzbox()
+	landevices, err := client.GetLandevices()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to fetch landevices: %v\n", err)
+		os.Exit(1)
+	}
+	var found *fritzbox.Landevice
+	for i, dev := range landevices {
+		if (uid != "" && dev.UID == uid) || (mac != "" && fritzbox.NormalizeMAC(dev.MAC) == fritzbox.NormalizeMAC(mac)) {
+			found = &landevices[i]
+			break
+		}
+	}
+	if found == nil {
+		fmt.Fprintln(os.Stderr, "no landevice found for the given --uid/--mac")
+		os.Exit(1)
+	}
+
+	reg := loadIdentities()
+	reg.Link(name, *found)
+	if err := reg.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save device identities: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Linked %s (%s, %s) to %s\n", found.FriendlyName, found.MAC, found.UID, name)
+}
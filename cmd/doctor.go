@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/pkg/monitor"
+)
+
+// doctorCmd checks that home-gate can monitor and enforce with the Fritz!Box
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the Fritz!Box setup",
+	Long: `Check that home-gate can work with the Fritz!Box, or with every router of
+the config file: that the credentials are accepted, the REST API is available,
+the online monitor shows devices and has traffic data, and that the user may
+block devices. Failed checks come with a hint how to fix them.
+
+Exits with status 1 if a check failed. A missing block permission only fails
+with --enforce.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runDoctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().String("username", "", "Fritzbox username")
+	doctorCmd.Flags().String("password", "", "Fritzbox password")
+	doctorCmd.Flags().String("mac", "", "MAC address to be monitored instead of the online monitor's devices (optional)")
+	doctorCmd.Flags().Bool("enforce", false, "Fail if the user may not block devices")
+
+	_ = viper.BindEnv("username", "FRITZBOX_USERNAME")
+	_ = viper.BindEnv("password", "FRITZBOX_PASSWORD")
+}
+
+func runDoctor() {
+	routers, err := routersConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	opts := []monitor.Option{
+		monitor.WithCredentials(viper.GetString("username"), viper.GetString("password")),
+		monitor.WithDevice(viper.GetString("mac")),
+	}
+	for _, r := range routers {
+		opts = append(opts, monitor.WithRouters(monitor.Router{Name: r.Name, URL: r.URL, Username: r.Username, Password: r.Password}))
+	}
+	if viper.GetBool("enforce") {
+		opts = append(opts, monitor.WithEnforcement())
+	}
+	checks := monitor.Diagnose(context.Background(), opts...)
+	failed := false
+	for _, c := range checks {
+		mark := "ok  "
+		switch {
+		case c.OK:
+		case c.Warning:
+			mark = "warn"
+		default:
+			mark = "FAIL"
+			failed = true
+		}
+		name := c.Name
+		if c.Router != "" {
+			name = fmt.Sprintf("[%s] %s", c.Router, c.Name)
+		}
+		fmt.Printf("%s  %s: %s\n", mark, name, c.Detail)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
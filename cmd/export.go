@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/export"
+)
+
+// exportCmd exports the usage history for spreadsheets
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the usage history as CSV or Excel workbook",
+	Long: `Export one row per day and device with the active minutes, quota,
+minutes over (positive) or under (negative) the quota, banked and bonus
+minutes and the number of blocks, from the history in the data directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runExport()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().String("from", "", "Start date (YYYY-MM-DD, default first day of this month)")
+	exportCmd.Flags().String("to", "", "End date (inclusive, default today)")
+	exportCmd.Flags().String("format", export.FormatCSV, "Output format: csv or xlsx")
+	exportCmd.Flags().StringP("output", "o", "", "Write to this file instead of standard output")
+}
+
+func runExport() {
+	loc := location()
+	format := viper.GetString("format")
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		fmt.Fprintf(os.Stderr, "Export error: unknown format %q, use csv or xlsx\n", format)
+		os.Exit(1)
+	}
+	from, to, err := export.Range(viper.GetString("from"), viper.GetString("to"), time.Now(), loc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export error: %v\n", err)
+		os.Exit(1)
+	}
+	rows, err := export.Rows(openStore(), from, to, loc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export error: %v\n", err)
+		os.Exit(1)
+	}
+	out := os.Stdout
+	if path := viper.GetString("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Export error: %v\n", err)
+			os.Exit(1)
+		}
+		out = f
+	}
+	if err := export.Write(out, format, rows); err != nil {
+		fmt.Fprintf(os.Stderr, "Export error: %v\n", err)
+		os.Exit(1)
+	}
+	if out != os.Stdout {
+		if err := out.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Export error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d rows to %s\n", len(rows), out.Name())
+	}
+}
@@ -0,0 +1,105 @@
+package cmd_test
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"home-gate/cmd"
+)
+
+// argsEnv holds the arguments, one per line, with which the test binary
+// runs the home-gate command instead of the tests, see startCommand.
+const argsEnv = "HOME_GATE_TEST_ARGS"
+
+func TestMain(m *testing.M) {
+	if args := os.Getenv(argsEnv); args != "" {
+		os.Args = append([]string{"home-gate"}, strings.Split(args, "\n")...)
+		cmd.Execute()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// process is a home-gate command running in a child process.
+type process struct {
+	cmd    *exec.Cmd
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	done   chan struct{}
+	err    error
+}
+
+// startCommand runs home-gate with args in a child process with its own home
+// and data directory. The process is killed when the test ends.
+func startCommand(t *testing.T, args ...string) *process {
+	t.Helper()
+	home := t.TempDir()
+	args = append([]string{"--data-dir", filepath.Join(home, "data")}, args...)
+	p := &process{cmd: exec.Command(os.Args[0]), done: make(chan struct{})}
+	p.cmd.Env = append(os.Environ(), argsEnv+"="+strings.Join(args, "\n"), "HOME="+home)
+	p.cmd.Stdout = &p.stdout
+	p.cmd.Stderr = &p.stderr
+	if err := p.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		p.err = p.cmd.Wait()
+		close(p.done)
+	}()
+	t.Cleanup(func() {
+		_ = p.cmd.Process.Kill()
+		<-p.done
+	})
+	return p
+}
+
+// wait waits for the process to exit and returns its exit code.
+func (p *process) wait(t *testing.T) int {
+	t.Helper()
+	select {
+	case <-p.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("home-gate did not exit")
+	}
+	return p.cmd.ProcessState.ExitCode()
+}
+
+// get polls url through client until the daemon answers.
+func (p *process) get(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			return resp
+		}
+		select {
+		case <-p.done:
+			t.Fatalf("home-gate exited: %v\n%s", p.err, p.stderr.String())
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("home-gate did not answer: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// freeAddr returns a local TCP address that was free a moment ago.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
@@ -3,9 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"home-gate/internal/monitor"
+	imonitor "home-gate/internal/monitor"
+	"home-gate/pkg/monitor"
+	"home-gate/pkg/policy"
 	"os"
 )
 
@@ -28,36 +31,39 @@ func init() {
 	monitorCmd.Flags().String("mac", "", "MAC address to query usage for (optional)")
 	monitorCmd.Flags().String("period", "day", "Period to query: hour or day")
 	monitorCmd.Flags().Float64("activity-threshold", 0, "Minimum Byte/s to consider interval active")
+	monitorCmd.Flags().Bool("auto-threshold", false, "Use the idle baseline learned per device (see calibrate) instead of --activity-threshold")
 	monitorCmd.Flags().String("policy", "", "Policy string for allowed minutes per day")
+	monitorCmd.Flags().String("policy-file", "", "YAML or JSON policy document, takes precedence over --policy")
 	monitorCmd.Flags().Bool("enforce", false, "Enforce policy by blocking devices that exceed limits")
+	monitorCmd.Flags().Duration("grace-period", 0, "Delay blocking this long after the quota is reached")
+	monitorCmd.Flags().Duration("min-block-duration", 0, "Keep a blocked device blocked at least this long")
+	addOverrideFlags(monitorCmd)
 
 	_ = viper.BindPFlag("username", monitorCmd.Flags().Lookup("username"))
 	_ = viper.BindPFlag("password", monitorCmd.Flags().Lookup("password"))
 	_ = viper.BindPFlag("mac", monitorCmd.Flags().Lookup("mac"))
 	_ = viper.BindPFlag("period", monitorCmd.Flags().Lookup("period"))
 	_ = viper.BindPFlag("activity-threshold", monitorCmd.Flags().Lookup("activity-threshold"))
+	_ = viper.BindPFlag("auto-threshold", monitorCmd.Flags().Lookup("auto-threshold"))
 	_ = viper.BindPFlag("policy", monitorCmd.Flags().Lookup("policy"))
+	_ = viper.BindPFlag("policy-file", monitorCmd.Flags().Lookup("policy-file"))
 	_ = viper.BindPFlag("enforce", monitorCmd.Flags().Lookup("enforce"))
+	_ = viper.BindPFlag("grace-period", monitorCmd.Flags().Lookup("grace-period"))
+	_ = viper.BindPFlag("min-block-duration", monitorCmd.Flags().Lookup("min-block-duration"))
 
 	_ = viper.BindEnv("username", "FRITZBOX_USERNAME")
 	_ = viper.BindEnv("password", "FRITZBOX_PASSWORD")
 }
 
 func runMonitor() {
-
-	summary, err := monitor.Run(
-		context.Background(),
-		monitor.Options{
-			Username:          viper.GetString("username"),
-			Password:          viper.GetString("password"),
-			Mac:               viper.GetString("mac"),
-			Period:            viper.GetString("period"),
-			ActivityThreshold: viper.GetFloat64("activity-threshold"),
-			PolicyString:      viper.GetString("policy"),
-			Enforce:           viper.GetBool("enforce"),
-			Out:               os.Stdout,
-		},
-	)
+	opts := monitorOptions()
+	if viper.GetBool("enforce") {
+		opts = append(opts, monitor.WithEnforcement())
+	}
+	summary, err := monitor.Run(context.Background(), append(opts,
+		monitor.WithPeriod(viper.GetString("period")),
+		monitor.WithOutput(os.Stdout),
+	)...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Monitoring error: %v\n", err)
 		os.Exit(1)
@@ -66,3 +72,116 @@ func runMonitor() {
 		summary.DevicesChecked, summary.UsersFetched, summary.Duration,
 	)
 }
+
+// monitorOptions builds the options shared by monitor and policy explain from
+// the flags and the config file. It exits on invalid settings.
+func monitorOptions() []monitor.Option {
+	overrides, err := policyOverrides()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Policy override error: %v\n", err)
+		os.Exit(1)
+	}
+	classifiers, err := monitorClassifiers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Activity error: %v\n", err)
+		os.Exit(1)
+	}
+	routers, err := routersConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Router error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := []monitor.Option{
+		monitor.WithCredentials(viper.GetString("username"), viper.GetString("password")),
+		monitor.WithDevice(viper.GetString("mac")),
+		monitor.WithActivityThreshold(viper.GetFloat64("activity-threshold")),
+		monitor.WithPolicy(viper.GetString("policy")),
+		monitor.WithLocation(location()),
+		monitor.WithGracePeriod(viper.GetDuration("grace-period")),
+		monitor.WithMinBlockDuration(viper.GetDuration("min-block-duration")),
+		monitor.WithDataDir(dataDir()),
+		monitor.WithIdentities(dataFile("identities.json")),
+	}
+	for _, r := range routers {
+		opts = append(opts, monitor.WithRouters(monitor.Router{Name: r.Name, URL: r.URL, Username: r.Username, Password: r.Password}))
+	}
+	if classifiers != nil {
+		opts = append(opts, monitor.WithClassifiers(*classifiers))
+	}
+	if viper.GetBool("auto-threshold") {
+		opts = append(opts, monitor.WithAutoThreshold())
+	}
+	if path := viper.GetString("policy-file"); path != "" {
+		opts = append(opts, monitor.WithPolicyFile(path))
+	} else if viper.IsSet("policies") {
+		opts = append(opts, monitor.WithPolicySection(viper.ConfigFileUsed(), "policies"))
+	}
+	for _, o := range overrides {
+		opts = append(opts, monitor.WithOverrides(policy.Override{From: o.From, To: o.To, Policy: o.Policy, Reason: o.Reason}))
+	}
+	return opts
+}
+
+// monitorClassifiers reads the "activity" section of the config file like
+// activityClassifiers, for pkg/monitor. It returns nil without such a
+// section.
+func monitorClassifiers() (*monitor.Classifiers, error) {
+	cfg, err := activityConfig()
+	if cfg == nil || err != nil {
+		return nil, err
+	}
+	def, byType, err := cfg.BuildTypes()
+	if err != nil {
+		return nil, err
+	}
+	classifiers := &monitor.Classifiers{Default: def, ByType: make(map[string]monitor.Classifier), Devices: cfg.Devices}
+	for name, classifier := range byType {
+		classifiers.ByType[name] = classifier
+	}
+	return classifiers, nil
+}
+
+// activityConfig decodes the "activity" section of the config file. It
+// returns nil without such a section.
+func activityConfig() (*imonitor.ActivityConfig, error) {
+	if !viper.IsSet("activity") {
+		return nil, nil
+	}
+	var cfg imonitor.ActivityConfig
+	err := viper.UnmarshalKey("activity", &cfg, func(c *mapstructure.DecoderConfig) { c.ErrorUnused = true })
+	if err != nil {
+		return nil, fmt.Errorf("invalid activity configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+// activityClassifiers builds the per-device activity classifiers from the
+// "activity" section of the config file. Devices without a configured type
+// fall back to --activity-threshold. It returns nil without such a section.
+func activityClassifiers() (*imonitor.Classifiers, error) {
+	cfg, err := activityConfig()
+	if cfg == nil || err != nil {
+		return nil, err
+	}
+	threshold := viper.GetFloat64("activity-threshold")
+	return cfg.Build(imonitor.ThresholdClassifier{Thresholds: imonitor.Thresholds{Rcv: threshold, Snd: threshold}})
+}
+
+// routersConfig reads the "routers" section of the config file, which
+// replaces --username and --password with several Fritz!Boxes. It returns nil
+// without such a section.
+func routersConfig() ([]imonitor.Router, error) {
+	if !viper.IsSet("routers") {
+		return nil, nil
+	}
+	var routers []imonitor.Router
+	err := viper.UnmarshalKey("routers", &routers, func(c *mapstructure.DecoderConfig) { c.ErrorUnused = true })
+	if err != nil {
+		return nil, fmt.Errorf("invalid routers configuration: %w", err)
+	}
+	if err := imonitor.ValidateRouters(routers); err != nil {
+		return nil, err
+	}
+	return routers, nil
+}
@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"home-gate/internal/fritzbox"
 	"home-gate/internal/fritzbox/fritzboxfakes"
+	"home-gate/internal/identity"
 	"home-gate/internal/monitor"
+	"home-gate/internal/policy"
+	"home-gate/internal/policy/policyfakes"
+	"home-gate/internal/store"
 )
 
 // helper to build measurements of given length with active indices set
@@ -131,8 +137,777 @@ func TestMonitor_EnforcesOnReachingLimit(t *testing.T) {
 	if uid != "user-123" || block != true {
 		t.Fatalf("unexpected block args: %v %v", uid, block)
 	}
+	if got := out.String(); strings.Index(got, "Timeline:") < strings.Index(got, "Exceeded policy") {
+		t.Fatalf("expected the timeline after the enforcement output, got:\n%s", got)
+	}
+}
+
+func TestMonitor_CombinesLinkedRandomizedMACs(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	oldMac := "daa119000000"
+	newMac := "3a0000000007"
+	totalIntervals := 96
+	rcvOld := buildMeasurements(totalIntervals, map[int]bool{0: true}, 100.0)
+	rcvNew := buildMeasurements(totalIntervals, map[int]bool{1: true}, 100.0)
+	snd := buildMeasurements(totalIntervals, map[int]bool{}, 0.0)
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + oldMac, Measurements: rcvOld},
+		{DataSourceName: "snd_" + oldMac, Measurements: snd},
+		{DataSourceName: "rcv_" + newMac, Measurements: rcvNew},
+		{DataSourceName: "snd_" + newMac, Measurements: snd},
+	}, nil)
+	oldPhone := fritzbox.Landevice{UID: "landevice1", MAC: "DA:A1:19:00:00:00", FriendlyName: "Annas-iPhone", UserUIDs: "user-1"}
+	newPhone := fritzbox.Landevice{UID: "landevice7", MAC: "3A:00:00:00:00:07", FriendlyName: "Annas-iPhone-2", UserUIDs: "user-1", Active: "1"}
+	fake.GetLandevicesReturns([]fritzbox.Landevice{oldPhone, newPhone}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1,landevice7"}, nil)
+
+	reg, err := identity.Load(filepath.Join(t.TempDir(), "identities.json"))
+	if err != nil {
+		t.Fatalf("load identities: %v", err)
+	}
+	reg.Link("Anna phone", oldPhone)
+
+	var out bytes.Buffer
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:   "irrelevant",
+			Password:   "irrelevant",
+			Period:     "day",
+			Out:        &out,
+			Identities: reg,
+			TestClient: fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected linked MACs to be reported as 1 device, got %d; output:\n%s", len(summary.Devices), out.String())
+	}
+	dev := summary.Devices[0]
+	if dev.Name != "Anna phone" || dev.MAC != newMac || dev.UID != "landevice7" {
+		t.Fatalf("unexpected device usage: %+v", dev)
+	}
+}
+
+func TestExplain_ReportsActiveIntervalsAndDecision(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	mac := "6c006b9068e9"
+	totalIntervals := 96
+	rcv := buildMeasurements(totalIntervals, map[int]bool{totalIntervals - 1: true}, 100.0)
+	snd := make([]float64, totalIntervals)
+	for i := range snd {
+		snd[i] = 5.0
+	}
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + mac, Measurements: rcv},
+		{DataSourceName: "snd_" + mac, Measurements: snd},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{MAC: mac, UserUIDs: "user-123", FriendlyName: "Tablet", Blocked: "1"}}, nil)
+
+	exp, err := monitor.Explain(
+		testingContext(),
+		monitor.Options{
+			Username:          "irrelevant",
+			Password:          "irrelevant",
+			Mac:               mac,
+			ActivityThreshold: 10.0,
+			PolicyString:      "MO-SU0",
+			TestClient:        fake,
+		},
+	)
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if !exp.RuleMatched || exp.Rule.String() != "MO-SU0" || !exp.Rule.IsRange() {
+		t.Fatalf("unexpected rule: %+v", exp.Rule)
+	}
+	if exp.QuotaMinutes != 0 || !exp.Blocked {
+		t.Fatalf("unexpected quota/blocked: %+v", exp)
+	}
+	if exp.Decision != "exceeded policy, device stays blocked" {
+		t.Fatalf("unexpected decision: %q", exp.Decision)
+	}
+	if fake.BlockDeviceCallCount() != 0 {
+		t.Fatalf("explain must not enforce, BlockDevice called %d times", fake.BlockDeviceCallCount())
+	}
+	for _, iv := range exp.Intervals {
+		if iv.Active && iv.Rcv != 100.0 {
+			t.Fatalf("unexpected active interval: %+v", iv)
+		}
+		if !iv.Active && iv.Snd != 5.0 {
+			t.Fatalf("unexpected inactive interval: %+v", iv)
+		}
+	}
+}
+
+func TestMonitor_AppliesWeeklyBudgetFromHistory(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	mac := "aa11bb22cc33"
+	rcv := buildMeasurements(96, map[int]bool{}, 0.0)
+	snd := buildMeasurements(96, map[int]bool{}, 0.0)
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + mac, Measurements: rcv},
+		{DataSourceName: "snd_" + mac, Measurements: snd},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{MAC: mac, UserUIDs: "user-1", FriendlyName: "iPad"}}, nil)
+
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	// Usage earlier this week, if today isn't Monday.
+	used := 0
+	now := time.Now()
+	weekday := (int(now.Weekday()) + 6) % 7
+	if weekday > 0 {
+		used = 550
+		yesterday := now.AddDate(0, 0, -1).Format(store.DateFormat)
+		if err := history.PutUsage(store.DayUsage{Date: yesterday, Device: mac, ActiveMinutes: used}); err != nil {
+			t.Fatalf("put usage: %v", err)
+		}
+	}
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Mac:          mac,
+			Period:       "day",
+			PolicyString: "MO-SU180WEEK600",
+			History:      history,
+			TestClient:   fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(summary.Devices))
+	}
+	dev := summary.Devices[0]
+	if want := min(180, 600-used); dev.QuotaMinutes != want {
+		t.Fatalf("expected quota %d, got %d", want, dev.QuotaMinutes)
+	}
+	if dev.WeeklyRemainingMinutes == nil || *dev.WeeklyRemainingMinutes != 600-used {
+		t.Fatalf("unexpected weekly remaining: %v", dev.WeeklyRemainingMinutes)
+	}
+	if dev.MonthlyRemainingMinutes != nil {
+		t.Fatalf("expected no monthly budget, got %d", *dev.MonthlyRemainingMinutes)
+	}
+	records, err := history.Usage(mac, now, now)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("expected today's usage to be recorded, got %v (%v)", records, err)
+	}
+}
+
+func TestMonitor_RecordsHistoryUnderOneKeyPerDevice(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+	mac := "aa11bb22cc33"
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + mac, Measurements: buildMeasurements(96, map[int]bool{95: true}, 5000)},
+		{DataSourceName: "snd_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"}}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+
+	// The same device addressed by MAC, by UID and found in the monitor.
+	for _, device := range []string{"AA:11:BB:22:CC:33", "landevice1", ""} {
+		_, err := monitor.Run(testingContext(), monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Mac:          device,
+			Period:       "day",
+			PolicyString: "MO-SU60",
+			History:      history,
+			TestClient:   fake,
+		})
+		if err != nil {
+			t.Fatalf("run for %q: %v", device, err)
+		}
+	}
+
+	now := time.Now()
+	records, err := history.Usage("", now, now)
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if len(records) != 1 || records[0].Device != mac || records[0].Name != "iPad" {
+		t.Fatalf("expected one record keyed by the MAC, got %+v", records)
+	}
+}
+
+func TestMonitor_AddsRewardsToQuota(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	mac := "aa11bb22cc33"
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "snd_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"}}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	for _, r := range []store.Reward{
+		{Time: time.Now(), Child: "ipad", Minutes: 30, Reason: "dishes"},
+		{Time: time.Now(), Child: "aa:11:bb:22:cc:33", Minutes: 15, Reason: "homework"},
+		{Time: time.Now(), Child: "Laptop", Minutes: 60, Reason: "other child"},
+		{Time: time.Now().AddDate(0, 0, -1), Child: "iPad", Minutes: 60, Reason: "yesterday"},
+	} {
+		if err := history.AddReward(r); err != nil {
+			t.Fatalf("add reward: %v", err)
+		}
+	}
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Period:       "day",
+			PolicyString: "MO-SU90",
+			History:      history,
+			TestClient:   fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(summary.Devices))
+	}
+	dev := summary.Devices[0]
+	if dev.BonusMinutes != 45 || dev.QuotaMinutes != 135 || len(dev.Rewards) != 2 {
+		t.Fatalf("unexpected bonus %d, quota %d, rewards %+v", dev.BonusMinutes, dev.QuotaMinutes, dev.Rewards)
+	}
+}
+
+func TestMonitor_AppliesPolicyDocumentPerChild(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "snd_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "rcv_aa11bb22cc44", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "snd_aa11bb22cc44", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{
+		{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"},
+		{UID: "landevice2", MAC: "AA:11:BB:22:CC:44", FriendlyName: "Laptop"},
+	}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1,landevice2"}, nil)
+
+	doc := &policy.Document{
+		Version: policy.DocumentVersion,
+		Default: &policy.Rules{Policy: "MO-SU30"},
+		Children: []policy.Child{{
+			Name:    "Anna",
+			Devices: []string{"ipad"},
+			Rules:   policy.Rules{Limits: policy.Limits{Days: map[string]int{"MO-SU": 120}}},
+		}},
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Period:       "day",
+			PolicyString: "MO-SU999",
+			Policies:     doc,
+			TestClient:   fake,
+		},
+	)
+
+	if len(summary.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d (errors %v)", len(summary.Devices), summary.Errors)
+	}
+	quotas := map[string]int{}
+	children := map[string]string{}
+	for _, d := range summary.Devices {
+		quotas[d.Name] = d.QuotaMinutes
+		children[d.Name] = d.Child
+	}
+	if quotas["iPad"] != 120 || children["iPad"] != "Anna" {
+		t.Errorf("expected iPad to get Anna's 120 minutes, got %d (%q)", quotas["iPad"], children["iPad"])
+	}
+	if quotas["Laptop"] != 30 || children["Laptop"] != "" {
+		t.Errorf("expected Laptop to get the default 30 minutes, got %d (%q)", quotas["Laptop"], children["Laptop"])
+	}
+}
+
+func TestMonitor_SharesChildBudgetBetweenDevices(t *testing.T) {
+	clock := &policyfakes.FakeClock{}
+	clock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)) // Wednesday
+
+	fake := &fritzboxfakes.FakeClient{}
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{92: true, 93: true, 94: true, 95: true}, 100.0)},
+		{DataSourceName: "snd_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "rcv_aa11bb22cc44", Measurements: buildMeasurements(96, map[int]bool{94: true, 95: true}, 100.0)},
+		{DataSourceName: "snd_aa11bb22cc44", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{
+		{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"},
+		{UID: "landevice2", MAC: "AA:11:BB:22:CC:44", FriendlyName: "Laptop"},
+	}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1,landevice2"}, nil)
+
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	for _, u := range []store.DayUsage{
+		{Date: "2026-10-13", Device: "aa11bb22cc33", Child: "Anna", ActiveMinutes: 200, QuotaMinutes: 120},
+		{Date: "2026-10-13", Device: "aa11bb22cc44", Child: "Anna", ActiveMinutes: 100, QuotaMinutes: 120},
+	} {
+		if err := history.PutUsage(u); err != nil {
+			t.Fatalf("put usage: %v", err)
+		}
+	}
+	doc := &policy.Document{
+		Version: policy.DocumentVersion,
+		Children: []policy.Child{{
+			Name:    "Anna",
+			Devices: []string{"iPad", "Laptop"},
+			Rules:   policy.Rules{Limits: policy.Limits{Days: map[string]int{"MO-SU": 80}, Week: 600}},
+		}},
+	}
+
+	// The second run sees today's usage of both devices.
+	var summary monitor.Summary
+	for range 2 {
+		summary, _ = monitor.Run(testingContext(), monitor.Options{
+			Username:   "irrelevant",
+			Password:   "irrelevant",
+			Period:     "day",
+			Policies:   doc,
+			History:    history,
+			Location:   time.UTC,
+			Clock:      clock,
+			TestClient: fake,
+		})
+	}
+
+	if len(summary.Devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d (errors %v)", len(summary.Devices), summary.Errors)
+	}
+	for _, d := range summary.Devices {
+		if d.UsedMinutes() != 90 {
+			t.Errorf("%s: expected Anna's 90 minutes on both devices to count, got %d (shared %d)", d.Name, d.UsedMinutes(), d.SharedMinutes)
+		}
+		if d.QuotaMinutes != 80 {
+			t.Errorf("%s: expected one daily quota of 80 minutes, got %d", d.Name, d.QuotaMinutes)
+		}
+		if d.WeeklyRemainingMinutes == nil || *d.WeeklyRemainingMinutes != 600-300-90 {
+			t.Errorf("%s: expected the weekly budget to be shared, got %v", d.Name, d.WeeklyRemainingMinutes)
+		}
+	}
+}
+
+func TestMonitor_BucketsDaysInTimezoneAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// Clocks moved forward at 02:00 today, so at 10:00 only 9 hours (36
+	// intervals) have passed since midnight.
+	clock := &policyfakes.FakeClock{}
+	clock.NowReturns(time.Date(2026, 3, 29, 10, 0, 0, 0, berlin).UTC())
+
+	fake := &fritzboxfakes.FakeClient{}
+	mac := "aa11bb22cc33"
+	active := map[int]bool{}
+	for i := 96 - 40; i < 96; i++ {
+		active[i] = true
+	}
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_" + mac, Measurements: buildMeasurements(96, active, 100.0)},
+		{DataSourceName: "snd_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"}}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Period:       "day",
+			PolicyString: "MO-SA60SU120",
+			Location:     berlin,
+			Clock:        clock,
+			TestClient:   fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d (errors %v)", len(summary.Devices), summary.Errors)
+	}
+	dev := summary.Devices[0]
+	if dev.DailyActiveMinutes != 36*15 {
+		t.Errorf("expected %d active minutes since local midnight, got %d", 36*15, dev.DailyActiveMinutes)
+	}
+	if dev.QuotaMinutes != 120 {
+		t.Errorf("expected the Sunday quota of 120 minutes, got %d", dev.QuotaMinutes)
+	}
+	if dev.Precision != monitor.PrecisionQuarterHour {
+		t.Errorf("expected 15-minute precision without per-minute data, got %s", dev.Precision)
+	}
+	if !strings.HasSuffix(dev.Active[0], "+01:00/PT9H") {
+		t.Errorf("expected one block starting at local midnight, got %v", dev.Active)
+	}
+}
+
+func TestMonitor_UsesActivityClassifierPerDeviceType(t *testing.T) {
+	fake := &fritzboxfakes.FakeClient{}
+
+	// Both devices send 200 B/s of background traffic in every interval.
+	background := buildMeasurements(96, map[int]bool{}, 0.0)
+	for i := range background {
+		background[i] = 200
+	}
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_aa11bb22cc33", Measurements: background},
+		{DataSourceName: "snd_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		{DataSourceName: "rcv_aa11bb22cc44", Measurements: background},
+		{DataSourceName: "snd_aa11bb22cc44", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{
+		{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "Anna phone"},
+		{UID: "landevice2", MAC: "AA:11:BB:22:CC:44", FriendlyName: "Laptop"},
+	}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1,landevice2"}, nil)
+
+	activity, err := monitor.ActivityConfig{
+		Types:   map[string]monitor.ClassifierSpec{"phone": {Mode: "hysteresis", Rcv: 2000, Snd: 500, OffRcv: 500, OffSnd: 100}},
+		Devices: map[string]string{"aa:11:bb:22:cc:33": "phone"},
+	}.Build(monitor.ThresholdClassifier{Thresholds: monitor.Thresholds{Rcv: 10, Snd: 10}})
+	if err != nil {
+		t.Fatalf("build classifiers: %v", err)
+	}
+
+	clock := &policyfakes.FakeClock{}
+	clock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local))
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:   "irrelevant",
+			Password:   "irrelevant",
+			Period:     "day",
+			Activity:   activity,
+			Clock:      clock,
+			TestClient: fake,
+		},
+	)
+
+	minutes := map[string]int{}
+	for _, d := range summary.Devices {
+		minutes[d.Name] = d.DailyActiveMinutes
+	}
+	if minutes["Anna phone"] != 0 {
+		t.Errorf("expected the phone's background traffic to be idle, got %d minutes", minutes["Anna phone"])
+	}
+	if minutes["Laptop"] == 0 {
+		t.Errorf("expected the laptop to use the default threshold and count as active")
+	}
+}
+
+func TestMonitor_AppliesLearnedIdleBaseline(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)
+	clock := &policyfakes.FakeClock{}
+	clock.NowReturns(now)
+
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	// A week of nights with 300 B/s of background sync between 01:00 and 05:00.
+	var samples []store.Sample
+	for d := 1; d <= 7; d++ {
+		night := time.Date(2026, 10, 14-d, 1, 0, 0, 0, time.Local)
+		for i := 0; i < 16; i++ {
+			samples = append(samples, store.Sample{Start: night.Add(time.Duration(i) * 15 * time.Minute), Device: "iPad", Rcv: 300, Snd: 20})
+		}
+	}
+	if err := history.PutSamples(samples, now); err != nil {
+		t.Fatalf("put samples: %v", err)
+	}
+
+	baselines, err := monitor.LearnBaselines(history, "", now, monitor.DefaultBaselineOptions)
+	if err != nil {
+		t.Fatalf("learn baselines: %v", err)
+	}
+	if len(baselines) != 1 || !baselines[0].Valid || baselines[0].Suggested != (monitor.Thresholds{Rcv: 450, Snd: 30}) {
+		t.Fatalf("unexpected baselines: %+v", baselines)
+	}
+
+	// Background traffic all day, plus 4 intervals of streaming.
+	rcv := buildMeasurements(96, map[int]bool{}, 0.0)
+	for i := range rcv {
+		rcv[i] = 300
+	}
+	for i := 92; i < 96; i++ {
+		rcv[i] = 5000
+	}
+	fake := &fritzboxfakes.FakeClient{}
+	fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+		{DataSourceName: "rcv_aa11bb22cc33", Measurements: rcv},
+		{DataSourceName: "snd_aa11bb22cc33", Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+	}, nil)
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"}}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:          "irrelevant",
+			Password:          "irrelevant",
+			Period:            "day",
+			ActivityThreshold: 10,
+			AutoThreshold:     true,
+			History:           history,
+			Clock:             clock,
+			TestClient:        fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d (errors %v)", len(summary.Devices), summary.Errors)
+	}
+	if got := summary.Devices[0].DailyActiveMinutes; got != 60 {
+		t.Errorf("expected only the streaming hour to count, got %d minutes", got)
+	}
+	stored, err := history.Samples("iPad", now.Add(-time.Hour), now)
+	if err != nil || len(stored) != 4 {
+		t.Errorf("expected the run to store the last hour's samples, got %d (%v)", len(stored), err)
+	}
+}
+
+func TestMonitor_CountsRecentHourPerMinute(t *testing.T) {
+	clock := &policyfakes.FakeClock{}
+	clock.NowReturns(time.Date(2026, 10, 14, 12, 7, 30, 0, time.UTC))
+
+	// In 15-minute data the intervals from 11:00 to 12:00 are all active.
+	quarters := buildMeasurements(96, map[int]bool{91: true, 92: true, 93: true, 94: true, 95: true}, 100.0)
+	// The last hour per minute starts at 11:08, so the intervals from 11:15
+	// on are counted per minute: only 11:20-11:23 was active. The activity
+	// at 11:10 is already part of the 11:00 interval.
+	minutes := buildMeasurements(60, map[int]bool{2: true, 12: true, 13: true, 14: true}, 100.0)
+	zeros := func(n int) []float64 { return buildMeasurements(n, map[int]bool{}, 0.0) }
+
+	fake := &fritzboxfakes.FakeClient{}
+	fake.GetMonitorDataStub = func(dataset, subset string) ([]fritzbox.SubsetData, error) {
+		if subset == "subset0001" {
+			return []fritzbox.SubsetData{
+				{DataSourceName: "rcv_aa11bb22cc33", Measurements: minutes},
+				{DataSourceName: "snd_aa11bb22cc33", Measurements: zeros(60)},
+			}, nil
+		}
+		return []fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: quarters},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: zeros(96)},
+		}, nil
+	}
+	fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"}}, nil)
+	fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+
+	summary, _ := monitor.Run(
+		testingContext(),
+		monitor.Options{
+			Username:   "irrelevant",
+			Password:   "irrelevant",
+			Period:     "day",
+			Location:   time.UTC,
+			Clock:      clock,
+			TestClient: fake,
+		},
+	)
+
+	if len(summary.Devices) != 1 {
+		t.Fatalf("expected 1 device, got %d (errors %v)", len(summary.Devices), summary.Errors)
+	}
+	dev := summary.Devices[0]
+	if dev.DailyActiveMinutes != 18 || dev.Precision != monitor.PrecisionMinute {
+		t.Errorf("expected 18 minutes at minute precision, got %d (%s)", dev.DailyActiveMinutes, dev.Precision)
+	}
+	want := []string{"11:00+00:00/PT15M", "11:20+00:00/PT3M"}
+	if fmt.Sprint(dev.Active) != fmt.Sprint(want) {
+		t.Errorf("expected active blocks %v, got %v", want, dev.Active)
+	}
+}
+
+func TestMonitor_EnforcesWithGracePeriodAndKeepsBlockUntilNextDay(t *testing.T) {
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	clock := &policyfakes.FakeClock{}
+	mac := "aa11bb22cc33"
+	landevice := fritzbox.Landevice{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad", UserUIDs: "user1"}
+
+	// run monitors at the given time with the device active in the given
+	// number of 15-minute intervals before it.
+	enforce := true
+	run := func(at time.Time, activeIntervals int, blocked bool, policyStr string) (*fritzboxfakes.FakeClient, string) {
+		clock.NowReturns(at)
+		active := map[int]bool{}
+		for i := 96 - activeIntervals; i < 96; i++ {
+			active[i] = true
+		}
+		fake := &fritzboxfakes.FakeClient{}
+		fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_" + mac, Measurements: buildMeasurements(96, active, 100.0)},
+			{DataSourceName: "snd_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		}, nil)
+		dev := landevice
+		if blocked {
+			dev.Blocked = "1"
+		}
+		fake.GetLandevicesReturns([]fritzbox.Landevice{dev}, nil)
+		fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+		var out bytes.Buffer
+		_, _ = monitor.Run(testingContext(), monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Period:       "day",
+			PolicyString: policyStr,
+			Enforce:      enforce,
+			GracePeriod:  10 * time.Minute,
+			History:      history,
+			Clock:        clock,
+			Location:     time.UTC,
+			TestClient:   fake,
+			Out:          &out,
+		})
+		return fake, out.String()
+	}
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	// The grace period starts while only reporting and continues once
+	// enforcement is switched on.
+	enforce = false
+	fake, out := run(noon, 4, false, "MO-SU60")
+	if fake.BlockDeviceCallCount() != 0 || !strings.Contains(out, "grace period") {
+		t.Fatalf("expected no block during the grace period, output:\n%s", out)
+	}
+	enforce = true
+	fake, out = run(noon.Add(15*time.Minute), 5, false, "MO-SU60")
+	if fake.BlockDeviceCallCount() != 1 {
+		t.Fatalf("expected a block after the grace period, output:\n%s", out)
+	}
+	if uid, block := fake.BlockDeviceArgsForCall(0); uid != "user1" || !block {
+		t.Fatalf("unexpected BlockDevice(%s, %t)", uid, block)
+	}
+
+	// A higher quota the same day does not unblock, a reward does.
+	fake, out = run(noon.Add(30*time.Minute), 5, true, "MO-SU120")
+	if fake.BlockDeviceCallCount() != 0 || !strings.Contains(out, "next policy day") {
+		t.Fatalf("expected the device to stay blocked, output:\n%s", out)
+	}
+	if err := history.AddReward(store.Reward{Time: noon.Add(40 * time.Minute), Child: "iPad", Minutes: 30, Reason: "dishes"}); err != nil {
+		t.Fatalf("add reward: %v", err)
+	}
+	fake, out = run(noon.Add(45*time.Minute), 5, true, "MO-SU60")
+	if fake.BlockDeviceCallCount() != 1 {
+		t.Fatalf("expected the reward to unblock the device, output:\n%s", out)
+	}
+	if _, block := fake.BlockDeviceArgsForCall(0); block {
+		t.Fatalf("expected an unblock")
+	}
+
+	entries, err := history.Audit("iPad", noon, noon.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != "block" || entries[1].Action != "unblock" {
+		t.Fatalf("unexpected audit log: %+v", entries)
+	}
+	if entries[0].Actor != store.ActorPolicy || entries[0].Reason != "quota of 60 minutes reached (75 active)" ||
+		entries[0].Device != mac || entries[0].Name != "iPad" {
+		t.Fatalf("unexpected audit entry: %+v", entries[0])
+	}
 }
 
 func testingContext() (ctx context.Context) {
 	return context.Background()
 }
+
+func TestExplain_FollowsGracePeriodAndKeptBlock(t *testing.T) {
+	history, err := store.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	clock := &policyfakes.FakeClock{}
+	mac := "aa11bb22cc33"
+
+	// options returns the options at the given time with the device active in
+	// the last 5 intervals.
+	options := func(at time.Time, blocked bool, policyStr string) (monitor.Options, *fritzboxfakes.FakeClient) {
+		clock.NowReturns(at)
+		active := map[int]bool{91: true, 92: true, 93: true, 94: true, 95: true}
+		fake := &fritzboxfakes.FakeClient{}
+		fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_" + mac, Measurements: buildMeasurements(96, active, 100.0)},
+			{DataSourceName: "snd_" + mac, Measurements: buildMeasurements(96, map[int]bool{}, 0.0)},
+		}, nil)
+		dev := fritzbox.Landevice{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad", UserUIDs: "user1"}
+		if blocked {
+			dev.Blocked = "1"
+		}
+		fake.GetLandevicesReturns([]fritzbox.Landevice{dev}, nil)
+		fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+		return monitor.Options{
+			Username:     "irrelevant",
+			Password:     "irrelevant",
+			Period:       "day",
+			Mac:          mac,
+			PolicyString: policyStr,
+			GracePeriod:  10 * time.Minute,
+			History:      history,
+			Clock:        clock,
+			Location:     time.UTC,
+			TestClient:   fake,
+			Out:          io.Discard,
+		}, fake
+	}
+	noon := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	opts, _ := options(noon, false, "MO-SU60")
+	if _, err := monitor.Run(testingContext(), opts); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	opts, _ = options(noon.Add(4*time.Minute), false, "MO-SU60")
+	exp, err := monitor.Explain(testingContext(), opts)
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if exp.Decision != "exceeded policy, device would be blocked in 6m0s (grace period)" {
+		t.Fatalf("unexpected decision during the grace period: %q", exp.Decision)
+	}
+
+	opts, fake := options(noon.Add(15*time.Minute), false, "MO-SU60")
+	opts.Enforce = true
+	if _, err := monitor.Run(testingContext(), opts); err != nil || fake.BlockDeviceCallCount() != 1 {
+		t.Fatalf("expected a block after the grace period: %v", err)
+	}
+	opts, _ = options(noon.Add(30*time.Minute), true, "MO-SU120")
+	exp, err = monitor.Explain(testingContext(), opts)
+	if err != nil {
+		t.Fatalf("explain failed: %v", err)
+	}
+	if !strings.HasPrefix(exp.Decision, "within policy, device stays blocked: quota reached today") {
+		t.Fatalf("unexpected decision for a kept block: %q", exp.Decision)
+	}
+}
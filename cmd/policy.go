@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/calendar"
+	"home-gate/internal/policy"
+	"home-gate/pkg/monitor"
+)
+
+// policyCmd groups the policy related commands
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Inspect parental control policies",
+}
+
+// policyExplainCmd explains today's policy decision for a device
+var policyExplainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain today's policy decision for a device",
+	Long: `Show which policy rule matched today, the quota, every active 15-minute
+interval with its receive/send rates compared to the activity threshold, and
+the resulting block decision, following the grace period and kept blocks of
+the enforcement state. Nothing is enforced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runPolicyExplain()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyExplainCmd)
+
+	policyExplainCmd.Flags().String("username", "", "Fritzbox username")
+	policyExplainCmd.Flags().String("password", "", "Fritzbox password")
+	policyExplainCmd.Flags().String("mac", "", "MAC address, landevice UID or linked device name to explain")
+	policyExplainCmd.Flags().Float64("activity-threshold", 0, "Minimum Byte/s to consider interval active")
+	policyExplainCmd.Flags().Bool("auto-threshold", false, "Use the idle baseline learned per device (see calibrate) instead of --activity-threshold")
+	policyExplainCmd.Flags().String("policy", "", "Policy string for allowed minutes per day")
+	policyExplainCmd.Flags().String("policy-file", "", "YAML or JSON policy document, takes precedence over --policy")
+	policyExplainCmd.Flags().Duration("grace-period", 0, "Delay blocking this long after the quota is reached")
+	policyExplainCmd.Flags().Duration("min-block-duration", 0, "Keep a blocked device blocked at least this long")
+	policyExplainCmd.Flags().Bool("all", false, "Show all intervals of today, not only active ones")
+	addOverrideFlags(policyExplainCmd)
+
+	_ = viper.BindEnv("username", "FRITZBOX_USERNAME")
+	_ = viper.BindEnv("password", "FRITZBOX_PASSWORD")
+}
+
+func runPolicyExplain() {
+	exp, err := monitor.Explain(context.Background(), viper.GetString("mac"), monitorOptions()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Explain error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Device: %s (%s)\n", exp.Name, exp.MAC)
+	if exp.Child != "" {
+		fmt.Printf("Child: %s\n", exp.Child)
+	}
+	switch {
+	case !exp.RuleMatched:
+		fmt.Println("Rule: no rule covers today, defaulting to 0 minutes")
+	case exp.RuleRange:
+		fmt.Printf("Rule: %s (day range)\n", exp.Rule)
+	default:
+		fmt.Printf("Rule: %s (single day)\n", exp.Rule)
+	}
+	if exp.Override != "" {
+		fmt.Printf("Override: %s\n", exp.Override)
+	}
+	if exp.BankMinutes > 0 {
+		fmt.Printf("Banked: %d minutes\n", exp.BankMinutes)
+	}
+	for _, r := range exp.Rewards {
+		fmt.Printf("Reward: %+d minutes (%s)\n", r.Minutes, r.Reason)
+	}
+	fmt.Printf("Quota: %d minutes\n", exp.QuotaMinutes)
+	fmt.Printf("Activity: %s\n\n", exp.Classifier)
+
+	all := viper.GetBool("all")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(tw, "INTERVAL\tRCV (B/s)\tSND (B/s)\tACTIVE\t")
+	for _, iv := range exp.Intervals {
+		if !all && !iv.Active {
+			continue
+		}
+		_, _ = fmt.Fprintf(tw, "%s-%s\t%.1f\t%.1f\t%t\t\n",
+			iv.Start.Format("15:04"), iv.Start.Add(15*time.Minute).Format("15:04"), iv.Rcv, iv.Snd, iv.Active)
+	}
+	_ = tw.Flush()
+
+	fmt.Printf("\nActive today: %d minutes of %d allowed (precision %s)\n", exp.ActiveMinutes+exp.SharedMinutes, exp.QuotaMinutes, exp.Precision)
+	if exp.SharedMinutes > 0 {
+		fmt.Printf("Of which %d minutes on %s's other devices\n", exp.SharedMinutes, exp.Child)
+	}
+	if !exp.InWindow {
+		fmt.Println("Outside allowed time window")
+	}
+	fmt.Printf("Currently blocked: %t\n", exp.Blocked)
+	fmt.Printf("Decision: %s\n", exp.Decision)
+}
+
+// policyDocument loads the structured policy document from --policy-file, or
+// from the "policies" section of the config file. It returns nil if neither
+// is set, in which case the --policy string applies.
+func policyDocument() (*policy.Document, error) {
+	if path := viper.GetString("policy-file"); path != "" {
+		return policy.LoadDocument(path)
+	}
+	if viper.IsSet("policies") {
+		return policy.DecodeDocument(viper.GetViper(), "policies")
+	}
+	return nil, nil
+}
+
+// policyOverrides builds the date-based policy overrides from the --override
+// ("FROM[..TO]=POLICY") and --calendar ("FILE.ics=POLICY") settings. Every
+// event of a calendar applies its policy on the days it covers.
+func policyOverrides() ([]policy.Override, error) {
+	var overrides []policy.Override
+	for _, spec := range viper.GetStringSlice("override") {
+		o, err := policy.ParseOverride(spec, location())
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	for _, spec := range viper.GetStringSlice("calendar") {
+		path, policyStr, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("calendar %q: expected FILE.ics=POLICY", spec)
+		}
+		events, err := calendar.LoadFile(path, location())
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			from, to := e.DaysIn(location())
+			o, err := policy.NewOverride(from, to, policyStr, e.Summary)
+			if err != nil {
+				return nil, fmt.Errorf("calendar %s: %w", path, err)
+			}
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides, nil
+}
+
+// addOverrideFlags defines the flags read by policyOverrides.
+func addOverrideFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("override", nil, "Date-based policy override FROM[..TO]=POLICY, e.g. 2026-12-24..2027-01-06=MO-SU180 (repeatable)")
+	cmd.Flags().StringArray("calendar", nil, "iCalendar file whose events switch the policy, FILE.ics=POLICY (repeatable)")
+}
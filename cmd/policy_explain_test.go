@@ -0,0 +1,59 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPolicyExplain_ReadsRoutersActivityAndPolicySection(t *testing.T) {
+	var blocks atomic.Int32
+	router := fakeFritzbox(t, &blocks)
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	yaml := "routers:\n  - name: home\n    url: " + router.URL + "\n    username: admin\n    password: secret\n" +
+		"activity:\n  types:\n    tablet: {rcv: 10, snd: 10}\n  devices:\n    tablet: tablet\n" +
+		"policies:\n  version: 1\n  children:\n    - name: Lena\n      devices: [tablet]\n      policy: MO-SU45\n"
+	if err := os.WriteFile(config, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := startCommand(t, "policy", "explain", "--config", config, "--mac", "AA:11:BB:22:CC:33")
+	if code := p.wait(t); code != 0 {
+		t.Fatalf("expected policy explain to succeed, got %d: %q", code, p.stdout.String())
+	}
+	out := p.stdout.String()
+	for _, want := range []string{
+		"Device: AA:11:BB:22:CC:33 (aa11bb22cc33)",
+		"Child: Lena",
+		"Rule: MO-SU45 (day range)",
+		"Activity: threshold 10 B/s",
+		"Decision: within policy, no action",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output, got %q", want, out)
+		}
+	}
+}
+
+func TestMonitor_RunsWithRoutersFromConfig(t *testing.T) {
+	var blocks atomic.Int32
+	router := fakeFritzbox(t, &blocks)
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	routers := "routers:\n  - name: home\n    url: " + router.URL + "\n    username: admin\n    password: secret\n"
+	if err := os.WriteFile(config, []byte(routers), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := startCommand(t, "monitor", "--config", config, "--policy", "MO-SU0", "--enforce")
+	if code := p.wait(t); code != 0 {
+		t.Fatalf("expected monitor to succeed, got %d: %q", code, p.stdout.String())
+	}
+	if out := p.stdout.String(); !strings.Contains(out, "Monitoring done: checked 1 devices") {
+		t.Errorf("expected the summary, got %q", out)
+	}
+	if n := blocks.Load(); n != 1 {
+		t.Errorf("expected the tablet to be blocked once, got %d requests", n)
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/pkg/policy"
+)
+
+// policyLintCmd validates a policy string or document
+var policyLintCmd = &cobra.Command{
+	Use:   "lint [policy]",
+	Short: "Validate a policy string or policy document",
+	Long: `Validate a policy string (given as argument or via --policy) and report
+syntax errors with their position, overlapping rules together with the rule
+that takes precedence, and days without a rule (which are fully blocked).
+
+With --policy-file, validate a YAML or JSON policy document instead and lint
+the limits of its default rules and of every child.
+
+When rules overlap, the rule covering fewer days wins (a single day beats a
+range); between equally specific rules the later one wins.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		if viper.GetString("policy-file") != "" {
+			runPolicyLintDocument()
+			return
+		}
+		policyStr := viper.GetString("policy")
+		if len(args) == 1 {
+			policyStr = args[0]
+		}
+		runPolicyLint(policyStr)
+	},
+}
+
+// policySchemaCmd prints the JSON Schema of the policy document
+var policySchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the policy document",
+	Run: func(cmd *cobra.Command, args []string) {
+		_, _ = os.Stdout.Write(policy.Schema())
+	},
+}
+
+func init() {
+	policyCmd.AddCommand(policyLintCmd)
+	policyCmd.AddCommand(policySchemaCmd)
+
+	policyLintCmd.Flags().String("policy", "", "Policy string for allowed minutes per day")
+	policyLintCmd.Flags().String("policy-file", "", "YAML or JSON policy document to validate")
+	policyLintCmd.Flags().Bool("strict", false, "Fail on warnings as well as errors")
+}
+
+func runPolicyLint(policyStr string) {
+	if policyStr == "" {
+		fmt.Fprintln(os.Stderr, "a policy is required")
+		os.Exit(1)
+	}
+	issues := policy.Lint(policyStr)
+	failed := false
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Severity == policy.SeverityError || viper.GetBool("strict") {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	if len(issues) == 0 {
+		fmt.Println("Policy OK")
+	}
+}
+
+func runPolicyLintDocument() {
+	doc, err := policy.LoadDocument(viper.GetString("policy-file"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	issues := doc.Lint()
+	failed := false
+	for _, issue := range issues {
+		name := issue.Child
+		if name == "" {
+			name = "default"
+		}
+		fmt.Printf("%s: %s\n", name, issue)
+		if issue.Severity == policy.SeverityError || viper.GetBool("strict") {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	if len(issues) == 0 {
+		fmt.Println("Policy document OK")
+	}
+}
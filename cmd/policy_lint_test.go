@@ -0,0 +1,33 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicyLint_DocumentOKOnlyWithoutIssues(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.yaml")
+	warned := filepath.Join(dir, "warned.yaml")
+	for path, policy := range map[string]string{clean: "MO-SU60", warned: "MO-FR60"} {
+		if err := os.WriteFile(path, []byte("version: 1\ndefault:\n  policy: "+policy+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := startCommand(t, "policy", "lint", "--policy-file", clean)
+	if code := p.wait(t); code != 0 || !strings.Contains(p.stdout.String(), "Policy document OK") {
+		t.Fatalf("expected the document to pass, got %d: %q", code, p.stdout.String())
+	}
+
+	p = startCommand(t, "policy", "lint", "--policy-file", warned)
+	if code := p.wait(t); code != 0 {
+		t.Fatalf("expected warnings not to fail, got %d", code)
+	}
+	out := p.stdout.String()
+	if !strings.Contains(out, "default: warning") || strings.Contains(out, "OK") {
+		t.Fatalf("expected the warning without OK, got %q", out)
+	}
+}
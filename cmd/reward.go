@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"home-gate/internal/store"
+)
+
+// rewardCmd awards earned bonus minutes to a child
+var rewardCmd = &cobra.Command{
+	Use:   "reward",
+	Short: "Award bonus minutes for today",
+	Long: `Award bonus minutes (e.g. for chores) on top of today's quota. The child is
+matched against the device name (or linked device name) and MAC address.
+Negative minutes take time away.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runReward()
+	},
+}
+
+// rewardListCmd shows the reward history
+var rewardListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show the reward history",
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = viper.BindPFlags(cmd.Flags())
+		runRewardList()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rewardCmd)
+	rewardCmd.AddCommand(rewardListCmd)
+
+	rewardCmd.Flags().String("child", "", "Device name or MAC address to reward")
+	rewardCmd.Flags().Int("minutes", 0, "Bonus minutes to award")
+	rewardCmd.Flags().String("reason", "", "Reason for the reward")
+
+	rewardListCmd.Flags().String("child", "", "Only show rewards for this device name or MAC address")
+	rewardListCmd.Flags().Int("days", 7, "Number of days to show")
+}
+
+func runReward() {
+	reward := store.Reward{
+		Time:    time.Now(),
+		Child:   viper.GetString("child"),
+		Minutes: viper.GetInt("minutes"),
+		Reason:  viper.GetString("reason"),
+	}
+	if err := openStore().AwardReward(reward, store.ActorCLI); err != nil {
+		fmt.Fprintf(os.Stderr, "Reward error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Awarded %+d minutes to %s\n", reward.Minutes, reward.Child)
+}
+
+func runRewardList() {
+	days := viper.GetInt("days")
+	if days < 1 {
+		fmt.Fprintln(os.Stderr, "days must be positive")
+		os.Exit(1)
+	}
+	now := time.Now().In(location())
+	rewards, err := openStore().Rewards(viper.GetString("child"), now.AddDate(0, 0, 1-days), now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reward error: %v\n", err)
+		os.Exit(1)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TIME\tCHILD\tMINUTES\tREASON")
+	for _, r := range rewards {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%+d\t%s\n", r.Time.Format("2006-01-02 15:04"), r.Child, r.Minutes, r.Reason)
+	}
+	_ = tw.Flush()
+}
@@ -3,9 +3,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"home-gate/internal/store"
 )
 
 var cfgFile string
@@ -35,6 +38,12 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.home-gate.yaml)")
+	rootCmd.PersistentFlags().String("data-dir", "", "directory for persisted state (default is $HOME/.home-gate)")
+	_ = viper.BindPFlag("data-dir", rootCmd.PersistentFlags().Lookup("data-dir"))
+	_ = viper.BindEnv("data-dir", "HOME_GATE_DATA_DIR")
+	rootCmd.PersistentFlags().String("timezone", "", "IANA time zone that decides when a day starts, e.g. Europe/Berlin (default is the local time zone)")
+	_ = viper.BindPFlag("timezone", rootCmd.PersistentFlags().Lookup("timezone"))
+	_ = viper.BindEnv("timezone", "HOME_GATE_TIMEZONE")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -64,3 +73,45 @@ func initConfig() {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// dataDir returns the directory for persisted state.
+func dataDir() string {
+	dir := viper.GetString("data-dir")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+		dir = filepath.Join(home, ".home-gate")
+	}
+	return dir
+}
+
+// dataFile returns the path of a file inside the data directory.
+func dataFile(name string) string {
+	return filepath.Join(dataDir(), name)
+}
+
+// location returns the time zone from --timezone, or the local time zone.
+func location() *time.Location {
+	loc, err := loadLocation()
+	cobra.CheckErr(err)
+	return loc
+}
+
+func loadLocation() (*time.Location, error) {
+	name := viper.GetString("timezone")
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return loc, nil
+}
+
+// openStore opens the persistent store in the data directory.
+func openStore() *store.Store {
+	s, err := store.Open(dataDir())
+	cobra.CheckErr(err)
+	return s
+}
@@ -2,19 +2,34 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"home-gate/internal/api"
+	v1 "home-gate/internal/api/v1"
+	"home-gate/internal/identity"
 	"home-gate/internal/monitor"
+	"home-gate/internal/policy"
+	"home-gate/internal/scheduler"
 	"home-gate/internal/state"
+	"home-gate/internal/store"
 	"home-gate/web"
 )
 
@@ -32,128 +47,687 @@ func init() {
 	webCmd.Flags().String("mac", "", "MAC address to query usage for (optional)")
 	webCmd.Flags().String("period", "day", "Period to query: hour or day")
 	webCmd.Flags().Float64("activity-threshold", 0, "Minimum Byte/s to consider interval active")
+	webCmd.Flags().Bool("auto-threshold", false, "Use the idle baseline learned per device (see calibrate) instead of --activity-threshold")
 	webCmd.Flags().String("policy", "", "Policy string for allowed minutes per day")
+	webCmd.Flags().String("policy-file", "", "YAML or JSON policy document, takes precedence over --policy")
 	webCmd.Flags().Bool("enforce", false, "Enforce policy by blocking devices that exceed limits")
+	webCmd.Flags().Duration("grace-period", 0, "Delay blocking this long after the quota is reached")
+	webCmd.Flags().Duration("min-block-duration", 0, "Keep a blocked device blocked at least this long")
 	webCmd.Flags().Duration("interval", 5*time.Minute, "Interval between monitoring runs (default 5m)")
+	webCmd.Flags().Duration("landevices-interval", 10*time.Minute, "Interval between fetches of the device list")
+	webCmd.Flags().Duration("hour-interval", time.Minute, "Interval between fetches of the per-minute data of the last hour")
+	webCmd.Flags().Duration("jitter", 5*time.Second, "Delay each job by a random duration up to this long")
+	webCmd.Flags().String("listen", defaultListen, "Address to serve the web UI/API on: host:port, or unix:/path/to.sock for a unix socket")
+	webCmd.Flags().String("tls-cert", "", "TLS certificate file (PEM) to serve HTTPS, together with --tls-key")
+	webCmd.Flags().String("tls-key", "", "TLS private key file (PEM) for --tls-cert")
+	webCmd.Flags().Duration("shutdown-timeout", 30*time.Second, "How long to wait for requests and running jobs to finish on shutdown")
+	webCmd.Flags().String("api-token", "", "Token required as \"Authorization: Bearer <token>\" to change state through the API")
+	addOverrideFlags(webCmd)
 
 	_ = viper.BindPFlag("username", webCmd.Flags().Lookup("username"))
 	_ = viper.BindPFlag("password", webCmd.Flags().Lookup("password"))
 	_ = viper.BindPFlag("mac", webCmd.Flags().Lookup("mac"))
 	_ = viper.BindPFlag("period", webCmd.Flags().Lookup("period"))
 	_ = viper.BindPFlag("activity-threshold", webCmd.Flags().Lookup("activity-threshold"))
+	_ = viper.BindPFlag("auto-threshold", webCmd.Flags().Lookup("auto-threshold"))
 	_ = viper.BindPFlag("policy", webCmd.Flags().Lookup("policy"))
+	_ = viper.BindPFlag("policy-file", webCmd.Flags().Lookup("policy-file"))
 	_ = viper.BindPFlag("enforce", webCmd.Flags().Lookup("enforce"))
+	_ = viper.BindPFlag("grace-period", webCmd.Flags().Lookup("grace-period"))
+	_ = viper.BindPFlag("min-block-duration", webCmd.Flags().Lookup("min-block-duration"))
 	_ = viper.BindPFlag("interval", webCmd.Flags().Lookup("interval"))
+	_ = viper.BindPFlag("landevices-interval", webCmd.Flags().Lookup("landevices-interval"))
+	_ = viper.BindPFlag("hour-interval", webCmd.Flags().Lookup("hour-interval"))
+	_ = viper.BindPFlag("jitter", webCmd.Flags().Lookup("jitter"))
+	_ = viper.BindPFlag("listen", webCmd.Flags().Lookup("listen"))
+	_ = viper.BindPFlag("tls-cert", webCmd.Flags().Lookup("tls-cert"))
+	_ = viper.BindPFlag("tls-key", webCmd.Flags().Lookup("tls-key"))
+	_ = viper.BindPFlag("shutdown-timeout", webCmd.Flags().Lookup("shutdown-timeout"))
+	_ = viper.BindPFlag("api-token", webCmd.Flags().Lookup("api-token"))
 
 	_ = viper.BindEnv("username", "FRITZBOX_USERNAME")
 	_ = viper.BindEnv("password", "FRITZBOX_PASSWORD")
+	_ = viper.BindEnv("api-token", "HOME_GATE_API_TOKEN")
 }
 
 // Static files are now embedded via the web package.
 
 func runWeb(cmd *cobra.Command, args []string) {
 	_ = viper.BindPFlags(cmd.Flags()) // Re-bind to ensure flag values are correct
-	interval := viper.GetDuration("interval")
-	if interval <= 0 {
-		fmt.Fprintln(os.Stderr, "Interval must be positive, got", interval)
+	var reload reloader
+	if result := reload.reload("startup"); !result.OK {
+		fmt.Fprintln(os.Stderr, "Configuration error:", result.Error)
 		os.Exit(1)
 	}
+	history, err := store.Open(dataDir())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to open data directory:", err)
+		os.Exit(1)
+	}
+	d := &daemon{reload: &reload, history: history}
+	sched, err := d.scheduler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Configuration error:", err)
+		os.Exit(1)
+	}
+	reload.onLoad = func(cfg *webConfig) { d.setIntervals(sched, cfg) }
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Start HTTP API server alongside monitor
+	// From here on the configuration is only read by the reloader, see watch.
+	shutdownTimeout := viper.GetDuration("shutdown-timeout")
+	server, ln, err := newWebServer(webMux(history, &reload, sched))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[web] failed to start the HTTP server:", err)
+		os.Exit(1)
+	}
+	serveErr := make(chan error, 1)
 	go func() {
-		mux := http.NewServeMux()
-		mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Content-Type", "application/json")
-			status := state.Get()
-			if err := json.NewEncoder(w).Encode(status); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-			}
+		serveErr <- serveWeb(server, ln)
+	}()
+
+	reload.watch(ctx)
+	sched.Start(ctx)
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "\nReceived interrupt, shutting down")
+	case err := <-serveErr:
+		fmt.Fprintln(os.Stderr, "[web] HTTP server error:", err)
+		stop()
+	}
+	shutdownWeb(server, sched, shutdownTimeout)
+}
+
+// webMux routes the API, /status and the dashboard. The API reads the time
+// zone and the API token of the configuration in effect for each request, so
+// a reload changes them too.
+func webMux(history *store.Store, reload *reloader, sched *scheduler.Scheduler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		status := state.Get()
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	// Reloads through the API are audited, like rewards.
+	apiReload := func(source string) state.Reload {
+		result := reload.reload(source)
+		entry := store.AuditEntry{Time: result.Time, Action: store.ActionReload, Actor: store.ActorAPI, Reason: result.Policy}
+		if !result.OK {
+			entry.Error = result.Error
+		}
+		if err := history.AppendAudit(entry); err != nil {
+			fmt.Fprintln(os.Stderr, "[web] failed to write audit log:", err)
+		}
+		return result
+	}
+
+	mux.Handle("/api/rewards", reload.handler(func(cfg *webConfig) http.Handler {
+		return api.Rewards(history, cfg.opts.Location, cfg.guard())
+	}))
+	mux.Handle("/api/reload", reload.handler(func(cfg *webConfig) http.Handler {
+		return api.Reload(apiReload, cfg.guard())
+	}))
+	mux.Handle("/api/audit", reload.handler(func(cfg *webConfig) http.Handler {
+		return api.Audit(history, cfg.opts.Location)
+	}))
+	mux.Handle("/api/export", reload.handler(func(cfg *webConfig) http.Handler {
+		return api.Export(history, cfg.opts.Location)
+	}))
+	mux.Handle("/api/calendar/", reload.handler(func(cfg *webConfig) http.Handler {
+		return api.Calendar(history, cfg.opts.Location)
+	}))
+	jobs := reload.handler(func(cfg *webConfig) http.Handler { return api.Jobs(sched, cfg.guard()) })
+	mux.Handle("/api/jobs", jobs)
+	mux.Handle("/api/jobs/", jobs)
+	mux.Handle("/healthz", api.Healthz(sched))
+	mux.Handle("/readyz", api.Readyz())
+	mux.Handle(v1.Prefix+"/", reload.handler(func(cfg *webConfig) http.Handler {
+		return v1.Handler(v1.Options{
+			Store:     history,
+			Location:  cfg.opts.Location,
+			Scheduler: sched,
+			Reload:    apiReload,
+			Guard:     cfg.guard(),
 		})
+	}))
 
-		// Serve frontend static files and SPA fallback
-		fileServer := http.FS(web.Assets)
-		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			// skip API route
-			if r.URL.Path == "/status" {
-				return
-			}
-			// Try to serve static file
-			f, err := web.Assets.Open(r.URL.Path[1:])
-			if err == nil {
-				if err := f.Close(); err != nil {
-					fmt.Fprintln(os.Stderr, "[web] error closing file:", err)
-				}
-				http.FileServer(fileServer).ServeHTTP(w, r)
-				return
-			}
-			// Fallback to index.html for SPA routing
-			index, err := web.Assets.Open("index.html")
-			if err != nil {
-				http.Error(w, "index.html not found", http.StatusNotFound)
-				return
+	// Serve frontend static files and SPA fallback
+	fileServer := http.FS(web.Assets)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// skip API route
+		if r.URL.Path == "/status" {
+			return
+		}
+		// Try to serve static file
+		f, err := web.Assets.Open(r.URL.Path[1:])
+		if err == nil {
+			if err := f.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "[web] error closing file:", err)
 			}
-			defer func() {
-				if err := index.Close(); err != nil {
-					fmt.Fprintln(os.Stderr, "[web] error closing index.html:", err)
-				}
-			}()
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			if _, err := io.Copy(w, index); err != nil {
-				fmt.Fprintln(os.Stderr, "[web] error copying index.html:", err)
+			http.FileServer(fileServer).ServeHTTP(w, r)
+			return
+		}
+		// Fallback to index.html for SPA routing
+		index, err := web.Assets.Open("index.html")
+		if err != nil {
+			http.Error(w, "index.html not found", http.StatusNotFound)
+			return
+		}
+		defer func() {
+			if err := index.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "[web] error closing index.html:", err)
 			}
-		})
+		}()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := io.Copy(w, index); err != nil {
+			fmt.Fprintln(os.Stderr, "[web] error copying index.html:", err)
+		}
+	})
+	return mux
+}
 
-		addr := ":8080"
-		if port := viper.GetString("web-port"); port != "" {
-			addr = ":" + port
+// newWebServer creates the HTTP server and its listener for --listen, with
+// TLS if --tls-cert and --tls-key are set.
+func newWebServer(handler http.Handler) (*http.Server, net.Listener, error) {
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	certFile, keyFile := viper.GetString("tls-cert"), viper.GetString("tls-key")
+	if (certFile == "") != (keyFile == "") {
+		return nil, nil, errors.New("--tls-cert and --tls-key must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
 		}
-		server := &http.Server{Addr: addr, Handler: mux}
-		go func() {
-			<-ctx.Done()
-			_ = server.Close()
-		}()
-		fmt.Printf("[web] API server listening at http://localhost%s/\n", addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			fmt.Fprintln(os.Stderr, "[web] HTTP server error:", err)
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+	ln, err := listen(listenAddress())
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, ln, nil
+}
+
+// defaultListen is the default of --listen.
+const defaultListen = ":8080"
+
+// listenAddress returns --listen, or the port of the older web-port setting
+// if --listen is left at its default.
+func listenAddress() string {
+	if port := viper.GetString("web-port"); port != "" && viper.GetString("listen") == defaultListen {
+		return ":" + port
+	}
+	return viper.GetString("listen")
+}
+
+// listen listens on a TCP host:port or, with the unix: prefix, on a unix
+// socket. A socket file left over from an earlier run is removed first.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
 		}
+	}
+	return net.Listen("unix", path)
+}
+
+// serveWeb serves until the server is shut down.
+func serveWeb(server *http.Server, ln net.Listener) error {
+	scheme := "http"
+	if server.TLSConfig != nil {
+		scheme = "https"
+	}
+	if ln.Addr().Network() == "unix" {
+		fmt.Printf("[web] API server listening on unix:%s (%s)\n", ln.Addr(), scheme)
+	} else {
+		fmt.Printf("[web] API server listening at %s://%s/\n", scheme, ln.Addr())
+	}
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ServeTLS(ln, "", "")
+	} else {
+		err = server.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// shutdownWeb stops accepting requests, lets the requests in flight finish
+// and waits for running jobs, e.g. a device being blocked. Jobs stop before
+// their next device once the daemon's context is done. It gives up after
+// timeout.
+func shutdownWeb(server *http.Server, sched *scheduler.Scheduler, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "[web] HTTP server did not shut down cleanly:", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		sched.Wait()
+		close(done)
 	}()
+	select {
+	case <-done:
+		fmt.Fprintln(os.Stderr, "[web] stopped")
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "[web] gave up waiting for running jobs after", timeout)
+	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Fprintln(os.Stderr, "\nReceived interrupt, exiting background monitor")
-			return
-		default:
+// Jobs of the web daemon. The fetch jobs refresh the data cache; report and
+// enforce evaluate the cached data.
+const (
+	jobLandevices = "landevices"
+	jobDay        = "day"
+	jobHour       = "hour"
+	jobReport     = "report"
+	jobEnforce    = "enforce"
+)
+
+// daemon runs the monitoring jobs of the web command on a shared data cache.
+type daemon struct {
+	reload  *reloader
+	history *store.Store
+
+	mu sync.Mutex
+	// cache holds the data of the Fritz!Boxes of cfg; it is replaced when a
+	// reload changes the routers or credentials.
+	cfg   *webConfig
+	cache *monitor.Cache
+}
+
+// scheduler creates the scheduler with the jobs and the intervals of the
+// current configuration.
+func (d *daemon) scheduler() (*scheduler.Scheduler, error) {
+	cfg := d.reload.current.Load()
+	jobs := []scheduler.Job{
+		{Name: jobLandevices, Run: d.fetch(jobLandevices, (*monitor.Cache).FetchLandevices)},
+		{Name: jobDay, Run: d.fetch(jobDay, (*monitor.Cache).FetchDay)},
+		{Name: jobHour, Run: d.fetch(jobHour, (*monitor.Cache).FetchHour)},
+		{Name: jobReport, Run: d.report},
+		{Name: jobEnforce, Run: d.enforce},
+	}
+	for i := range jobs {
+		jobs[i].Interval = cfg.intervals[jobs[i].Name]
+		jobs[i].Jitter = cfg.jitter
+	}
+	return scheduler.New(jobs...)
+}
+
+// setIntervals applies the intervals of a reloaded configuration.
+func (d *daemon) setIntervals(s *scheduler.Scheduler, cfg *webConfig) {
+	for name, interval := range cfg.intervals {
+		if err := s.SetInterval(name, interval); err != nil {
+			fmt.Fprintln(os.Stderr, "[web] failed to change interval:", err)
+		}
+	}
+}
+
+// current returns the active configuration and the cache for it, connecting
+// anew if the routers or credentials changed.
+func (d *daemon) current() (*webConfig, *monitor.Cache, error) {
+	cfg := d.reload.current.Load()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cache == nil || !sameConnection(d.cfg.opts, cfg.opts) {
+		opts := cfg.opts
+		opts.Out = io.Discard
+		cache, err := monitor.NewCache(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		d.cache = cache
+	}
+	d.cfg = cfg
+	return cfg, d.cache, nil
+}
+
+// sameConnection reports whether a and b fetch the same data.
+func sameConnection(a, b monitor.Options) bool {
+	return a.Username == b.Username && a.Password == b.Password && a.URL == b.URL &&
+		a.Mac == b.Mac && slices.Equal(a.Routers, b.Routers)
+}
+
+// fetch returns a job refreshing part of the cache.
+func (d *daemon) fetch(name string, fetch func(*monitor.Cache, context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, cache, err := d.current()
+		if err == nil {
+			err = fetch(cache, ctx)
+		}
+		state.RecordFetch(time.Now(), err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[web] %s job failed: %v\n", name, err)
+		}
+		return err
+	}
+}
+
+// pipeline assembles a pipeline for the cached data with the current
+// configuration.
+func (d *daemon) pipeline() (*monitor.Pipeline, *webConfig, *monitor.Cache, error) {
+	cfg, cache, err := d.current()
+	if err != nil {
+		state.RecordFetch(time.Now(), err)
+		return nil, nil, nil, err
+	}
+	identities, err := identity.Load(filepath.Join(cfg.dataDir, "identities.json"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[web] failed to load device identities:", err)
+	}
+	opts := cfg.opts
+	opts.Identities = identities
+	opts.History = d.history
+	opts.Out = io.Discard // discard monitor logs when running as a daemon
+	p, err := cache.Pipeline(opts)
+	return p, cfg, cache, err
+}
+
+// report evaluates the cached data, records the usage and publishes it on
+// /status. It never blocks devices, see enforce.
+func (d *daemon) report(ctx context.Context) error {
+	start := time.Now()
+	fmt.Printf("[web] Starting monitoring at %s\n", start.Format(time.RFC3339))
+	p, _, _, err := d.pipeline()
+	if err != nil {
+		state.RecordRun(start, err)
+		fmt.Fprintln(os.Stderr, "[web] report job failed:", err)
+		return err
+	}
+	p.Enforcer = nil
+	summary, err := p.Run(ctx)
+	state.Update(summary)
+	state.RecordRun(start, err)
+	if err != nil {
+		fmt.Printf("[web] Finished run with errors, checked %d devices, fetched %d users, duration %s\n", summary.DevicesChecked, summary.UsersFetched, summary.Duration)
+		for _, e := range summary.Errors {
+			fmt.Printf("  error: %v\n", e)
+		}
+		return err
+	}
+	fmt.Printf("[web] Finished run: checked %d devices, fetched %d users, duration %s\n", summary.DevicesChecked, summary.UsersFetched, summary.Duration)
+	return errors.Join(summary.Errors...)
+}
+
+// enforce blocks and unblocks devices according to the cached data. With
+// --enforce it fetches the device list first so it acts on the current block
+// state. Without, it only keeps the enforcement state, so a grace period that
+// started goes on when enforcement is switched on.
+func (d *daemon) enforce(ctx context.Context) error {
+	p, cfg, cache, err := d.pipeline()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[web] enforce job failed:", err)
+		return err
+	}
+	if cfg.opts.Enforce {
+		err = cache.FetchLandevices(ctx)
+		state.RecordFetch(time.Now(), err)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "[web] enforce job failed:", err)
+			return err
+		}
+	}
+	p.Reporters = nil
+	summary, err := p.Run(ctx)
+	if err == nil {
+		err = errors.Join(summary.Errors...)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[web] enforce job failed:", err)
+	}
+	return err
+}
+
+// webConfig is the configuration of the monitoring runs that a reload swaps.
+// It is a snapshot: the jobs read it instead of viper, which the reloader
+// changes while they run.
+type webConfig struct {
+	opts monitor.Options
+	// intervals holds the interval of each job.
+	intervals map[string]time.Duration
+	jitter    time.Duration
+	dataDir   string
+	// policyFile is the absolute path of the policy document, if any.
+	policyFile string
+	// apiToken is required to change state through the API, if set.
+	apiToken string
+}
+
+// loadWebConfig reads and validates the configuration from flags, environment,
+// config file and policy document.
+func loadWebConfig() (*webConfig, error) {
+	intervals := map[string]time.Duration{
+		jobLandevices: viper.GetDuration("landevices-interval"),
+		jobDay:        viper.GetDuration("interval"),
+		jobHour:       viper.GetDuration("hour-interval"),
+		jobReport:     viper.GetDuration("interval"),
+		jobEnforce:    viper.GetDuration("interval"),
+	}
+	for _, flag := range []string{"interval", "landevices-interval", "hour-interval"} {
+		if d := viper.GetDuration(flag); d <= 0 {
+			return nil, fmt.Errorf("%s must be positive, got %s", flag, d)
+		}
+	}
+	loc, err := loadLocation()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := policyDocument()
+	if err != nil {
+		return nil, err
+	}
+	activity, err := activityClassifiers()
+	if err != nil {
+		return nil, err
+	}
+	routers, err := routersConfig()
+	if err != nil {
+		return nil, err
+	}
+	policyStr := viper.GetString("policy")
+	if doc == nil && policyStr != "" {
+		if _, err := policy.FromString(policyStr); err != nil {
+			return nil, fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+	overrides, err := policyOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy override: %w", err)
+	}
+	policyFile := viper.GetString("policy-file")
+	if policyFile != "" {
+		if policyFile, err = filepath.Abs(policyFile); err != nil {
+			return nil, err
 		}
-		start := time.Now()
-		fmt.Printf("[web] Starting monitoring at %s\n", start.Format(time.RFC3339))
-		summary, err := monitor.Run(ctx, monitor.Options{
+	}
+	return &webConfig{
+		intervals:  intervals,
+		jitter:     viper.GetDuration("jitter"),
+		dataDir:    dataDir(),
+		policyFile: policyFile,
+		apiToken:   viper.GetString("api-token"),
+		opts: monitor.Options{
 			Username:          viper.GetString("username"),
 			Password:          viper.GetString("password"),
+			Routers:           routers,
 			Mac:               viper.GetString("mac"),
 			Period:            viper.GetString("period"),
 			ActivityThreshold: viper.GetFloat64("activity-threshold"),
-			PolicyString:      viper.GetString("policy"),
+			Activity:          activity,
+			AutoThreshold:     viper.GetBool("auto-threshold"),
+			PolicyString:      policyStr,
+			Policies:          doc,
+			Location:          loc,
 			Enforce:           viper.GetBool("enforce"),
-			Out:               io.Discard, // discard monitor logs when running as a daemon
-		})
-		state.Update(summary)
-		if err != nil {
-			fmt.Printf("[web] Finished run with errors, checked %d devices, fetched %d users, duration %s\n", summary.DevicesChecked, summary.UsersFetched, summary.Duration)
-			for _, e := range summary.Errors {
-				fmt.Printf("  error: %v\n", e)
-			}
-		} else {
-			fmt.Printf("[web] Finished run: checked %d devices, fetched %d users, duration %s\n", summary.DevicesChecked, summary.UsersFetched, summary.Duration)
+			GracePeriod:       viper.GetDuration("grace-period"),
+			MinBlockDuration:  viper.GetDuration("min-block-duration"),
+			Overrides:         overrides,
+		},
+	}, nil
+}
+
+// guard protects the API endpoints that change state.
+func (c *webConfig) guard() api.Guard {
+	return api.Guard{Token: c.apiToken}
+}
+
+// describe summarizes the policy in effect for the reload status.
+func (c *webConfig) describe() string {
+	switch {
+	case c.opts.Policies != nil && c.policyFile != "":
+		return fmt.Sprintf("policy document %s (%d children)", c.policyFile, len(c.opts.Policies.Children))
+	case c.opts.Policies != nil:
+		return fmt.Sprintf("policy document from config file (%d children)", len(c.opts.Policies.Children))
+	case c.opts.PolicyString != "":
+		return c.opts.PolicyString
+	default:
+		return "no policy"
+	}
+}
+
+// reloader holds the active configuration and replaces it when the config
+// file or the policy document changes, on SIGHUP or on POST /api/reload. A
+// configuration that fails validation is rejected and the previous one stays
+// active.
+//
+// Once the daemon runs, viper is only read and written under mu, by reload;
+// everything else uses the snapshot in current.
+type reloader struct {
+	mu      sync.Mutex
+	current atomic.Pointer[webConfig]
+	// onLoad is called with each configuration that is swapped in. Optional.
+	onLoad func(*webConfig)
+	// configFile is the config file in use, watcher watches its directory and
+	// that of the policy document. Both are set by watch.
+	configFile string
+	watcher    *fsnotify.Watcher
+}
+
+// reload loads the configuration and swaps it in for subsequent runs. Runs in
+// progress keep the configuration they started with.
+func (r *reloader) reload(source string) state.Reload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := state.Reload{Time: time.Now(), Source: source}
+	var err error
+	if source != "startup" && viper.ConfigFileUsed() != "" {
+		err = viper.ReadInConfig()
+	}
+	var cfg *webConfig
+	if err == nil {
+		cfg, err = loadWebConfig()
+	}
+	if err != nil {
+		result.Error = err.Error()
+		if prev := r.current.Load(); prev != nil {
+			result.Policy = prev.describe()
+			fmt.Fprintln(os.Stderr, "[web] reload rejected, keeping previous configuration:", err)
 		}
-		// Wait for next interval or exit
-		select {
-		case <-ctx.Done():
-			return
-		case <-time.After(interval):
+	} else {
+		r.current.Store(cfg)
+		r.watchFile(cfg.policyFile)
+		if r.onLoad != nil {
+			r.onLoad(cfg)
 		}
+		result.OK = true
+		result.Policy = cfg.describe()
+		fmt.Printf("[web] configuration loaded (%s): %s\n", source, result.Policy)
+	}
+	state.SetReload(result)
+	return result
+}
+
+// handler serves each request with the handler that build returns for the
+// active configuration.
+func (r *reloader) handler(build func(*webConfig) http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		build(r.current.Load()).ServeHTTP(w, req)
+	})
+}
+
+// watch reloads when the config file or the policy document changes and on
+// SIGHUP until ctx is done. It replaces viper.WatchConfig, which re-reads the
+// config file without holding mu.
+func (r *reloader) watch(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[web] failed to watch the configuration, reload with SIGHUP:", err)
+	} else {
+		r.mu.Lock()
+		r.watcher = watcher
+		if file := viper.ConfigFileUsed(); file != "" {
+			r.configFile, _ = filepath.Abs(file)
+			r.watchFile(r.configFile)
+		}
+		if cfg := r.current.Load(); cfg != nil {
+			r.watchFile(cfg.policyFile)
+		}
+		r.mu.Unlock()
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		if watcher != nil {
+			defer func() { _ = watcher.Close() }()
+		}
+		var events <-chan fsnotify.Event
+		var errs <-chan error
+		if watcher != nil {
+			events, errs = watcher.Events, watcher.Errors
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.reload("SIGHUP")
+			case ev := <-events:
+				if source := r.changed(ev); source != "" {
+					r.reload(source)
+				}
+			case err := <-errs:
+				fmt.Fprintln(os.Stderr, "[web] configuration watcher error:", err)
+			}
+		}
+	}()
+}
+
+// watchFile watches the directory of path, so files replaced by editors are
+// noticed too. Call with mu held.
+func (r *reloader) watchFile(path string) {
+	if r.watcher == nil || path == "" {
+		return
+	}
+	if err := r.watcher.Add(filepath.Dir(path)); err != nil {
+		fmt.Fprintf(os.Stderr, "[web] failed to watch %s: %v\n", path, err)
+	}
+}
+
+// changed returns the reload source for a file event, or "" if the event
+// does not change the config file or the policy document.
+func (r *reloader) changed(ev fsnotify.Event) string {
+	if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+		return ""
+	}
+	name := filepath.Clean(ev.Name)
+	switch {
+	case name == r.configFile:
+		return "config file"
+	case name == r.current.Load().policyFile:
+		return "policy file"
+	default:
+		return ""
 	}
 }
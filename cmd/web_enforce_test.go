@@ -0,0 +1,95 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/store"
+)
+
+// fakeFritzbox answers the login and the REST API of a Fritz!Box with one
+// monitored, unblocked tablet that was idle all day. It counts the requests
+// to block or unblock a device in blocks.
+func fakeFritzbox(t *testing.T, blocks *atomic.Int32) *httptest.Server {
+	t.Helper()
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login_sid.lua", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte("<SessionInfo><SID>0123456789abcdef</SID><Challenge>1234abcd</Challenge><BlockTime>0</BlockTime></SessionInfo>"))
+	})
+	mux.HandleFunc("/api/v0/landevice", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, fritzbox.LandeviceResponse{Landevice: []fritzbox.Landevice{
+			{UID: "landevice1", FriendlyName: "tablet", MAC: "AA:11:BB:22:CC:33", UserUIDs: "user1", Blocked: "0"},
+		}})
+	})
+	mux.HandleFunc("/api/v0/monitor/configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"})
+	})
+	mux.HandleFunc("/api/v0/monitor/macaddrs/", func(w http.ResponseWriter, r *http.Request) {
+		samples := 96
+		if filepath.Base(r.URL.Path) == "subset0001" {
+			samples = 60
+		}
+		writeJSON(w, []fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: make([]float64, samples)},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, samples)},
+		})
+	})
+	mux.HandleFunc("/data.lua", func(w http.ResponseWriter, r *http.Request) {
+		blocks.Add(1)
+		writeJSON(w, map[string]any{})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWeb_KeepsEnforcementStateWithoutEnforce(t *testing.T) {
+	var blocks atomic.Int32
+	router := fakeFritzbox(t, &blocks)
+	dir := t.TempDir()
+	config := filepath.Join(dir, "home-gate.yaml")
+	routers := "routers:\n  - name: home\n    url: " + router.URL + "\n    username: admin\n    password: secret\n"
+	if err := os.WriteFile(config, []byte(routers), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data := filepath.Join(dir, "data")
+
+	// A quota of 0 minutes is reached right away, the grace period starts.
+	startCommand(t, "web", "--config", config, "--listen", freeAddr(t), "--jitter", "0",
+		"--policy", "MO-SU0", "--grace-period", "1h", "--data-dir", data)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		history, err := store.Open(data)
+		if err == nil {
+			var st store.Enforcement
+			var ok bool
+			st, ok, err = history.Enforcement("aa11bb22cc33@home")
+			if err == nil && ok {
+				if st.QuotaReachedAt == nil || st.BlockedAt != nil {
+					t.Fatalf("expected the grace period to start without a block, got %+v", st)
+				}
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the enforce job to store the grace start without --enforce (last error: %v)", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := blocks.Load(); n != 0 {
+		t.Errorf("expected no device to be blocked without --enforce, got %d requests", n)
+	}
+}
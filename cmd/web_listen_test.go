@@ -0,0 +1,128 @@
+package cmd_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWeb_UnixSocketReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "home-gate.sock")
+	// A socket file left behind by a killed daemon.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	p := startCommand(t, "web", "--listen", "unix:"+path, "--jitter", "0")
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp := p.get(t, client, "http://home-gate/readyz")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the daemon to answer on the socket, got %d", resp.StatusCode)
+	}
+}
+
+func TestWeb_ListenFallsBackToWebPort(t *testing.T) {
+	addr := freeAddr(t)
+	_, port, _ := net.SplitHostPort(addr)
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	if err := os.WriteFile(config, []byte("web-port: \""+port+"\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := startCommand(t, "web", "--config", config, "--jitter", "0")
+	resp := p.get(t, http.DefaultClient, "http://"+addr+"/api/reload")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected web-port to apply, got %d", resp.StatusCode)
+	}
+}
+
+func TestWeb_ListenTakesPrecedenceOverWebPort(t *testing.T) {
+	addr := freeAddr(t)
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	if err := os.WriteFile(config, []byte("web-port: \"1\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := startCommand(t, "web", "--config", config, "--listen", addr, "--jitter", "0")
+	resp := p.get(t, http.DefaultClient, "http://"+addr+"/api/reload")
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected --listen to take precedence, got %d", resp.StatusCode)
+	}
+}
+
+func TestWeb_NeedsCertAndKey(t *testing.T) {
+	p := startCommand(t, "web", "--listen", freeAddr(t), "--tls-cert", "cert.pem")
+	if code := p.wait(t); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(p.stderr.String(), "must be set together") {
+		t.Fatalf("expected an error for a certificate without key, got %q", p.stderr.String())
+	}
+}
+
+func TestWeb_ShutdownWaitsForRunningJobs(t *testing.T) {
+	// A Fritz!Box that answers slowly, so the first job is still running
+	// when the daemon is told to stop.
+	var once sync.Once
+	requested := make(chan struct{})
+	var mu sync.Mutex
+	var answered time.Time
+	fritzbox := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(requested) })
+		time.Sleep(300 * time.Millisecond)
+		mu.Lock()
+		answered = time.Now()
+		mu.Unlock()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer fritzbox.Close()
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	routers := "routers:\n  - name: home\n    url: " + fritzbox.URL + "\n    username: admin\n    password: secret\n"
+	if err := os.WriteFile(config, []byte(routers), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	addr := freeAddr(t)
+
+	p := startCommand(t, "web", "--config", config, "--listen", addr, "--jitter", "0", "--shutdown-timeout", "5s")
+	select {
+	case <-requested:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected a job to query the Fritz!Box")
+	}
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if code := p.wait(t); code != 0 {
+		t.Fatalf("expected a clean exit, got %d\n%s", code, p.stderr.String())
+	}
+	exited := time.Now()
+	mu.Lock()
+	defer mu.Unlock()
+	if answered.IsZero() || answered.After(exited) {
+		t.Fatal("expected shutdown to wait for the running job")
+	}
+	if !strings.Contains(p.stderr.String(), "[web] stopped") {
+		t.Fatalf("expected the jobs to finish in time\n%s", p.stderr.String())
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatal("expected the server to stop accepting connections")
+	}
+}
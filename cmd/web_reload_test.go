@@ -0,0 +1,145 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+)
+
+// lastReload polls GET /api/reload until done accepts the reload status.
+func lastReload(t *testing.T, p *process, addr string, done func(state.Reload) bool) state.Reload {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp := p.get(t, http.DefaultClient, "http://"+addr+"/api/reload")
+		var reload state.Reload
+		err := json.NewDecoder(resp.Body).Decode(&reload)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatalf("decode reload status: %v", err)
+		}
+		if done(reload) {
+			return reload
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected reload status %+v", reload)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// writeFile replaces the file at path in one step, as editors do, so the
+// daemon never reloads it half written.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestWeb_KeepsPreviousConfigurationOnInvalidPolicy(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	writeFile(t, config, "policy: MO-SU60\ninterval: 1m\n")
+	addr := freeAddr(t)
+	p := startCommand(t, "web", "--config", config, "--listen", addr, "--jitter", "0")
+	lastReload(t, p, addr, func(r state.Reload) bool { return r.Source == "startup" && r.OK })
+
+	writeFile(t, config, "policy: MO-XX60\ninterval: 1m\n")
+	result := lastReload(t, p, addr, func(r state.Reload) bool { return r.Source != "startup" })
+	if result.OK || result.Error == "" || result.Policy != "MO-SU60" {
+		t.Fatalf("expected invalid policy to be rejected and the previous one to stay active, got %+v", result)
+	}
+
+	writeFile(t, config, "policy: MO-SU90\ninterval: 2m\n")
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/api/reload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode reload result: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !result.OK || result.Policy != "MO-SU90" {
+		t.Fatalf("reload failed with %d: %+v", resp.StatusCode, result)
+	}
+
+	resp = p.get(t, http.DefaultClient, "http://"+addr+"/api/jobs")
+	var jobs []scheduler.Status
+	err = json.NewDecoder(resp.Body).Decode(&jobs)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decode jobs: %v", err)
+	}
+	intervals := map[string]time.Duration{}
+	for _, job := range jobs {
+		intervals[job.Name] = job.Interval
+	}
+	if intervals["report"] != 2*time.Minute || intervals["hour"] != time.Minute {
+		t.Errorf("expected the new intervals, got %v", intervals)
+	}
+}
+
+func TestWeb_ReloadsWhenPolicyFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeFile(t, path, "version: 1\ndefault:\n  policy: MO-SU60\n")
+	addr := freeAddr(t)
+	p := startCommand(t, "web", "--policy-file", path, "--listen", addr, "--jitter", "0")
+	lastReload(t, p, addr, func(r state.Reload) bool { return r.Source == "startup" && r.OK })
+
+	writeFile(t, path, "version: 1\ndefault:\n  policy: MO-SU60\nchildren:\n  - name: Anna\n    devices: [tablet]\n    policy: MO-SU90\n")
+	result := lastReload(t, p, addr, func(r state.Reload) bool { return strings.HasSuffix(r.Policy, "(1 children)") })
+	if result.Source != "policy file" || !result.OK {
+		t.Errorf("unexpected reload status %+v", result)
+	}
+}
+
+func TestWeb_ReloadChangesAPIToken(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "home-gate.yaml")
+	writeFile(t, config, "policy: MO-SU60\napi-token: old\n")
+	addr := freeAddr(t)
+	p := startCommand(t, "web", "--config", config, "--listen", addr, "--jitter", "0")
+	lastReload(t, p, addr, func(r state.Reload) bool { return r.Source == "startup" && r.OK })
+
+	writeFile(t, config, "policy: MO-SU90\napi-token: new\n")
+	lastReload(t, p, addr, func(r state.Reload) bool { return r.Policy == "MO-SU90" })
+
+	award := func(token string) int {
+		t.Helper()
+		body := strings.NewReader(`{"child": "Anna", "minutes": 10, "reason": "dishes"}`)
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/api/rewards", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := award("old"); code != http.StatusUnauthorized {
+		t.Errorf("expected the previous token to be rejected, got %d", code)
+	}
+	if code := award("new"); code != http.StatusCreated {
+		t.Errorf("expected the reloaded token to be accepted, got %d", code)
+	}
+}
@@ -20,6 +20,7 @@ import Bulma.CDN exposing (stylesheet)
 
 import DailyQuotaCard
 import ActiveTimeCard
+import RewardsCard exposing (Reward)
 
 intervalMinutes : Int
 intervalMinutes = 15
@@ -46,6 +47,8 @@ type alias Device =
     , dailyActiveMinutes : Int
     , activeSlots : List String
     , quota : Int
+    , bonus : Int
+    , rewards : List Reward
     }
 
 type alias Status =
@@ -64,14 +67,13 @@ type alias Model =
     , lastError : Maybe String
     }
 
--- The Elm program expects a single string flag: the API URL to use for /status
--- This is computed in the embedding HTML so Elm doesn't need to parse hostname/port.
+-- The dashboard reads the versioned /api/v1/status endpoint, relative to the page.
 
 -- Initialize from the actual browser URL via Browser.application
 init : () -> Url -> Nav.Key -> ( Model, Cmd Msg )
 init _ url key =
     let
-        apiUrl = "/status"
+        apiUrl = "/api/v1/status"
     in
     ( { status = Nothing
       , now = Time.millisToPosix 0
@@ -138,21 +140,62 @@ httpErrorToString err =
 
 deviceDecoder : Decoder Device
 deviceDecoder =
-    Decode.map5 Device
+    Decode.map7 Device
         (Decode.field "mac" Decode.string)
         (Decode.field "name" Decode.string)
-        (Decode.field "daily_active_minutes" Decode.int)
-        -- backend sometimes returns `null` for `active`; accept null as empty list
-        (Decode.field "active" (Decode.oneOf [ Decode.list Decode.string, Decode.null [] ]))
-        (Decode.field "quota" Decode.int)
+        (Decode.field "active_time" durationDecoder)
+        (Decode.field "sessions" (Decode.list sessionDecoder))
+        (Decode.field "quota" durationDecoder)
+        (Decode.field "bonus" durationDecoder)
+        (Decode.field "rewards" (Decode.list rewardDecoder))
+
+-- Sessions are turned into the "HH:MM/duration" slots the timeline is built
+-- from, in the time zone of the daemon like the start time itself.
+sessionDecoder : Decoder String
+sessionDecoder =
+    Decode.map2 (\start dur -> String.slice 11 16 start ++ "/" ++ dur)
+        (Decode.field "start" Decode.string)
+        (Decode.field "duration" Decode.string)
+
+rewardDecoder : Decoder Reward
+rewardDecoder =
+    Decode.map2 Reward
+        (Decode.field "duration" durationDecoder)
+        (Decode.field "reason" Decode.string)
 
 statusDecoder : Decoder Status
 statusDecoder =
     Decode.map3 Status
-        (Decode.field "DevicesChecked" Decode.int)
-        (Decode.field "UsersFetched" Decode.int)
+        (Decode.field "devices_checked" Decode.int)
+        (Decode.field "users_fetched" Decode.int)
         (Decode.field "devices" (Decode.list deviceDecoder))
 
+durationDecoder : Decoder Int
+durationDecoder =
+    Decode.map durationMinutes Decode.string
+
+-- The API writes durations as ISO 8601, e.g. "PT1H15M" or "-PT15M" for a
+-- reward taking time away; seconds are dropped.
+durationMinutes : String -> Int
+durationMinutes iso =
+    let
+        sign = if String.startsWith "-" iso then -1 else 1
+        step c (digits, minutes) =
+            if Char.isDigit c || c == '.' then
+                (digits ++ String.fromChar c, minutes)
+            else
+                case c of
+                    'H' -> ("", minutes + 60 * Maybe.withDefault 0 (String.toInt digits))
+                    'M' -> ("", minutes + Maybe.withDefault 0 (String.toInt digits))
+                    _ -> ("", minutes)
+    in
+    iso
+        |> String.replace "-" ""
+        |> String.replace "PT" ""
+        |> String.foldl step ("", 0)
+        |> Tuple.second
+        |> (*) sign
+
 -- VIEW
 
 minuteMs : Int
@@ -270,6 +313,7 @@ deviceTimelineView device currSeg hoveredTimelineBox hoveredHourCard liftMsg =
             [ DailyQuotaCard.dailyQuotaCard { quota = device.quota, dailyActiveMinutes = device.dailyActiveMinutes }
             , ActiveTimeCard.activeTimeCard { quota = device.quota, dailyActiveMinutes = device.dailyActiveMinutes }
             ]
+        , RewardsCard.rewardsCard { bonus = device.bonus, rewards = device.rewards }
         , div [ style "display" "flex", style "flex-wrap" "wrap", style "gap" "12px", style "justify-content" "flex-start", style "margin" "16px 0 0 0" ]
             (List.map (\h -> hourContainerView name h timeline currSeg hoveredTimelineBox hoveredHourCard liftMsg) (List.range 6 21))
         ]
@@ -391,41 +435,19 @@ parseSlot slotString =
                             (h * 60 + m) // intervalMinutes
                         _ -> 0
                 _ -> 0
-        parseDuration s =
-            let
-                get = String.dropLeft 2 s
-                (dh, rest) =
-                    if String.startsWith "PT" s && String.contains "H" get then
-                        case String.split "H" get of
-                            hour :: r :: _ -> (String.toInt hour, r)
-                            hour :: _ -> (String.toInt hour, "")
-                            _ -> (Nothing, "")
-                    else (Nothing, get)
-                minPart =
-                    if String.contains "M" rest then
-                        String.left (Maybe.withDefault 0 (String.indexes "M" rest |> List.head)) rest
-                            |> String.toInt
-                    else
-                        Nothing
-            in
-            case (dh, minPart) of
-                (Just h, Just m) -> h * 60 + m
-                (Just h, Nothing) -> h * 60
-                (Nothing, Just m) -> m
-                _ -> 0
     in
     case timeAndDur of
         [start, dur] ->
             let
                 baseTime = String.left 5 start -- "09:45"
                 idx = toIdx baseTime
-                mins = parseDuration dur
+                mins = durationMinutes dur
                 count = (mins + intervalMinutes - 1) // intervalMinutes
             in
             Just (idx, count)
         _ -> Nothing
 
--- The program always uses the relative "/status" endpoint. No flags/host detection required.
+-- The program always uses the relative "/api/v1/status" endpoint. No flags/host detection required.
 main : Program () Model Msg
 main =
     Browser.application
@@ -0,0 +1,38 @@
+module RewardsCard exposing (rewardsCard, Reward)
+
+import Html exposing (Html, div, span, text)
+import Html.Attributes exposing (style)
+
+type alias Reward =
+    { minutes : Int
+    , reason : String
+    }
+
+-- Shows today's earned-time rewards with their reasons; renders nothing when there are none.
+rewardsCard : { bonus : Int, rewards : List Reward } -> Html msg
+rewardsCard { bonus, rewards } =
+    if List.isEmpty rewards then
+        text ""
+    else
+        let
+            signed mins =
+                (if mins > 0 then "+" else "") ++ String.fromInt mins ++ " min"
+            rewardRow reward =
+                div [ style "display" "flex", style "justify-content" "space-between", style "font-size" "14px", style "padding" "6px 0", style "border-top" "1px solid #f1f5f9" ]
+                    [ span [ style "color" "#334155" ] [ text reward.reason ]
+                    , span [ style "font-weight" "600", style "color" (if reward.minutes >= 0 then "#059669" else "#dc2626") ] [ text (signed reward.minutes) ]
+                    ]
+        in
+        div [ style "background" "#fff"
+            , style "border-radius" "20px"
+            , style "box-shadow" "0 2px 16px rgba(17,24,39,0.07)"
+            , style "padding" "24px"
+            , style "border" "1px solid #f1f5f9"
+            , style "margin-bottom" "24px"
+            ]
+            (div [ style "display" "flex", style "justify-content" "space-between", style "margin-bottom" "8px" ]
+                [ span [ style "font-size" "17px", style "font-weight" "600", style "color" "#1e293b" ] [ text "Earned Time" ]
+                , span [ style "font-size" "17px", style "font-weight" "600", style "color" "#059669" ] [ text (signed bonus) ]
+                ]
+                :: List.map rewardRow rewards
+            )
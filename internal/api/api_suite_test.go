@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAPI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "API Suite")
+}
+
+// request sends a request with the given headers and returns the response
+// with its body read.
+func request(method, url, body string, header map[string]string) (*http.Response, string) {
+	GinkgoHelper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	Expect(err).ToNot(HaveOccurred())
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	Expect(err).ToNot(HaveOccurred())
+	return resp, string(b)
+}
+
+// jsonHeader is the header of a JSON write.
+var jsonHeader = map[string]string{"Content-Type": "application/json"}
+
+// dayBorder returns a time zone whose today is another day than the local
+// one, and the times a minute before and after its last midnight, so the
+// local time zone and the returned one put them on different days.
+func dayBorder() (loc *time.Location, before, after time.Time) {
+	now := time.Now()
+	_, offset := now.Zone()
+	shift := 14 * 60 * 60
+	if now.Hour() < 12 {
+		shift = -shift
+	}
+	loc = time.FixedZone("far", offset+shift)
+	today := now.In(loc)
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	return loc, midnight.Add(-time.Minute), midnight.Add(time.Minute)
+}
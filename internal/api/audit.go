@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"home-gate/internal/store"
+)
+
+// Audit serves the audit log of blocks, unblocks, rewards and reloads: GET lists the
+// entries between ?from= and ?to= (dates or RFC 3339 times, default the last
+// 7 days), optionally for ?device= (name or MAC address). Only the dashboard,
+// served from the same origin, reads the log, so other web sites get no CORS
+// headers to read it with.
+func Audit(s *store.Store, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		from, to, err := store.AuditRange(q.Get("from"), q.Get("to"), time.Now(), loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := s.Audit(q.Get("device"), from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []store.AuditEntry{}
+		}
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
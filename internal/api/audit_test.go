@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Audit", func() {
+	var ts *httptest.Server
+
+	BeforeEach(func() {
+		s, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		now := time.Now().UTC()
+		for _, e := range []store.AuditEntry{
+			{Time: now.Add(-time.Hour), Device: "iPad", Action: "block", Actor: store.ActorPolicy},
+			{Time: now.Add(-time.Hour), Device: "Laptop", Action: "block", Actor: store.ActorPolicy},
+			{Time: now.AddDate(0, 0, -10), Device: "iPad", Action: "unblock", Actor: store.ActorPolicy},
+		} {
+			Expect(s.AppendAudit(e)).To(Succeed())
+		}
+		ts = httptest.NewServer(api.Audit(s, time.UTC))
+		DeferCleanup(ts.Close)
+	})
+
+	It("filters the entries by device and time", func() {
+		resp, body := request(http.MethodGet, ts.URL+"?device=ipad", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		var entries []store.AuditEntry
+		Expect(json.Unmarshal([]byte(body), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("block"))
+	})
+
+	It("does not let other web sites read the log", func() {
+		resp, _ := request(http.MethodGet, ts.URL, "", map[string]string{"Origin": "https://example.com"})
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+
+	It("rejects invalid times", func() {
+		resp, _ := request(http.MethodGet, ts.URL+"?from=yesterday", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"home-gate/internal/calendar"
+	"home-gate/internal/export"
+	"home-gate/internal/store"
+)
+
+// defaultCalendarDays is how far back a calendar feed goes without ?days=.
+const defaultCalendarDays = 90
+
+// Calendar serves the activity sessions of a child, or of one device by name
+// or MAC address, as an iCalendar feed: GET /api/calendar/{name}.ics covers
+// today and the ?days= before it (default 90), answering 404 if nothing was
+// recorded for name in that time. Days recorded without sessions, e.g. by
+// older versions, give a feed without events. Calendar apps fetch the feed
+// directly, so it has no CORS headers.
+func Calendar(s *store.Store, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/calendar/"), ".ics")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		days := defaultCalendarDays
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid days %q, expected a non-negative number", v), http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+		now := time.Now().In(loc)
+		events, found, err := export.Sessions(s, name, now.AddDate(0, 0, -days), now, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("no usage recorded for %q", name), http.StatusNotFound)
+			return
+		}
+		var b bytes.Buffer
+		if err := calendar.Write(&b, "home-gate: "+name, events, now); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		_, _ = w.Write(b.Bytes())
+	}
+}
@@ -0,0 +1,69 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Calendar", func() {
+	var (
+		ts    *httptest.Server
+		today time.Time
+	)
+
+	BeforeEach(func() {
+		s, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		today = time.Now().UTC()
+		for _, u := range []store.DayUsage{
+			{Date: today.Format(store.DateFormat), Device: "iPad", Child: "Lena", ActiveMinutes: 45, Active: []string{"10:00+00:00/PT45M"}},
+			{Date: today.AddDate(0, 0, -10).Format(store.DateFormat), Device: "iPad", Child: "Lena", ActiveMinutes: 15, Active: []string{"18:00+00:00/PT15M"}},
+			// Recorded by an older version, without sessions.
+			{Date: today.Format(store.DateFormat), Device: "TV", ActiveMinutes: 30},
+		} {
+			Expect(s.PutUsage(u)).To(Succeed())
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/api/calendar/", api.Calendar(s, time.UTC))
+		ts = httptest.NewServer(mux)
+		DeferCleanup(ts.Close)
+	})
+
+	It("serves the sessions as an iCalendar feed", func() {
+		resp, body := request(http.MethodGet, ts.URL+"/api/calendar/Lena.ics", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/calendar; charset=utf-8"))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+		Expect(strings.Count(body, "BEGIN:VEVENT")).To(Equal(2))
+		Expect(body).To(ContainSubstring("DTSTART:" + today.Format("20060102") + "T100000Z"))
+	})
+
+	It("limits the feed to ?days=", func() {
+		_, body := request(http.MethodGet, ts.URL+"/api/calendar/Lena.ics?days=1", "", nil)
+		Expect(strings.Count(body, "BEGIN:VEVENT")).To(Equal(1))
+	})
+
+	It("serves an empty feed for records without sessions", func() {
+		resp, body := request(http.MethodGet, ts.URL+"/api/calendar/TV.ics", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).ToNot(ContainSubstring("BEGIN:VEVENT"))
+	})
+
+	DescribeTable("rejects invalid requests",
+		func(path string, status int) {
+			resp, _ := request(http.MethodGet, ts.URL+path, "", nil)
+			Expect(resp.StatusCode).To(Equal(status))
+		},
+		Entry("unknown name", "/api/calendar/Max.ics", http.StatusNotFound),
+		Entry("without .ics", "/api/calendar/Lena", http.StatusNotFound),
+		Entry("negative days", "/api/calendar/Lena.ics?days=-1", http.StatusBadRequest),
+	)
+})
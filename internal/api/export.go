@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"home-gate/internal/export"
+	"home-gate/internal/store"
+)
+
+// Export serves the usage history as a download: GET returns one row per day
+// and device between ?from= and ?to= (dates, default the current month) as
+// ?format=csv (the default) or xlsx. It is a download, not meant to be read
+// by scripts of other web sites, so it has no CORS headers.
+func Export(s *store.Store, loc *time.Location) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		format := q.Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+		if format != export.FormatCSV && format != export.FormatXLSX {
+			http.Error(w, fmt.Sprintf("unknown format %q, use csv or xlsx", format), http.StatusBadRequest)
+			return
+		}
+		from, to, err := export.Range(q.Get("from"), q.Get("to"), time.Now(), loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rows, err := export.Rows(s, from, to, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Render first so a failure can still be reported as an error.
+		var b bytes.Buffer
+		if err := export.Write(&b, format, rows); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName(from, to, format)))
+		_, _ = w.Write(b.Bytes())
+	}
+}
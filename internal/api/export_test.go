@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Export", func() {
+	var ts *httptest.Server
+
+	BeforeEach(func() {
+		s, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.PutUsage(store.DayUsage{Date: "2024-03-01", Device: "iPad", ActiveMinutes: 75, QuotaMinutes: 60})).To(Succeed())
+		ts = httptest.NewServer(api.Export(s, time.UTC))
+		DeferCleanup(ts.Close)
+	})
+
+	It("serves CSV by default", func() {
+		resp, body := request(http.MethodGet, ts.URL+"?from=2024-03-01&to=2024-03-31", "", nil)
+		Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/csv"))
+		Expect(resp.Header.Get("Content-Disposition")).To(Equal(`attachment; filename="home-gate-2024-03-01-2024-03-31.csv"`))
+		Expect(body).To(ContainSubstring("2024-03-01,iPad,,75,60,15,0,0,0\n"))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+
+	It("serves an Excel workbook", func() {
+		resp, body := request(http.MethodGet, ts.URL+"?from=2024-03-01&to=2024-03-31&format=xlsx", "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(HavePrefix("PK"))
+	})
+
+	DescribeTable("rejects invalid queries",
+		func(query string) {
+			resp, _ := request(http.MethodGet, ts.URL+query, "", nil)
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		},
+		Entry("unknown format", "?format=pdf"),
+		Entry("invalid date", "?from=yesterday"),
+		Entry("reversed dates", "?from=2024-03-05&to=2024-03-01"),
+	)
+})
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Guard protects the endpoints that change state, such as awarding rewards,
+// reloading the configuration or running a job, against requests from other
+// web sites (CSRF):
+//
+//   - a write must be sent as application/json, which a page of another
+//     origin can only do after a CORS preflight that is never granted;
+//   - write responses carry no CORS headers;
+//   - with a Token, a write needs "Authorization: Bearer <token>", without
+//     one a browser request from another origin is rejected.
+//
+// Reads are not affected.
+type Guard struct {
+	// Token is the API token required for writes. Optional.
+	Token string
+}
+
+// AllowWrite checks a write request. It answers 401, 403 or 415 and returns
+// false if the request is not allowed.
+func (g Guard) AllowWrite(w http.ResponseWriter, r *http.Request) bool {
+	status, err := g.Check(r)
+	if err != nil {
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", Challenge)
+		}
+		http.Error(w, err.Error(), status)
+		return false
+	}
+	return true
+}
+
+// Challenge is the WWW-Authenticate header of a 401 answer.
+const Challenge = `Bearer realm="home-gate"`
+
+// Check checks a write request without answering it, for handlers with their
+// own error bodies. It returns the status to answer, 401, 403 or 415, and why
+// the request is not allowed, or a nil error.
+func (g Guard) Check(r *http.Request) (int, error) {
+	if g.Token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(g.Token)) != 1 {
+			return http.StatusUnauthorized, errors.New("missing or invalid API token")
+		}
+	} else if !sameOrigin(r) {
+		return http.StatusForbidden, errors.New("cross-origin requests may not change state")
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json")
+	}
+	return http.StatusOK, nil
+}
+
+// sameOrigin reports whether a request comes from the daemon's own pages or
+// from outside a browser, e.g. curl, which sends neither header.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// AllowReadCORS lets pages of any origin read the response of a GET, as for
+// /status; writes get no CORS headers.
+func AllowReadCORS(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+)
+
+// stallSlack is how late a job may start, or how much longer than twice its
+// interval it may run, before the daemon counts as wedged.
+const stallSlack = time.Minute
+
+// healthResponse is the body of /healthz and /readyz.
+type healthResponse struct {
+	Status string `json:"status"`
+	// Problems explains a status other than ok.
+	Problems []string `json:"problems,omitempty"`
+	state.Health
+}
+
+// Healthz reports liveness: 200 while the jobs run on schedule, 503 once a
+// job is stuck or stopped being scheduled, so a supervisor can restart the
+// daemon.
+func Healthz(s *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		var problems []string
+		for _, job := range s.Status() {
+			if job.Stalled(now, stallSlack) {
+				problems = append(problems, fmt.Sprintf("job %s is stalled", job.Name))
+			}
+		}
+		writeHealth(w, problems)
+	}
+}
+
+// Readyz reports readiness: 200 once a monitoring run succeeded and the
+// Fritz!Box was reachable and accepted the login at the last fetch, 503
+// otherwise.
+func Readyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := state.GetHealth()
+		var problems []string
+		switch {
+		case h.LastFetch.IsZero():
+			problems = append(problems, "no data fetched from the Fritz!Box yet")
+		case !h.Reachable:
+			problems = append(problems, "Fritz!Box is not reachable")
+		case !h.LoggedIn:
+			problems = append(problems, "Fritz!Box rejected the login")
+		}
+		if h.LastSuccess.IsZero() {
+			problems = append(problems, "no successful monitoring run yet")
+		}
+		writeHealth(w, problems)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, problems []string) {
+	resp := healthResponse{Status: "ok", Problems: problems, Health: state.GetHealth()}
+	status := http.StatusOK
+	if len(problems) > 0 {
+		resp.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
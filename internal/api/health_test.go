@@ -0,0 +1,61 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+)
+
+// getHealth requests a health endpoint and returns the status code and body.
+func getHealth(h http.Handler) (int, map[string]any) {
+	GinkgoHelper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var body map[string]any
+	Expect(json.NewDecoder(rec.Body).Decode(&body)).To(Succeed())
+	return rec.Code, body
+}
+
+var _ = Describe("Healthz", func() {
+	It("reports jobs on schedule", func() {
+		s, err := scheduler.New(scheduler.Job{Name: "report", Interval: time.Hour, Run: func(context.Context) error { return nil }})
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		s.Start(ctx)
+
+		code, body := getHealth(api.Healthz(s))
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("status", "ok"))
+	})
+})
+
+var _ = Describe("Readyz", func() {
+	It("needs a successful run and login", func() {
+		code, _ := getHealth(api.Readyz())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+
+		state.RecordFetch(time.Now(), nil)
+		state.RecordRun(time.Now(), errors.New("router unreachable"))
+		state.RecordRun(time.Now(), errors.New("router unreachable"))
+		code, body := getHealth(api.Readyz())
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(body).To(HaveKeyWithValue("consecutive_failures", 2.0))
+
+		state.RecordRun(time.Now(), nil)
+		code, body = getHealth(api.Readyz())
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(HaveKeyWithValue("consecutive_failures", 0.0))
+		Expect(body).To(HaveKeyWithValue("fritzbox_logged_in", true))
+	})
+})
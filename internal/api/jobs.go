@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"home-gate/internal/scheduler"
+)
+
+// Jobs serves the scheduled jobs of the daemon: GET /api/jobs lists their
+// status, POST /api/jobs/{name}/run runs a job now, answering 202, 404 for
+// an unknown job or 409 while the job is running. The guard protects the
+// POST.
+func Jobs(s *scheduler.Scheduler, guard Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		AllowReadCORS(w, r)
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/jobs"), "/")
+		if path == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", "GET")
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, s.Status())
+			return
+		}
+		name, ok := strings.CutSuffix(path, "/run")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !guard.AllowWrite(w, r) {
+			return
+		}
+		switch err := s.Trigger(name); {
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, scheduler.ErrRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusAccepted, map[string]string{"job": name, "status": "triggered"})
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/scheduler"
+)
+
+var _ = Describe("Jobs", func() {
+	It("lists and triggers jobs", func() {
+		release := make(chan struct{})
+		s, err := scheduler.New(
+			scheduler.Job{Name: "day", Interval: time.Hour, Run: func(context.Context) error { return nil }},
+			scheduler.Job{Name: "enforce", Interval: time.Hour, Run: func(context.Context) error { <-release; return nil }},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(func() {
+			cancel()
+			close(release)
+			s.Wait()
+		})
+		s.Start(ctx)
+
+		mux := http.NewServeMux()
+		mux.Handle("/api/jobs", api.Jobs(s, api.Guard{}))
+		mux.Handle("/api/jobs/", api.Jobs(s, api.Guard{}))
+		ts := httptest.NewServer(mux)
+		DeferCleanup(ts.Close)
+
+		_, body := request(http.MethodGet, ts.URL+"/api/jobs", "", nil)
+		var jobs []scheduler.Status
+		Expect(json.Unmarshal([]byte(body), &jobs)).To(Succeed())
+		Expect(jobs).To(HaveLen(2))
+		Expect(jobs[0].Name).To(Equal("day"))
+		Expect(jobs[1].Name).To(Equal("enforce"))
+
+		// Wait until the first run of enforce has started and blocks.
+		Eventually(func() bool { return s.Status()[1].Running }).Should(BeTrue())
+
+		for path, want := range map[string]int{
+			"/api/jobs/day/run":     http.StatusAccepted,
+			"/api/jobs/enforce/run": http.StatusConflict,
+			"/api/jobs/report/run":  http.StatusNotFound,
+		} {
+			resp, _ := request(http.MethodPost, ts.URL+path, "", jsonHeader)
+			Expect(resp.StatusCode).To(Equal(want), path)
+		}
+	})
+
+	It("protects runs", func() {
+		runs := make(chan struct{}, 1)
+		s, err := scheduler.New(scheduler.Job{Name: "day", Interval: time.Hour, Run: func(context.Context) error {
+			runs <- struct{}{}
+			return nil
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		ts := httptest.NewServer(api.Jobs(s, api.Guard{Token: "secret"}))
+		DeferCleanup(ts.Close)
+
+		resp, _ := request(http.MethodPost, ts.URL+"/api/jobs/day/run", "", jsonHeader)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+		Expect(runs).To(BeEmpty())
+
+		resp, _ = request(http.MethodPost, ts.URL+"/api/jobs/day/run", "", map[string]string{
+			"Content-Type": "application/json", "Authorization": "Bearer secret",
+		})
+		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"home-gate/internal/state"
+)
+
+// Reload reports the result of the latest configuration reload on GET and
+// triggers a reload on POST, answering 422 if the new configuration was
+// rejected. The guard protects the POST.
+func Reload(reload func(source string) state.Reload, guard Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		AllowReadCORS(w, r)
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, state.LastReload())
+		case http.MethodPost:
+			if !guard.AllowWrite(w, r) {
+				return
+			}
+			result := reload("api")
+			status := http.StatusOK
+			if !result.OK {
+				status = http.StatusUnprocessableEntity
+			}
+			writeJSON(w, status, result)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
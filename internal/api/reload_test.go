@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/state"
+)
+
+var _ = Describe("Reload", func() {
+	It("triggers a reload and reports its result", func() {
+		ok := false
+		reload := func(source string) state.Reload {
+			r := state.Reload{Source: source, OK: ok}
+			if !ok {
+				r.Error = "invalid policy"
+			}
+			state.SetReload(r)
+			return r
+		}
+		ts := httptest.NewServer(api.Reload(reload, api.Guard{}))
+		DeferCleanup(ts.Close)
+
+		resp, _ := request(http.MethodPost, ts.URL, "", jsonHeader)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+
+		ok = true
+		resp, _ = request(http.MethodPost, ts.URL, "", jsonHeader)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		_, body := request(http.MethodGet, ts.URL, "", nil)
+		var last state.Reload
+		Expect(json.Unmarshal([]byte(body), &last)).To(Succeed())
+		Expect(last.OK).To(BeTrue())
+		Expect(last.Source).To(Equal("api"))
+	})
+
+	It("protects reloads", func() {
+		calls := 0
+		reload := func(source string) state.Reload {
+			calls++
+			return state.Reload{Source: source, OK: true}
+		}
+		ts := httptest.NewServer(api.Reload(reload, api.Guard{Token: "secret"}))
+		DeferCleanup(ts.Close)
+
+		post := func(contentType, token string) int {
+			header := map[string]string{"Content-Type": contentType}
+			if token != "" {
+				header["Authorization"] = "Bearer " + token
+			}
+			resp, _ := request(http.MethodPost, ts.URL, "", header)
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+			return resp.StatusCode
+		}
+		Expect(post("application/json", "")).To(Equal(http.StatusUnauthorized))
+		Expect(post("text/plain", "secret")).To(Equal(http.StatusUnsupportedMediaType))
+		Expect(calls).To(BeZero())
+		Expect(post("application/json", "secret")).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal(1))
+	})
+})
@@ -0,0 +1,78 @@
+// Package api implements the HTTP endpoints served by the web daemon next to
+// /status and the dashboard.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"home-gate/internal/store"
+)
+
+// rewardRequest is the body of POST /api/rewards.
+type rewardRequest struct {
+	Child   string `json:"child"`
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason"`
+}
+
+// Rewards serves the earned-time ledger: GET lists the rewards of the last
+// ?days= days (default 7, optionally for ?child=), with days in loc, POST
+// awards minutes if the guard allows it.
+func Rewards(s *store.Store, loc *time.Location, guard Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		AllowReadCORS(w, r)
+		switch r.Method {
+		case http.MethodGet:
+			days := 7
+			if v := r.URL.Query().Get("days"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 {
+					http.Error(w, "days must be a positive number", http.StatusBadRequest)
+					return
+				}
+				days = n
+			}
+			now := time.Now().In(loc)
+			rewards, err := s.Rewards(r.URL.Query().Get("child"), now.AddDate(0, 0, 1-days), now)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if rewards == nil {
+				rewards = []store.Reward{}
+			}
+			writeJSON(w, http.StatusOK, rewards)
+		case http.MethodPost:
+			if !guard.AllowWrite(w, r) {
+				return
+			}
+			var req rewardRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			reward := store.Reward{Time: time.Now(), Child: req.Child, Minutes: req.Minutes, Reason: req.Reason}
+			if err := reward.Validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := s.AwardReward(reward, store.ActorAPI); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, reward)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,111 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Rewards", func() {
+	const body = `{"child":"iPad","minutes":30,"reason":"dishes"}`
+	var s *store.Store
+
+	BeforeEach(func() {
+		var err error
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	serve := func(guard api.Guard) *httptest.Server {
+		ts := httptest.NewServer(api.Rewards(s, time.UTC, guard))
+		DeferCleanup(ts.Close)
+		return ts
+	}
+
+	It("awards and lists rewards", func() {
+		ts := serve(api.Guard{})
+		resp, _ := request(http.MethodPost, ts.URL, body, jsonHeader)
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+		_, list := request(http.MethodGet, ts.URL+"?child=ipad", "", nil)
+		var rewards []store.Reward
+		Expect(json.Unmarshal([]byte(list), &rewards)).To(Succeed())
+		Expect(rewards).To(HaveLen(1))
+		Expect(rewards[0].Minutes).To(Equal(30))
+		Expect(rewards[0].Reason).To(Equal("dishes"))
+
+		entries, err := s.Audit("iPad", rewards[0].Time, rewards[0].Time.Add(time.Second))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal(store.ActionReward))
+		Expect(entries[0].Actor).To(Equal(store.ActorAPI))
+	})
+
+	It("lists the rewards of the days in the configured time zone", func() {
+		loc, before, after := dayBorder()
+		Expect(s.AddReward(store.Reward{Time: before, Child: "iPad", Minutes: 10, Reason: "yesterday"})).To(Succeed())
+		Expect(s.AddReward(store.Reward{Time: after, Child: "iPad", Minutes: 20, Reason: "today"})).To(Succeed())
+		ts := httptest.NewServer(api.Rewards(s, loc, api.Guard{}))
+		DeferCleanup(ts.Close)
+
+		_, list := request(http.MethodGet, ts.URL+"?days=1", "", nil)
+		var rewards []store.Reward
+		Expect(json.Unmarshal([]byte(list), &rewards)).To(Succeed())
+		Expect(rewards).To(HaveLen(1))
+		Expect(rewards[0].Reason).To(Equal("today"))
+	})
+
+	DescribeTable("rejects invalid rewards",
+		func(body string) {
+			resp, _ := request(http.MethodPost, serve(api.Guard{}).URL, body, jsonHeader)
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+		},
+		Entry("without child", `{"minutes":30}`),
+		Entry("without minutes", `{"child":"iPad"}`),
+		Entry("without JSON", `not json`),
+	)
+
+	It("rejects other methods", func() {
+		resp, _ := request(http.MethodDelete, serve(api.Guard{}).URL, "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	DescribeTable("protects awards without a token",
+		func(header map[string]string, status int) {
+			ts := serve(api.Guard{})
+			if header["Origin"] == "same" {
+				header["Origin"] = ts.URL
+			}
+			resp, _ := request(http.MethodPost, ts.URL, body, header)
+			Expect(resp.StatusCode).To(Equal(status))
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+		},
+		Entry("form post", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType),
+		Entry("other origin", map[string]string{"Content-Type": "application/json", "Origin": "https://evil.example"}, http.StatusForbidden),
+		Entry("cross-site fetch", map[string]string{"Content-Type": "application/json", "Sec-Fetch-Site": "cross-site"}, http.StatusForbidden),
+		Entry("same origin", map[string]string{"Content-Type": "application/json", "Origin": "same"}, http.StatusCreated),
+		Entry("outside a browser", map[string]string{"Content-Type": "application/json; charset=utf-8"}, http.StatusCreated),
+	)
+
+	It("needs the token once one is set", func() {
+		ts := serve(api.Guard{Token: "secret"})
+		resp, _ := request(http.MethodPost, ts.URL, body, jsonHeader)
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		resp, _ = request(http.MethodPost, ts.URL, body, map[string]string{"Content-Type": "application/json", "Authorization": "Bearer wrong"})
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+		resp, _ = request(http.MethodPost, ts.URL, body, map[string]string{"Content-Type": "application/json", "Authorization": "Bearer secret"})
+		Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+
+		// Reads stay open.
+		resp, _ = request(http.MethodGet, ts.URL, "", nil)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+	})
+})
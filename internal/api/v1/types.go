@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"time"
+
+	"home-gate/internal/iso8601"
+	"home-gate/internal/monitor"
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+	"home-gate/internal/store"
+)
+
+// The types of the v1 API. Keys are snake_case, times RFC 3339 and durations
+// ISO 8601 strings, see openapi.yaml.
+
+// Status is the result of the latest monitoring run.
+type Status struct {
+	StartedAt      time.Time `json:"started_at,omitzero"`
+	Duration       string    `json:"duration"`
+	DevicesChecked int       `json:"devices_checked"`
+	UsersFetched   int       `json:"users_fetched"`
+	Errors         []string  `json:"errors"`
+	Devices        []Device  `json:"devices"`
+}
+
+// Device is a device's usage today.
+type Device struct {
+	Name    string   `json:"name"`
+	MAC     string   `json:"mac"`
+	UID     string   `json:"uid,omitempty"`
+	Child   string   `json:"child,omitempty"`
+	Routers []string `json:"routers,omitempty"`
+	// ActiveTime is today's active time, counted in steps of Precision.
+	ActiveTime string    `json:"active_time"`
+	Precision  string    `json:"precision"`
+	Sessions   []Session `json:"sessions"`
+	// Quota includes Bank and Bonus.
+	Quota            string   `json:"quota"`
+	Bank             string   `json:"bank"`
+	Bonus            string   `json:"bonus"`
+	WeeklyRemaining  *string  `json:"weekly_remaining,omitempty"`
+	MonthlyRemaining *string  `json:"monthly_remaining,omitempty"`
+	Rewards          []Reward `json:"rewards"`
+}
+
+// Session is a stretch of activity.
+type Session struct {
+	Start    time.Time `json:"start"`
+	Duration string    `json:"duration"`
+}
+
+// Reward is bonus time awarded to a child; a negative duration takes time
+// away.
+type Reward struct {
+	Time     time.Time `json:"time"`
+	Child    string    `json:"child"`
+	Duration string    `json:"duration"`
+	Reason   string    `json:"reason"`
+}
+
+// NewReward is the body of POST /api/v1/rewards.
+type NewReward struct {
+	Child    string `json:"child"`
+	Duration string `json:"duration"`
+	Reason   string `json:"reason"`
+}
+
+// AuditEntry is a block, unblock, reward or reload.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Device  string    `json:"device"`
+	Name    string    `json:"name,omitempty"`
+	MAC     string    `json:"mac,omitempty"`
+	UserUID string    `json:"user_uid,omitempty"`
+	Router  string    `json:"router,omitempty"`
+	Action  string    `json:"action"`
+	Actor   string    `json:"actor"`
+	Reason  string    `json:"reason"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Job is a scheduled job of the daemon.
+type Job struct {
+	Name      string    `json:"name"`
+	Interval  string    `json:"interval"`
+	Running   bool      `json:"running"`
+	Runs      int       `json:"runs"`
+	Skipped   int       `json:"skipped"`
+	LastStart time.Time `json:"last_start,omitzero"`
+	LastEnd   time.Time `json:"last_end,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+	NextRun   time.Time `json:"next_run,omitzero"`
+}
+
+// Reload is the result of the latest configuration reload.
+type Reload struct {
+	Time   time.Time `json:"time,omitzero"`
+	Source string    `json:"source,omitempty"`
+	OK     bool      `json:"ok"`
+	Error  string    `json:"error,omitempty"`
+	Policy string    `json:"policy,omitempty"`
+}
+
+// Error is the body of every error response.
+type Error struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error: Code is a stable snake_case identifier
+// derived from Status, e.g. not_found.
+type ErrorDetail struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newStatus(s monitor.Summary) Status {
+	st := Status{
+		StartedAt:      s.StartTime,
+		Duration:       iso8601.FormatDuration(s.Duration),
+		DevicesChecked: s.DevicesChecked,
+		UsersFetched:   s.UsersFetched,
+		Errors:         []string{},
+		Devices:        []Device{},
+	}
+	for _, err := range s.Errors {
+		st.Errors = append(st.Errors, err.Error())
+	}
+	for _, d := range s.Devices {
+		st.Devices = append(st.Devices, newDevice(d, s.StartTime))
+	}
+	return st
+}
+
+// newDevice converts a device's usage; day is the day of the run, which
+// dates the activity blocks.
+func newDevice(d monitor.DeviceUsage, day time.Time) Device {
+	dev := Device{
+		Name:       d.Name,
+		MAC:        d.MAC,
+		UID:        d.UID,
+		Child:      d.Child,
+		Routers:    d.Routers,
+		ActiveTime: iso8601.FormatMinutes(d.DailyActiveMinutes),
+		Precision:  d.Precision,
+		Sessions:   []Session{},
+		Quota:      iso8601.FormatMinutes(d.QuotaMinutes),
+		Bank:       iso8601.FormatMinutes(d.BankMinutes),
+		Bonus:      iso8601.FormatMinutes(d.BonusMinutes),
+		Rewards:    []Reward{},
+	}
+	for _, block := range d.Active {
+		start, dur, err := iso8601.ParseBlock(day, block)
+		if err != nil {
+			continue
+		}
+		dev.Sessions = append(dev.Sessions, Session{Start: start, Duration: iso8601.FormatDuration(dur)})
+	}
+	if d.WeeklyRemainingMinutes != nil {
+		v := iso8601.FormatMinutes(*d.WeeklyRemainingMinutes)
+		dev.WeeklyRemaining = &v
+	}
+	if d.MonthlyRemainingMinutes != nil {
+		v := iso8601.FormatMinutes(*d.MonthlyRemainingMinutes)
+		dev.MonthlyRemaining = &v
+	}
+	for _, r := range d.Rewards {
+		dev.Rewards = append(dev.Rewards, newReward(r))
+	}
+	return dev
+}
+
+func newReward(r store.Reward) Reward {
+	return Reward{Time: r.Time, Child: r.Child, Duration: iso8601.FormatMinutes(r.Minutes), Reason: r.Reason}
+}
+
+func newAuditEntry(e store.AuditEntry) AuditEntry {
+	return AuditEntry{
+		Time:    e.Time,
+		Device:  e.Device,
+		Name:    e.Name,
+		MAC:     e.MAC,
+		UserUID: e.UserUID,
+		Router:  e.Router,
+		Action:  e.Action,
+		Actor:   e.Actor,
+		Reason:  e.Reason,
+		Error:   e.Error,
+	}
+}
+
+func newJob(s scheduler.Status) Job {
+	return Job{
+		Name:      s.Name,
+		Interval:  iso8601.FormatDuration(s.Interval),
+		Running:   s.Running,
+		Runs:      s.Runs,
+		Skipped:   s.Skipped,
+		LastStart: s.LastStart,
+		LastEnd:   s.LastEnd,
+		LastError: s.LastError,
+		NextRun:   s.NextRun,
+	}
+}
+
+func newReload(r state.Reload) Reload {
+	return Reload{Time: r.Time, Source: r.Source, OK: r.OK, Error: r.Error, Policy: r.Policy}
+}
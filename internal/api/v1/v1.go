@@ -0,0 +1,233 @@
+// Package v1 implements the versioned REST API served under /api/v1. Unlike
+// the older endpoints it uses explicit response types with snake_case keys,
+// RFC 3339 times, ISO 8601 durations and JSON error bodies, and it is
+// described by the OpenAPI document served at /api/v1/openapi.yaml.
+package v1
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"home-gate/internal/api"
+	"home-gate/internal/iso8601"
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+	"home-gate/internal/store"
+)
+
+// Prefix is the path the API is served under.
+const Prefix = "/api/v1"
+
+// OpenAPI is the OpenAPI 3 document describing the API.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// Options are the parts of the daemon the API serves.
+type Options struct {
+	Store *store.Store
+	// Location is the time zone of dates in queries.
+	Location  *time.Location
+	Scheduler *scheduler.Scheduler
+	// Reload reloads the configuration, see state.Reload.
+	Reload func(source string) state.Reload
+	// Guard protects the requests that change state, see api.Guard.
+	Guard api.Guard
+}
+
+// Handler returns the handler for all paths under Prefix.
+func Handler(opts Options) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(Prefix+"/status", methods{http.MethodGet: opts.status}.serve)
+	mux.HandleFunc(Prefix+"/rewards", methods{http.MethodGet: opts.rewards, http.MethodPost: opts.guard(opts.addReward)}.serve)
+	mux.HandleFunc(Prefix+"/audit", methods{http.MethodGet: opts.audit}.serve)
+	mux.HandleFunc(Prefix+"/jobs", methods{http.MethodGet: opts.jobs}.serve)
+	mux.HandleFunc(Prefix+"/jobs/{name}/run", methods{http.MethodPost: opts.guard(opts.runJob)}.serve)
+	mux.HandleFunc(Prefix+"/reload", methods{http.MethodGet: opts.lastReload, http.MethodPost: opts.guard(opts.reload)}.serve)
+	mux.HandleFunc(Prefix+"/openapi.yaml", methods{http.MethodGet: serveOpenAPI}.serve)
+	mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no such endpoint: %s", r.URL.Path))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Like /api/audit, the audit log is not for other web sites.
+		if r.URL.Path != Prefix+"/audit" {
+			api.AllowReadCORS(w, r)
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// guard answers a write the Guard rejects with an error body instead of
+// calling h.
+func (o Options) guard(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status, err := o.Guard.Check(r); err != nil {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", api.Challenge)
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		h(w, r)
+	}
+}
+
+// methods routes a path by request method, answering 405 with the allowed
+// methods otherwise.
+type methods map[string]http.HandlerFunc
+
+func (m methods) serve(w http.ResponseWriter, r *http.Request) {
+	if h, ok := m[r.Method]; ok {
+		h(w, r)
+		return
+	}
+	allowed := make([]string, 0, len(m))
+	for method := range m {
+		allowed = append(allowed, method)
+	}
+	slices.Sort(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+}
+
+func (o Options) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, newStatus(state.Get()))
+}
+
+// rewards lists the rewards of the last ?days= days (default 7) in Location,
+// optionally for ?child=.
+func (o Options) rewards(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		days = n
+	}
+	now := time.Now().In(o.Location)
+	rewards, err := o.Store.Rewards(r.URL.Query().Get("child"), now.AddDate(0, 0, 1-days), now)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := []Reward{}
+	for _, rw := range rewards {
+		resp = append(resp, newReward(rw))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (o Options) addReward(w http.ResponseWriter, r *http.Request) {
+	var req NewReward
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	d, err := iso8601.ParseDuration(req.Duration)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "duration: "+err.Error())
+		return
+	}
+	if d%time.Minute != 0 {
+		writeError(w, http.StatusBadRequest, "duration must be whole minutes")
+		return
+	}
+	reward := store.Reward{Time: time.Now(), Child: req.Child, Minutes: int(d / time.Minute), Reason: req.Reason}
+	if err := reward.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := o.Store.AwardReward(reward, store.ActorAPI); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, newReward(reward))
+}
+
+// audit lists the audited actions between ?from= and ?to=, see
+// store.AuditRange, optionally for ?device=.
+func (o Options) audit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := store.AuditRange(q.Get("from"), q.Get("to"), time.Now(), o.Location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, err := o.Store.Audit(q.Get("device"), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := []AuditEntry{}
+	for _, e := range entries {
+		resp = append(resp, newAuditEntry(e))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (o Options) jobs(w http.ResponseWriter, r *http.Request) {
+	resp := []Job{}
+	for _, s := range o.Scheduler.Status() {
+		resp = append(resp, newJob(s))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (o Options) runJob(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	switch err := o.Scheduler.Trigger(name); {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, scheduler.ErrRunning):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		for _, s := range o.Scheduler.Status() {
+			if s.Name == name {
+				writeJSON(w, http.StatusAccepted, newJob(s))
+				return
+			}
+		}
+	}
+}
+
+func (o Options) lastReload(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, newReload(state.LastReload()))
+}
+
+// reload reloads the configuration, answering 422 if it was rejected.
+func (o Options) reload(w http.ResponseWriter, r *http.Request) {
+	result := o.Reload("api")
+	if !result.OK {
+		writeError(w, http.StatusUnprocessableEntity, "configuration rejected, keeping the previous one: "+result.Error)
+		return
+	}
+	writeJSON(w, http.StatusOK, newReload(result))
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(OpenAPI)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error body for status.
+func writeError(w http.ResponseWriter, status int, message string) {
+	code := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	writeJSON(w, status, Error{Error: ErrorDetail{Status: status, Code: code, Message: message}})
+}
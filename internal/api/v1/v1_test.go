@@ -0,0 +1,241 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/api"
+	v1 "home-gate/internal/api/v1"
+	"home-gate/internal/monitor"
+	"home-gate/internal/scheduler"
+	"home-gate/internal/state"
+	"home-gate/internal/store"
+)
+
+func newServer(guard api.Guard) *httptest.Server {
+	GinkgoHelper()
+	history, err := store.Open(GinkgoT().TempDir())
+	Expect(err).ToNot(HaveOccurred())
+	return serve(v1.Options{Store: history, Location: time.UTC, Guard: guard})
+}
+
+// serve serves the API with opts, a scheduler with a report job and a reload
+// that rejects the configuration.
+func serve(opts v1.Options) *httptest.Server {
+	GinkgoHelper()
+	sched, err := scheduler.New(scheduler.Job{Name: "report", Interval: 5 * time.Minute, Run: func(context.Context) error { return nil }})
+	Expect(err).ToNot(HaveOccurred())
+	opts.Scheduler = sched
+	opts.Reload = func(source string) state.Reload {
+		return state.Reload{Source: source, Error: "invalid policy"}
+	}
+	ts := httptest.NewServer(v1.Handler(opts))
+	DeferCleanup(ts.Close)
+	return ts
+}
+
+// send sends a request with the given headers and decodes the JSON response
+// into v.
+func send(method, url, body string, header map[string]string, v any) *http.Response {
+	GinkgoHelper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	Expect(err).ToNot(HaveOccurred())
+	for k, val := range header {
+		req.Header.Set(k, val)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = resp.Body.Close() }()
+	Expect(json.NewDecoder(resp.Body).Decode(v)).To(Succeed())
+	return resp
+}
+
+// do sends a request, as JSON unless it is a GET, and decodes the JSON
+// response into v.
+func do(method, url, body string, v any) int {
+	GinkgoHelper()
+	var header map[string]string
+	if method != http.MethodGet {
+		header = map[string]string{"Content-Type": "application/json"}
+	}
+	return send(method, url, body, header, v).StatusCode
+}
+
+var _ = Describe("API v1", func() {
+	var ts *httptest.Server
+
+	BeforeEach(func() {
+		ts = newServer(api.Guard{})
+	})
+
+	It("reports the status with ISO durations and sessions", func() {
+		weekly := 90
+		state.Update(monitor.Summary{
+			DevicesChecked: 3,
+			UsersFetched:   2,
+			Errors:         []error{errors.New("MAC tv not found in data")},
+			StartTime:      time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+			Duration:       1500 * time.Millisecond,
+			Devices: []monitor.DeviceUsage{{
+				MAC:                    "aa11bb22cc33",
+				Name:                   "iPad",
+				DailyActiveMinutes:     75,
+				Precision:              monitor.PrecisionMinute,
+				Active:                 []string{"10:00+02:00/PT1H15M"},
+				QuotaMinutes:           120,
+				WeeklyRemainingMinutes: &weekly,
+			}},
+		})
+		DeferCleanup(state.Reset)
+
+		var raw map[string]any
+		Expect(do(http.MethodGet, ts.URL+"/api/v1/status", "", &raw)).To(Equal(http.StatusOK))
+		Expect(raw).To(HaveKeyWithValue("duration", "PT1.5S"))
+		Expect(raw).To(HaveKeyWithValue("devices_checked", 3.0))
+		Expect(raw).To(HaveKeyWithValue("started_at", "2026-10-18T12:00:00Z"))
+		Expect(raw["errors"]).To(Equal([]any{"MAC tv not found in data"}))
+		dev := raw["devices"].([]any)[0].(map[string]any)
+		Expect(dev).To(HaveKeyWithValue("active_time", "PT1H15M"))
+		Expect(dev).To(HaveKeyWithValue("quota", "PT2H"))
+		Expect(dev).To(HaveKeyWithValue("bonus", "PT0S"))
+		Expect(dev).To(HaveKeyWithValue("weekly_remaining", "PT1H30M"))
+		session := dev["sessions"].([]any)[0].(map[string]any)
+		Expect(session).To(HaveKeyWithValue("start", "2026-10-18T10:00:00+02:00"))
+		Expect(session).To(HaveKeyWithValue("duration", "PT1H15M"))
+	})
+
+	It("accepts rewards with ISO durations", func() {
+		var reward v1.Reward
+		Expect(do(http.MethodPost, ts.URL+"/api/v1/rewards", `{"child":"alice","duration":"PT15M","reason":"chores"}`, &reward)).To(Equal(http.StatusCreated))
+		Expect(reward.Duration).To(Equal("PT15M"))
+		Expect(reward.Child).To(Equal("alice"))
+
+		var e v1.Error
+		Expect(do(http.MethodPost, ts.URL+"/api/v1/rewards", `{"child":"alice","duration":"15"}`, &e)).To(Equal(http.StatusBadRequest))
+		Expect(e.Error.Code).To(Equal("bad_request"))
+		Expect(e.Error.Message).To(ContainSubstring("duration"))
+
+		var rewards []v1.Reward
+		Expect(do(http.MethodGet, ts.URL+"/api/v1/rewards?child=alice", "", &rewards)).To(Equal(http.StatusOK))
+		Expect(rewards).To(HaveLen(1))
+	})
+
+	It("lists the rewards of the days in Location", func() {
+		history, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		loc, before, after := dayBorder()
+		Expect(history.AddReward(store.Reward{Time: before, Child: "alice", Minutes: 10, Reason: "yesterday"})).To(Succeed())
+		Expect(history.AddReward(store.Reward{Time: after, Child: "alice", Minutes: 20, Reason: "today"})).To(Succeed())
+		ts := serve(v1.Options{Store: history, Location: loc})
+
+		var rewards []v1.Reward
+		Expect(do(http.MethodGet, ts.URL+"/api/v1/rewards?days=1", "", &rewards)).To(Equal(http.StatusOK))
+		Expect(rewards).To(HaveLen(1))
+		Expect(rewards[0].Reason).To(Equal("today"))
+	})
+
+	DescribeTable("answers errors with the error body",
+		func(method, path string, status int, code string) {
+			var e v1.Error
+			Expect(do(method, ts.URL+path, "", &e)).To(Equal(status))
+			Expect(e.Error.Code).To(Equal(code))
+			Expect(e.Error.Status).To(Equal(status))
+			Expect(e.Error.Message).ToNot(BeEmpty())
+		},
+		Entry("unknown endpoint", http.MethodGet, "/api/v1/unknown", http.StatusNotFound, "not_found"),
+		Entry("wrong method", http.MethodDelete, "/api/v1/status", http.StatusMethodNotAllowed, "method_not_allowed"),
+		Entry("unknown job", http.MethodPost, "/api/v1/jobs/backup/run", http.StatusNotFound, "not_found"),
+		Entry("rejected reload", http.MethodPost, "/api/v1/reload", http.StatusUnprocessableEntity, "unprocessable_entity"),
+		Entry("invalid time", http.MethodGet, "/api/v1/audit?from=yesterday", http.StatusBadRequest, "bad_request"),
+	)
+
+	It("reports job intervals as durations", func() {
+		var jobs []v1.Job
+		Expect(do(http.MethodGet, ts.URL+"/api/v1/jobs", "", &jobs)).To(Equal(http.StatusOK))
+		Expect(jobs).To(HaveLen(1))
+		Expect(jobs[0].Interval).To(Equal("PT5M"))
+	})
+
+	It("serves an OpenAPI document describing every endpoint", func() {
+		resp, err := http.Get(ts.URL + "/api/v1/openapi.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		_ = resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/yaml"))
+		doc := string(v1.OpenAPI)
+		for _, path := range []string{"/status", "/rewards", "/audit", "/jobs", "/jobs/{name}/run", "/reload", "/openapi.yaml"} {
+			Expect(doc).To(ContainSubstring("\n  "+path+":\n"), path)
+		}
+		Expect(doc).To(ContainSubstring("scheme: bearer"))
+	})
+
+	It("rejects writes from other origins without a token", func() {
+		var e v1.Error
+		resp := send(http.MethodPost, ts.URL+"/api/v1/reload", "", map[string]string{
+			"Content-Type": "application/json", "Origin": "https://evil.example",
+		}, &e)
+		Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		Expect(e.Error.Code).To(Equal("forbidden"))
+	})
+
+	It("does not let other web sites read the audit log", func() {
+		var entries []v1.AuditEntry
+		resp := send(http.MethodGet, ts.URL+"/api/v1/audit", "", map[string]string{"Origin": "https://evil.example"}, &entries)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+
+	Context("with an API token", func() {
+		BeforeEach(func() {
+			ts = newServer(api.Guard{Token: "s3cret"})
+		})
+
+		DescribeTable("guards writes",
+			func(path string) {
+				var e v1.Error
+				Expect(do(http.MethodPost, ts.URL+path, `{"child":"alice","duration":"PT15M"}`, &e)).To(Equal(http.StatusUnauthorized))
+				Expect(e.Error.Code).To(Equal("unauthorized"))
+			},
+			Entry("rewards", "/api/v1/rewards"),
+			Entry("job runs", "/api/v1/jobs/report/run"),
+			Entry("reloads", "/api/v1/reload"),
+		)
+
+		It("accepts writes with the token, without CORS headers", func() {
+			var reward v1.Reward
+			resp := send(http.MethodPost, ts.URL+"/api/v1/rewards", `{"child":"alice","duration":"PT15M"}`, map[string]string{
+				"Content-Type": "application/json", "Authorization": "Bearer s3cret",
+			}, &reward)
+			Expect(resp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(BeEmpty())
+
+			var rewards []v1.Reward
+			resp = send(http.MethodGet, ts.URL+"/api/v1/rewards", "", nil, &rewards)
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+		})
+	})
+})
+
+// dayBorder returns a time zone whose today is another day than the local
+// one, and the times a minute before and after its last midnight, so the
+// local time zone and the returned one put them on different days.
+func dayBorder() (loc *time.Location, before, after time.Time) {
+	now := time.Now()
+	_, offset := now.Zone()
+	shift := 14 * 60 * 60
+	if now.Hour() < 12 {
+		shift = -shift
+	}
+	loc = time.FixedZone("far", offset+shift)
+	today := now.In(loc)
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	return loc, midnight.Add(-time.Minute), midnight.Add(time.Minute)
+}
@@ -0,0 +1,170 @@
+// Package calendar reads events from iCalendar (.ics) files, such as school
+// holiday calendars, so they can switch the effective policy, and writes
+// calendar feeds.
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Event is a calendar event. End is exclusive, as in iCalendar.
+type Event struct {
+	// UID identifies the event across versions of a feed. Optional.
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	// AllDay is set for date-only events such as holidays.
+	AllDay bool
+}
+
+// LoadFile parses the events of an .ics file, see Parse.
+func LoadFile(path string, loc *time.Location) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	events, err := Parse(f, loc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
+
+// Parse reads the VEVENTs of an iCalendar stream. Only SUMMARY, DTSTART and
+// DTEND are interpreted; recurrence rules are ignored. Dates without a time
+// are all-day events, and times without a time zone (floating times) are in
+// loc.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	for i, line := range lines {
+		name, params, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", i+1)
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, current.Summary)
+			}
+			if current.End.IsZero() {
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				} else {
+					current.End = current.Start
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "DESCRIPTION":
+			current.Description = unescape(value)
+		case name == "DTSTART" || name == "DTEND":
+			t, allDay, err := parseTime(params, value, loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if name == "DTSTART" {
+				current.Start, current.AllDay = t, allDay
+			} else {
+				current.End = t
+			}
+		}
+	}
+	return events, nil
+}
+
+// Days returns the first and last day (inclusive, at midnight) the event
+// touches in the local time zone.
+func (e Event) Days() (time.Time, time.Time) {
+	return e.DaysIn(time.Local)
+}
+
+// DaysIn is Days for the time zone loc. All-day events keep their dates,
+// timed events are converted to loc first.
+func (e Event) DaysIn(loc *time.Location) (time.Time, time.Time) {
+	day := func(t time.Time) time.Time {
+		if !e.AllDay {
+			t = t.In(loc)
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	first := day(e.Start)
+	last := first
+	if e.End.After(e.Start) {
+		last = day(e.End.Add(-time.Nanosecond))
+	}
+	return first, last
+}
+
+// unfold joins continuation lines (starting with a space or tab) per RFC 5545.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitProperty splits "DTSTART;VALUE=DATE:20240101" into name, params and value.
+func splitProperty(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params := make(map[string]string)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseTime parses a DTSTART or DTEND value. Dates and floating times are in
+// loc, as is a time with an unknown TZID.
+func parseTime(params map[string]string, value string, loc *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, "\n", `\N`, "\n", `\\`, `\`).Replace(s)
+}
@@ -0,0 +1,129 @@
+package calendar_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/calendar"
+)
+
+const holidays = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Herbstferien\\, Niedersachsen\r\n" +
+	"DTSTART;VALUE=DATE:20261012\r\n" +
+	"DTEND;VALUE=DATE:20261024\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Birthday party at\r\n" +
+	"  Grandma's\r\n" +
+	"DTSTART:20261107T140000Z\r\n" +
+	"DTEND:20261107T180000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Reformationstag\r\n" +
+	"DTSTART;VALUE=DATE:20261031\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+var _ = Describe("Parse", func() {
+	It("reads all-day and timed events", func() {
+		events, err := calendar.Parse(strings.NewReader(holidays), time.Local)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(3))
+
+		Expect(events[0].Summary).To(Equal("Herbstferien, Niedersachsen"))
+		Expect(events[0].AllDay).To(BeTrue())
+		first, last := events[0].Days()
+		Expect(first).To(Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)))
+		Expect(last).To(Equal(time.Date(2026, 10, 23, 0, 0, 0, 0, time.Local)))
+
+		Expect(events[1].Summary).To(Equal("Birthday party at Grandma's"))
+		Expect(events[1].AllDay).To(BeFalse())
+		Expect(events[1].Start).To(Equal(time.Date(2026, 11, 7, 14, 0, 0, 0, time.UTC)))
+
+		first, last = events[2].Days()
+		Expect(first).To(Equal(last))
+	})
+
+	It("places days in the given time zone", func() {
+		events, err := calendar.Parse(strings.NewReader(holidays), time.Local)
+		Expect(err).ToNot(HaveOccurred())
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		Expect(err).ToNot(HaveOccurred())
+
+		// All-day events keep their dates.
+		first, last := events[0].DaysIn(tokyo)
+		Expect(first).To(Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, tokyo)))
+		Expect(last).To(Equal(time.Date(2026, 10, 23, 0, 0, 0, 0, tokyo)))
+
+		// 14:00-18:00 UTC is 23:00-03:00 in Tokyo.
+		first, last = events[1].DaysIn(tokyo)
+		Expect(first).To(Equal(time.Date(2026, 11, 7, 0, 0, 0, 0, tokyo)))
+		Expect(last).To(Equal(time.Date(2026, 11, 8, 0, 0, 0, 0, tokyo)))
+	})
+
+	It("reads floating times in the given time zone, not the local one", func() {
+		_, offset := time.Now().Zone()
+		loc := time.FixedZone("configured", offset+10*60*60)
+		const party = "BEGIN:VEVENT\r\n" +
+			"SUMMARY:Sleepover\r\n" +
+			"DTSTART:20261107T230000\r\n" +
+			"DTEND:20261108T020000\r\n" +
+			"END:VEVENT\r\n"
+		events, err := calendar.Parse(strings.NewReader(party), loc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Start).To(BeTemporally("==", time.Date(2026, 11, 7, 23, 0, 0, 0, loc)))
+		Expect(events[0].End).To(BeTemporally("==", time.Date(2026, 11, 8, 2, 0, 0, 0, loc)))
+
+		first, last := events[0].DaysIn(loc)
+		Expect(first).To(Equal(time.Date(2026, 11, 7, 0, 0, 0, 0, loc)))
+		Expect(last).To(Equal(time.Date(2026, 11, 8, 0, 0, 0, 0, loc)))
+	})
+
+	It("rejects events without a start", func() {
+		_, err := calendar.Parse(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n"), time.Local)
+		Expect(err).To(MatchError(ContainSubstring("has no DTSTART")))
+	})
+})
+
+var _ = Describe("Write", func() {
+	It("writes events that parse back", func() {
+		events := []calendar.Event{
+			{
+				UID:         "20261018T0800Z-ipad@home-gate",
+				Summary:     "Lena online (iPad; living room, upstairs)",
+				Description: strings.Repeat("Active for PT45M ", 16),
+				Start:       time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+				End:         time.Date(2026, 10, 18, 8, 45, 0, 0, time.UTC),
+			},
+			{Summary: "Herbstferien", Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local), End: time.Date(2026, 10, 24, 0, 0, 0, 0, time.Local), AllDay: true},
+		}
+		var b strings.Builder
+		Expect(calendar.Write(&b, "home-gate: Lena", events, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))).To(Succeed())
+
+		ics := b.String()
+		Expect(ics).To(HavePrefix("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		Expect(ics).To(ContainSubstring("X-WR-CALNAME:home-gate: Lena\r\n"))
+		Expect(ics).To(ContainSubstring(`SUMMARY:Lena online (iPad\; living room\, upstairs)` + "\r\n"))
+		Expect(ics).To(ContainSubstring("DTSTAMP:20261018T120000Z\r\n"))
+		for _, line := range strings.Split(ics, "\r\n") {
+			Expect(len(line)).To(BeNumerically("<=", 75))
+		}
+
+		parsed, err := calendar.Parse(strings.NewReader(ics), time.Local)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(HaveLen(2))
+		Expect(parsed[0].UID).To(Equal(events[0].UID))
+		Expect(parsed[0].Summary).To(Equal(events[0].Summary))
+		Expect(parsed[0].Description).To(Equal(events[0].Description))
+		Expect(parsed[0].Start).To(Equal(events[0].Start))
+		Expect(parsed[0].End).To(Equal(events[0].End))
+		Expect(parsed[1].AllDay).To(BeTrue())
+		Expect(parsed[1].End).To(Equal(events[1].End))
+	})
+})
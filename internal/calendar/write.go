@@ -0,0 +1,63 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Write writes the events as an iCalendar stream named name, e.g. for a
+// subscription in a calendar app. Timed events are written in UTC; now is the
+// DTSTAMP of every event.
+func Write(w io.Writer, name string, events []Event, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Lines are folded after 75 octets, without splitting UTF-8 sequences.
+		// Continuation lines start with a space, leaving 74 for the text.
+		limit := 75
+		for len(s) > limit {
+			cut := limit
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			_, _ = bw.WriteString(s[:cut] + "\r\n ")
+			s = s[cut:]
+			limit = 74
+		}
+		_, _ = bw.WriteString(s + "\r\n")
+	}
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//home-gate//home-gate//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + escape(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		if e.UID != "" {
+			line("UID:" + e.UID)
+		}
+		line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			line("DTSTART;VALUE=DATE:" + e.Start.Format("20060102"))
+			line("DTEND;VALUE=DATE:" + e.End.Format("20060102"))
+		} else {
+			line("DTSTART:" + e.Start.UTC().Format("20060102T150405Z"))
+			line("DTEND:" + e.End.UTC().Format("20060102T150405Z"))
+		}
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`).Replace(s)
+}
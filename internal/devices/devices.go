@@ -0,0 +1,98 @@
+// Package devices lists the landevices known to the Fritz!Box together with
+// their monitoring and blocking state, so they can be picked for policies.
+package devices
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/identity"
+)
+
+// Device describes a single landevice as shown by the devices command.
+type Device struct {
+	Name      string `json:"name"`
+	MAC       string `json:"mac"`
+	UID       string `json:"uid"`
+	UserUIDs  string `json:"user_uids"`
+	Active    bool   `json:"active"`
+	Blocked   bool   `json:"blocked"`
+	Monitored bool   `json:"monitored"`
+	// Randomized is set for private (locally administered) MAC addresses.
+	Randomized bool `json:"randomized"`
+	// Identity is the name of the linked identity, if any.
+	Identity string `json:"identity,omitempty"`
+}
+
+// Filter narrows down the listed devices. Nil pointers mean "don't care".
+type Filter struct {
+	// Name matches case-insensitively against a substring of the friendly name.
+	Name    string
+	Active  *bool
+	Blocked *bool
+	// NewRandomized only keeps randomized MACs not linked to an identity yet.
+	NewRandomized bool
+}
+
+// Matches reports whether the device passes the filter.
+func (f Filter) Matches(d Device) bool {
+	if f.Name != "" && !strings.Contains(strings.ToLower(d.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.Active != nil && d.Active != *f.Active {
+		return false
+	}
+	if f.Blocked != nil && d.Blocked != *f.Blocked {
+		return false
+	}
+	if f.NewRandomized && (!d.Randomized || d.Identity != "") {
+		return false
+	}
+	return true
+}
+
+// List fetches all landevices and the monitor configuration from a connected
+// client and returns the devices passing the filter, sorted by name. The
+// registry is optional and used to report linked identities.
+func List(client fritzbox.Client, reg *identity.Registry, filter Filter) ([]Device, error) {
+	landevices, err := client.GetLandevices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch landevices: %w", err)
+	}
+	config, err := client.GetMonitorConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch monitor config: %w", err)
+	}
+	monitored := make(map[string]bool)
+	for _, uid := range strings.Split(config.DisplayHomenetDevices, ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			monitored[uid] = true
+		}
+	}
+
+	var result []Device
+	for _, ld := range landevices {
+		d := Device{
+			Name:       ld.FriendlyName,
+			MAC:        ld.MAC,
+			UID:        ld.UID,
+			UserUIDs:   ld.UserUIDs,
+			Active:     ld.IsActive(),
+			Blocked:    ld.IsBlocked(),
+			Monitored:  monitored[ld.UID],
+			Randomized: identity.IsRandomized(ld.MAC),
+		}
+		if id, ok := reg.Lookup(ld); ok {
+			d.Identity = id.Name
+		}
+		if filter.Matches(d) {
+			result = append(result, d)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package devices_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/devices"
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/fritzbox/fritzboxfakes"
+	"home-gate/internal/identity"
+)
+
+var _ = Describe("List", func() {
+	var fake *fritzboxfakes.FakeClient
+
+	BeforeEach(func() {
+		fake = &fritzboxfakes.FakeClient{}
+		fake.GetLandevicesReturns([]fritzbox.Landevice{
+			{UID: "landevice2", FriendlyName: "iPad", MAC: "AA:11:BB:22:CC:33", Active: "1", UserUIDs: "user2", Blocked: "1"},
+			{UID: "landevice1", FriendlyName: "Laptop", MAC: "00:11:22:33:44:55", Active: "0", UserUIDs: "user1", Blocked: "0"},
+			{UID: "landevice3", FriendlyName: "ipad-old", MAC: "66:77:88:99:AA:BB", Active: "0", Blocked: "0"},
+		}, nil)
+		fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1,landevice2"}, nil)
+	})
+
+	It("lists all devices sorted by name with their monitored state", func() {
+		list, err := devices.List(fake, nil, devices.Filter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(3))
+		Expect(list[0].Name).To(Equal("iPad"))
+		Expect(list[0].Active).To(BeTrue())
+		Expect(list[0].Blocked).To(BeTrue())
+		Expect(list[0].Monitored).To(BeTrue())
+		Expect(list[1].Name).To(Equal("ipad-old"))
+		Expect(list[1].Monitored).To(BeFalse())
+		Expect(list[2].Name).To(Equal("Laptop"))
+	})
+
+	It("filters by name, active and blocked state", func() {
+		inactive := false
+		list, err := devices.List(fake, nil, devices.Filter{Name: "IPAD", Active: &inactive})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].UID).To(Equal("landevice3"))
+
+		blocked := true
+		list, err = devices.List(fake, nil, devices.Filter{Blocked: &blocked})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].UID).To(Equal("landevice2"))
+	})
+
+	It("reports randomized MACs and their linked identity", func() {
+		fake.GetLandevicesReturns([]fritzbox.Landevice{
+			{UID: "landevice4", FriendlyName: "Annas-iPhone", MAC: "DA:A1:19:00:00:01"},
+			{UID: "landevice5", FriendlyName: "Bens-iPhone", MAC: "5E:00:00:00:00:02"},
+		}, nil)
+		reg, err := identity.Load(filepath.Join(GinkgoT().TempDir(), "identities.json"))
+		Expect(err).ToNot(HaveOccurred())
+		reg.Link("Anna phone", fritzbox.Landevice{UID: "landevice1", FriendlyName: "Annas-iPhone", MAC: "DA:A1:19:00:00:00"})
+
+		list, err := devices.List(fake, reg, devices.Filter{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Randomized).To(BeTrue())
+		Expect(list[0].Identity).To(Equal("Anna phone"))
+
+		list, err = devices.List(fake, reg, devices.Filter{NewRandomized: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].UID).To(Equal("landevice5"))
+	})
+
+	It("returns an error when landevices cannot be fetched", func() {
+		fake.GetLandevicesReturns(nil, errors.New("boom"))
+		_, err := devices.List(fake, nil, devices.Filter{})
+		Expect(err).To(MatchError(ContainSubstring("failed to fetch landevices")))
+	})
+})
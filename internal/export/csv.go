@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"home-gate/internal/store"
+)
+
+// WriteCSV writes the rows as CSV with a header line.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.Date.Format(store.DateFormat)}
+		for _, v := range r.values() {
+			if s, ok := v.(string); ok {
+				record = append(record, text(s))
+			} else {
+				record = append(record, fmt.Sprint(v))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// text keeps a spreadsheet from evaluating a text cell, such as a device
+// name, as a formula by prefixing it with ' if it starts like one. Numbers
+// are written as they are, so negative ones stay numbers.
+func text(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+// Package export turns the usage history into per-day, per-device rows for
+// spreadsheets, as CSV or as an Excel workbook, and into calendar events of
+// the activity sessions.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/store"
+)
+
+// Formats of an export.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Row is a device's usage on one day.
+type Row struct {
+	Date          time.Time
+	Device        string
+	MAC           string
+	ActiveMinutes int
+	QuotaMinutes  int
+	// OverMinutes is ActiveMinutes minus QuotaMinutes: positive over the
+	// quota, negative under it.
+	OverMinutes  int
+	BankMinutes  int
+	BonusMinutes int
+	// Blocks counts the successful blocks of the device that day.
+	Blocks int
+}
+
+// header holds the column names, in the order of Row.values.
+var header = []string{"date", "device", "mac", "active_minutes", "quota_minutes", "over_minutes", "bank_minutes", "bonus_minutes", "blocks"}
+
+// values returns the row's cells after the date.
+func (r Row) values() []any {
+	return []any{r.Device, r.MAC, r.ActiveMinutes, r.QuotaMinutes, r.OverMinutes, r.BankMinutes, r.BonusMinutes, r.Blocks}
+}
+
+// Range parses the dates of an export. from defaults to the first day of the
+// month of now and to to today; both are inclusive.
+func Range(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	now = now.In(loc)
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	var err error
+	if from != "" {
+		if start, err = time.ParseInLocation(store.DateFormat, from, loc); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from date %q, expected YYYY-MM-DD", from)
+		}
+	}
+	if to != "" {
+		if end, err = time.ParseInLocation(store.DateFormat, to, loc); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date %q, expected YYYY-MM-DD", to)
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to date %s is before from date %s", end.Format(store.DateFormat), start.Format(store.DateFormat))
+	}
+	return start, end, nil
+}
+
+// Rows returns the recorded usage between the dates from and to (inclusive),
+// by date and device, with the blocks of the audit log. Days are in loc.
+func Rows(history *store.Store, from, to time.Time, loc *time.Location) ([]Row, error) {
+	usage, err := history.Usage("", from, to)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := history.Audit("", from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]Row, 0, len(usage))
+	for _, u := range usage {
+		date, err := time.ParseInLocation(store.DateFormat, u.Date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in usage history: %w", err)
+		}
+		row := Row{
+			Date:          date,
+			Device:        deviceName(u),
+			MAC:           u.MAC,
+			ActiveMinutes: u.ActiveMinutes,
+			QuotaMinutes:  u.QuotaMinutes,
+			OverMinutes:   u.ActiveMinutes - u.QuotaMinutes,
+			BankMinutes:   u.BankMinutes,
+			BonusMinutes:  u.BonusMinutes,
+		}
+		for _, e := range entries {
+			if e.Action == store.ActionBlock && e.Error == "" && e.Time.In(loc).Format(store.DateFormat) == u.Date && sameDevice(u, e.Device, e.MAC) {
+				row.Blocks++
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// deviceName returns the name of the device of u; records written before
+// names were kept only have the key.
+func deviceName(u store.DayUsage) string {
+	if u.Name != "" {
+		return u.Name
+	}
+	return u.Device
+}
+
+// sameDevice reports whether the device key or name or the MAC address is
+// the device of u. The audit log has the device key, entries written before
+// keys were kept the Fritz!Box name.
+func sameDevice(u store.DayUsage, name, mac string) bool {
+	if strings.EqualFold(u.Device, name) || strings.EqualFold(u.Name, name) {
+		return true
+	}
+	return u.MAC != "" && mac != "" && fritzbox.NormalizeMAC(u.MAC) == fritzbox.NormalizeMAC(mac)
+}
+
+// Write writes the rows in format, FormatCSV or FormatXLSX.
+func Write(w io.Writer, format string, rows []Row) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, rows)
+	case FormatXLSX:
+		return WriteXLSX(w, rows)
+	default:
+		return fmt.Errorf("unknown format %q, use csv or xlsx", format)
+	}
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName returns a file name for an export of the dates from to to.
+func FileName(from, to time.Time, format string) string {
+	return fmt.Sprintf("home-gate-%s-%s.%s", from.Format(store.DateFormat), to.Format(store.DateFormat), format)
+}
@@ -0,0 +1,183 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/export"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Export", func() {
+	var (
+		s   *store.Store
+		loc *time.Location
+	)
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, loc) }
+
+	BeforeEach(func() {
+		var err error
+		loc, err = time.LoadLocation("Europe/Berlin")
+		Expect(err).ToNot(HaveOccurred())
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Range", func() {
+		now := time.Date(2024, 3, 18, 15, 0, 0, 0, time.UTC)
+
+		It("defaults to the current month up to today", func() {
+			from, to, err := export.Range("", "", now, loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(from).To(Equal(day(1)))
+			Expect(to).To(Equal(day(18)))
+		})
+
+		It("parses dates", func() {
+			from, to, err := export.Range("2024-03-02", "2024-03-05", now, loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(from).To(Equal(day(2)))
+			Expect(to).To(Equal(day(5)))
+		})
+
+		It("rejects invalid or reversed dates", func() {
+			_, _, err := export.Range("03/02/2024", "", now, loc)
+			Expect(err).To(MatchError(ContainSubstring("invalid from date")))
+			_, _, err = export.Range("2024-03-05", "2024-03-02", now, loc)
+			Expect(err).To(MatchError(ContainSubstring("before")))
+		})
+	})
+
+	Describe("Rows", func() {
+		BeforeEach(func() {
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-01", Device: "iPad", MAC: "aa:bb:cc:dd:ee:01", ActiveMinutes: 75, QuotaMinutes: 60})).To(Succeed())
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-02", Device: "iPad", MAC: "aa:bb:cc:dd:ee:01", ActiveMinutes: 40, QuotaMinutes: 90, BankMinutes: 10, BonusMinutes: 20})).To(Succeed())
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-02", Device: "Laptop", MAC: "aa:bb:cc:dd:ee:02", ActiveMinutes: 5, QuotaMinutes: 120})).To(Succeed())
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-03", Device: "iPad", ActiveMinutes: 1})).To(Succeed())
+
+			// 23:30 UTC is already March 2 in Berlin.
+			Expect(s.AppendAudit(store.AuditEntry{Time: time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC), Device: "iPad", MAC: "AA:BB:CC:DD:EE:01", Action: "block"})).To(Succeed())
+			Expect(s.AppendAudit(store.AuditEntry{Time: time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC), Device: "Lenas iPad", MAC: "AA:BB:CC:DD:EE:01", Action: "block"})).To(Succeed())
+			Expect(s.AppendAudit(store.AuditEntry{Time: time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), Device: "iPad", Action: "unblock"})).To(Succeed())
+			Expect(s.AppendAudit(store.AuditEntry{Time: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC), Device: "Laptop", Action: "block", Error: "timeout"})).To(Succeed())
+		})
+
+		It("returns a row per day and device with over/under and blocks", func() {
+			rows, err := export.Rows(s, day(1), day(2), loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows).To(Equal([]export.Row{
+				{Date: day(1), Device: "iPad", MAC: "aa:bb:cc:dd:ee:01", ActiveMinutes: 75, QuotaMinutes: 60, OverMinutes: 15, Blocks: 1},
+				{Date: day(2), Device: "Laptop", MAC: "aa:bb:cc:dd:ee:02", ActiveMinutes: 5, QuotaMinutes: 120, OverMinutes: -115},
+				{Date: day(2), Device: "iPad", MAC: "aa:bb:cc:dd:ee:01", ActiveMinutes: 40, QuotaMinutes: 90, OverMinutes: -50, BankMinutes: 10, BonusMinutes: 20, Blocks: 1},
+			}))
+		})
+	})
+
+	Describe("Sessions", func() {
+		BeforeEach(func() {
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-01", Device: "iPad", MAC: "aa:bb:cc:dd:ee:01", Child: "Lena", ActiveMinutes: 75, QuotaMinutes: 60, Active: []string{"10:00+01:00/PT45M", "16:15+01:00/PT30M"}})).To(Succeed())
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-02", Device: "Switch", MAC: "aa:bb:cc:dd:ee:03", Child: "Lena", ActiveMinutes: 15, QuotaMinutes: 60, Active: []string{"09:00+01:00/PT15M"}})).To(Succeed())
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-02", Device: "Laptop", MAC: "aa:bb:cc:dd:ee:02", ActiveMinutes: 5, Active: []string{"20:00+01:00/PT5M"}})).To(Succeed())
+		})
+
+		It("returns the sessions of a child's devices", func() {
+			events, found, err := export.Sessions(s, "lena", day(1), day(2), loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(events).To(HaveLen(3))
+			Expect(events[0].Summary).To(Equal("Lena online (iPad)"))
+			Expect(events[0].Start).To(BeTemporally("==", time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)))
+			Expect(events[0].End).To(BeTemporally("==", time.Date(2024, 3, 1, 9, 45, 0, 0, time.UTC)))
+			Expect(events[0].UID).To(Equal("20240301T0900Z-ipad@home-gate"))
+			Expect(events[0].Description).To(Equal("Active for PT45M; 75 of 60 minutes used that day"))
+			Expect(events[2].Summary).To(Equal("Lena online (Switch)"))
+		})
+
+		It("returns the sessions of a device by name or MAC address", func() {
+			events, found, err := export.Sessions(s, "AA:BB:CC:DD:EE:02", day(1), day(2), loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Summary).To(Equal("Laptop online"))
+		})
+
+		It("finds records without sessions but returns no events for them", func() {
+			Expect(s.PutUsage(store.DayUsage{Date: "2024-03-01", Device: "TV", MAC: "aa:bb:cc:dd:ee:04", ActiveMinutes: 30})).To(Succeed())
+			events, found, err := export.Sessions(s, "TV", day(1), day(2), loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("reports unknown names", func() {
+			_, found, err := export.Sessions(s, "Max", day(1), day(2), loc)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("writing", func() {
+		rows := []export.Row{
+			{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Device: `Lena's "iPad" & co`, MAC: "aa:bb", ActiveMinutes: 75, QuotaMinutes: 60, OverMinutes: 15, Blocks: 2},
+		}
+
+		It("writes CSV with a header", func() {
+			var b bytes.Buffer
+			Expect(export.Write(&b, export.FormatCSV, rows)).To(Succeed())
+			Expect(b.String()).To(Equal("date,device,mac,active_minutes,quota_minutes,over_minutes,bank_minutes,bonus_minutes,blocks\n" +
+				`2024-03-01,"Lena's ""iPad"" & co",aa:bb,75,60,15,0,0,2` + "\n"))
+		})
+
+		It("keeps spreadsheets from evaluating device names as formulas", func() {
+			var b bytes.Buffer
+			formulas := []export.Row{
+				{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Device: `=HYPERLINK("http://evil.example")`, MAC: "aa:bb", OverMinutes: -15},
+				{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Device: "@SUM(A1)", MAC: "+cc:dd"},
+				{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Device: "-2+3", MAC: "\tee"},
+			}
+			Expect(export.Write(&b, export.FormatCSV, formulas)).To(Succeed())
+			Expect(strings.Split(b.String(), "\n")[1:]).To(Equal([]string{
+				`2024-03-01,"'=HYPERLINK(""http://evil.example"")",aa:bb,0,0,-15,0,0,0`,
+				`2024-03-01,'@SUM(A1),'+cc:dd,0,0,0,0,0,0`,
+				"2024-03-01,'-2+3,'\tee,0,0,0,0,0,0",
+				"",
+			}))
+		})
+
+		It("writes an XLSX workbook with date and number cells", func() {
+			var b bytes.Buffer
+			Expect(export.Write(&b, export.FormatXLSX, rows)).To(Succeed())
+
+			zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+			Expect(err).ToNot(HaveOccurred())
+			parts := make(map[string]string)
+			for _, f := range zr.File {
+				rc, err := f.Open()
+				Expect(err).ToNot(HaveOccurred())
+				content, err := io.ReadAll(rc)
+				Expect(err).ToNot(HaveOccurred())
+				parts[f.Name] = string(content)
+			}
+			Expect(parts).To(HaveKey("[Content_Types].xml"))
+			Expect(parts).To(HaveKey("xl/workbook.xml"))
+			Expect(parts).To(HaveKey("xl/styles.xml"))
+			sheet := parts["xl/worksheets/sheet1.xml"]
+			Expect(sheet).To(ContainSubstring(`<c r="A1" t="inlineStr" s="2"><is><t>date</t></is></c>`))
+			Expect(sheet).To(ContainSubstring(`<c r="A2" s="1"><v>45352</v></c>`))
+			Expect(sheet).To(ContainSubstring(`<t>Lena&#39;s &#34;iPad&#34; &amp; co</t>`))
+			Expect(sheet).To(ContainSubstring(`<c r="F2"><v>15</v></c>`))
+			Expect(strings.Count(sheet, "<row ")).To(Equal(2))
+		})
+
+		It("rejects unknown formats", func() {
+			Expect(export.Write(io.Discard, "pdf", rows)).To(MatchError(ContainSubstring("unknown format")))
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"home-gate/internal/calendar"
+	"home-gate/internal/iso8601"
+	"home-gate/internal/store"
+)
+
+// Sessions returns the recorded activity blocks between the dates from and to
+// (inclusive) as calendar events, for the devices of a child or one device,
+// matched by name or MAC address. The second result is false if no record
+// matched. A matching record without sessions, such as one written before
+// sessions were recorded, adds no events but still counts as found. Days are
+// in loc.
+func Sessions(history *store.Store, name string, from, to time.Time, loc *time.Location) ([]calendar.Event, bool, error) {
+	usage, err := history.Usage("", from, to)
+	if err != nil {
+		return nil, false, err
+	}
+	var events []calendar.Event
+	found := false
+	for _, u := range usage {
+		if !strings.EqualFold(u.Child, name) && !sameDevice(u, name, name) {
+			continue
+		}
+		found = true
+		date, err := time.ParseInLocation(store.DateFormat, u.Date, loc)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid date in usage history: %w", err)
+		}
+		summary := deviceName(u) + " online"
+		if u.Child != "" {
+			summary = fmt.Sprintf("%s online (%s)", u.Child, deviceName(u))
+		}
+		for _, block := range u.Active {
+			start, d, err := iso8601.ParseBlock(date, block)
+			if err != nil {
+				continue
+			}
+			events = append(events, calendar.Event{
+				UID:         fmt.Sprintf("%s-%s@home-gate", start.UTC().Format("20060102T1504Z"), slug(u.Device)),
+				Summary:     summary,
+				Description: fmt.Sprintf("Active for %s; %d of %d minutes used that day", iso8601.FormatDuration(d), u.ActiveMinutes, u.QuotaMinutes),
+				Start:       start,
+				End:         start.Add(d),
+			})
+		}
+	}
+	return events, found, nil
+}
+
+// slug reduces a device name to letters, digits and dashes for event UIDs.
+func slug(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, s)
+}
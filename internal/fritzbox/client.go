@@ -3,10 +3,13 @@ package fritzbox
 import (
 	"crypto/tls"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	fritzboxlib "github.com/ByteSizedMarius/go-fritzbox-api/v2"
 )
@@ -29,6 +32,9 @@ type Client interface {
 	GetMonitorDatasets() ([]Dataset, error)
 	GetMonitorData(dataset, subset string) ([]SubsetData, error)
 	BlockDevice(userUID string, block bool) error
+	// Rights returns the access level of the logged-in user per right, e.g.
+	// "BoxAdmin": 2 for write access.
+	Rights() (map[string]int, error)
 }
 
 type fritzboxClient struct {
@@ -36,10 +42,20 @@ type fritzboxClient struct {
 	baseUrl           string
 }
 
+// DefaultURL is the address of the Fritz!Box used by New.
+const DefaultURL = "http://192.168.2.1"
+
 func New(username, password string) Client {
+	return NewWithURL(username, password, DefaultURL)
+}
+
+// NewWithURL creates a client for the Fritz!Box at baseURL, e.g.
+// https://fritz.box.
+func NewWithURL(username, password, baseURL string) Client {
+	baseURL = strings.TrimSuffix(baseURL, "/")
 	c := fritzboxlib.New(username, password)
-	c.BaseUrl = "http://192.168.2.1"
-	return &fritzboxClient{fritzboxLibClient: c, baseUrl: "http://192.168.2.1"}
+	c.BaseUrl = baseURL
+	return &fritzboxClient{fritzboxLibClient: c, baseUrl: baseURL}
 }
 
 func (c *fritzboxClient) Connect() error {
@@ -129,11 +145,7 @@ func (c *fritzboxClient) BlockDevice(userUID string, block bool) error {
 	req.Header.Set("Sec-GPC", "1")
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36")
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	httpClient := &http.Client{Transport: tr}
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient().Do(req)
 	if err != nil {
 		return err
 	}
@@ -143,3 +155,52 @@ func (c *fritzboxClient) BlockDevice(userUID string, block bool) error {
 	}
 	return nil
 }
+
+// sessionInfo is the answer of login_sid.lua.
+type sessionInfo struct {
+	SID    string `xml:"SID"`
+	Rights struct {
+		Names  []string `xml:"Name"`
+		Access []int    `xml:"Access"`
+	} `xml:"Rights"`
+}
+
+func (c *fritzboxClient) Rights() (map[string]int, error) {
+	resp, err := httpClient().Get(c.baseUrl + "/login_sid.lua?version=2&sid=" + url.QueryEscape(c.SID()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var info sessionInfo
+	if err := xml.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	if info.SID == "" || strings.Trim(info.SID, "0") == "" {
+		return nil, errors.New("session is not valid")
+	}
+	rights := make(map[string]int, len(info.Rights.Names))
+	for i, name := range info.Rights.Names {
+		if i < len(info.Rights.Access) {
+			rights[name] = info.Rights.Access[i]
+		}
+	}
+	return rights, nil
+}
+
+// requestTimeout bounds the requests of httpClient, so an unresponsive
+// Fritz!Box cannot hang a run or a health check.
+const requestTimeout = 10 * time.Second
+
+// httpClient returns a client for requests the library does not cover. The
+// Fritz!Box uses a self-signed certificate.
+func httpClient() *http.Client {
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package fritzbox
+
+import (
+	"errors"
+	"net"
+
+	fritzboxlib "github.com/ByteSizedMarius/go-fritzbox-api/v2"
+)
+
+// IsUnreachable reports whether err means the Fritz!Box could not be
+// reached, e.g. a DNS failure, a refused connection or a timeout.
+func IsUnreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsUnauthorized reports whether err means the login was rejected.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, fritzboxlib.ErrInvalidCredentials)
+}
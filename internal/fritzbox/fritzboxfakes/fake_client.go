@@ -94,6 +94,18 @@ type FakeClient struct {
 		result2 int
 		result3 error
 	}
+	RightsStub        func() (map[string]int, error)
+	rightsMutex       sync.RWMutex
+	rightsArgsForCall []struct {
+	}
+	rightsReturns struct {
+		result1 map[string]int
+		result2 error
+	}
+	rightsReturnsOnCall map[int]struct {
+		result1 map[string]int
+		result2 error
+	}
 	SIDStub        func() string
 	sIDMutex       sync.RWMutex
 	sIDArgsForCall []struct {
@@ -523,6 +535,62 @@ func (fake *FakeClient) RestGetReturnsOnCall(i int, result1 []byte, result2 int,
 	}{result1, result2, result3}
 }
 
+func (fake *FakeClient) Rights() (map[string]int, error) {
+	fake.rightsMutex.Lock()
+	ret, specificReturn := fake.rightsReturnsOnCall[len(fake.rightsArgsForCall)]
+	fake.rightsArgsForCall = append(fake.rightsArgsForCall, struct {
+	}{})
+	stub := fake.RightsStub
+	fakeReturns := fake.rightsReturns
+	fake.recordInvocation("Rights", []interface{}{})
+	fake.rightsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) RightsCallCount() int {
+	fake.rightsMutex.RLock()
+	defer fake.rightsMutex.RUnlock()
+	return len(fake.rightsArgsForCall)
+}
+
+func (fake *FakeClient) RightsCalls(stub func() (map[string]int, error)) {
+	fake.rightsMutex.Lock()
+	defer fake.rightsMutex.Unlock()
+	fake.RightsStub = stub
+}
+
+func (fake *FakeClient) RightsReturns(result1 map[string]int, result2 error) {
+	fake.rightsMutex.Lock()
+	defer fake.rightsMutex.Unlock()
+	fake.RightsStub = nil
+	fake.rightsReturns = struct {
+		result1 map[string]int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RightsReturnsOnCall(i int, result1 map[string]int, result2 error) {
+	fake.rightsMutex.Lock()
+	defer fake.rightsMutex.Unlock()
+	fake.RightsStub = nil
+	if fake.rightsReturnsOnCall == nil {
+		fake.rightsReturnsOnCall = make(map[int]struct {
+			result1 map[string]int
+			result2 error
+		})
+	}
+	fake.rightsReturnsOnCall[i] = struct {
+		result1 map[string]int
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeClient) SID() string {
 	fake.sIDMutex.Lock()
 	ret, specificReturn := fake.sIDReturnsOnCall[len(fake.sIDArgsForCall)]
@@ -0,0 +1,9 @@
+package fritzbox
+
+import "strings"
+
+// NormalizeMAC lowercases a MAC address and strips its colons, matching the
+// form used in monitor data source names (e.g. "rcv_20c9d07d3b1b").
+func NormalizeMAC(mac string) string {
+	return strings.ToLower(strings.ReplaceAll(mac, ":", ""))
+}
@@ -42,3 +42,13 @@ type LandeviceResponse struct {
 type MonitorConfig struct {
 	DisplayHomenetDevices string `json:"displayHomenetDevices"`
 }
+
+// IsActive reports whether the Fritz!Box currently sees the device online.
+func (d Landevice) IsActive() bool {
+	return d.Active == "1"
+}
+
+// IsBlocked reports whether the device's user profile is currently blocked.
+func (d Landevice) IsBlocked() bool {
+	return d.Blocked == "1"
+}
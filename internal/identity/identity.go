@@ -0,0 +1,202 @@
+// Package identity tracks devices across MAC address changes. Phones rotate
+// private (locally administered) Wi-Fi MACs, so a device is remembered by its
+// Fritz!Box landevice UIDs, every MAC it has used and its hostname.
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Identity is a named device (e.g. "Anna's iPhone") with all aliases it is known by.
+type Identity struct {
+	Name      string   `json:"name"`
+	UIDs      []string `json:"uids,omitempty"`
+	MACs      []string `json:"macs,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+}
+
+// Registry is the persisted set of identities.
+type Registry struct {
+	path       string
+	Identities []Identity `json:"identities"`
+}
+
+// IsRandomized reports whether the MAC has the locally administered bit set,
+// which is how iOS and Android mark private (randomized) addresses.
+func IsRandomized(mac string) bool {
+	normalized := fritzbox.NormalizeMAC(mac)
+	if len(normalized) < 2 {
+		return false
+	}
+	first, err := strconv.ParseUint(normalized[:2], 16, 8)
+	if err != nil {
+		return false
+	}
+	return first&0x02 != 0
+}
+
+// Load reads the registry from path. A missing file yields an empty registry.
+func Load(path string) (*Registry, error) {
+	reg := &Registry{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return reg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, reg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return reg, nil
+}
+
+// Save writes the registry back to the file it was loaded from.
+func (r *Registry) Save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// Link records the landevice's UID, MAC and hostname as aliases of the named
+// identity, creating the identity if needed.
+func (r *Registry) Link(name string, dev fritzbox.Landevice) {
+	idx := -1
+	for i := range r.Identities {
+		if strings.EqualFold(r.Identities[i].Name, name) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		r.Identities = append(r.Identities, Identity{Name: name})
+		idx = len(r.Identities) - 1
+	}
+	id := &r.Identities[idx]
+	id.UIDs = appendUnique(id.UIDs, dev.UID)
+	id.MACs = appendUnique(id.MACs, fritzbox.NormalizeMAC(dev.MAC))
+	id.Hostnames = appendUnique(id.Hostnames, hostname(dev.FriendlyName))
+}
+
+// Lookup returns the identity a landevice belongs to, matching by UID first,
+// then by MAC and finally by hostname. A hostname with a "-2" style suffix
+// only matches when its base is a known hostname of the identity and the MAC
+// is a new randomized one, as after a rotation; "pixel-7" is not "pixel".
+func (r *Registry) Lookup(dev fritzbox.Landevice) (Identity, bool) {
+	if r == nil {
+		return Identity{}, false
+	}
+	mac := fritzbox.NormalizeMAC(dev.MAC)
+	host := hostname(dev.FriendlyName)
+	base := duplicateSuffix.ReplaceAllString(host, "")
+	matchers := []func(Identity) bool{
+		func(id Identity) bool { return dev.UID != "" && contains(id.UIDs, dev.UID) },
+		func(id Identity) bool { return mac != "" && contains(id.MACs, mac) },
+		func(id Identity) bool { return host != "" && contains(id.Hostnames, host) },
+		func(id Identity) bool {
+			return base != host && base != "" && contains(id.Hostnames, base) && IsRandomized(mac) && !contains(id.MACs, mac)
+		},
+	}
+	for _, match := range matchers {
+		for _, id := range r.Identities {
+			if match(id) {
+				return id, true
+			}
+		}
+	}
+	return Identity{}, false
+}
+
+// Resolve finds the landevices addressed by key, which may be an identity
+// name, a landevice UID or a MAC address. When key belongs to a registered
+// identity, every landevice of that identity is returned.
+func (r *Registry) Resolve(landevices []fritzbox.Landevice, key string) (string, []fritzbox.Landevice) {
+	name := ""
+	if r != nil {
+		for _, id := range r.Identities {
+			if strings.EqualFold(id.Name, key) {
+				name = id.Name
+				break
+			}
+		}
+		if name == "" {
+			for _, dev := range landevices {
+				if matchesKey(dev, key) {
+					if id, ok := r.Lookup(dev); ok {
+						name = id.Name
+					}
+					break
+				}
+			}
+		}
+	}
+
+	var matches []fritzbox.Landevice
+	for _, dev := range landevices {
+		if name != "" {
+			if id, ok := r.Lookup(dev); ok && id.Name == name {
+				matches = append(matches, dev)
+			}
+		} else if matchesKey(dev, key) {
+			matches = append(matches, dev)
+		}
+	}
+	return name, matches
+}
+
+// Unlinked returns the landevices with a randomized MAC that don't belong to
+// any identity yet, i.e. private addresses that appeared after a rotation.
+func (r *Registry) Unlinked(landevices []fritzbox.Landevice) []fritzbox.Landevice {
+	var result []fritzbox.Landevice
+	for _, dev := range landevices {
+		if !IsRandomized(dev.MAC) {
+			continue
+		}
+		if _, ok := r.Lookup(dev); !ok {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+func matchesKey(dev fritzbox.Landevice, key string) bool {
+	return dev.UID == key || fritzbox.NormalizeMAC(dev.MAC) == fritzbox.NormalizeMAC(key)
+}
+
+// duplicateSuffix matches the "-2" style suffix the Fritz!Box appends when a
+// hostname reappears with a new MAC address.
+var duplicateSuffix = regexp.MustCompile(`-\d+$`)
+
+func hostname(friendlyName string) string {
+	return strings.ToLower(strings.TrimSpace(friendlyName))
+}
+
+func contains(values []string, v string) bool {
+	for _, existing := range values {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(values []string, v string) []string {
+	if v == "" || contains(values, v) {
+		return values
+	}
+	return append(values, v)
+}
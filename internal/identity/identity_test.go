@@ -0,0 +1,87 @@
+package identity_test
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/identity"
+)
+
+var _ = Describe("Identity", func() {
+	Describe("IsRandomized", func() {
+		It("detects the locally administered bit", func() {
+			Expect(identity.IsRandomized("DA:A1:19:00:00:01")).To(BeTrue())
+			Expect(identity.IsRandomized("6e006b9068e9")).To(BeTrue())
+			Expect(identity.IsRandomized("00:11:22:33:44:55")).To(BeFalse())
+			Expect(identity.IsRandomized("")).To(BeFalse())
+		})
+	})
+
+	Describe("Registry", func() {
+		var (
+			path string
+			reg  *identity.Registry
+		)
+		oldPhone := fritzbox.Landevice{UID: "landevice1", FriendlyName: "Annas-iPhone", MAC: "DA:A1:19:00:00:00"}
+		rotatedPhone := fritzbox.Landevice{UID: "landevice7", FriendlyName: "Annas-iPhone-2", MAC: "3A:00:00:00:00:07", Active: "1"}
+		laptop := fritzbox.Landevice{UID: "landevice2", FriendlyName: "Laptop", MAC: "00:11:22:33:44:55"}
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "identities.json")
+			var err error
+			reg, err = identity.Load(path)
+			Expect(err).ToNot(HaveOccurred())
+			reg.Link("Anna phone", oldPhone)
+		})
+
+		It("persists linked identities", func() {
+			Expect(reg.Save()).To(Succeed())
+			loaded, err := identity.Load(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Identities).To(HaveLen(1))
+			Expect(loaded.Identities[0].UIDs).To(ConsistOf("landevice1"))
+			Expect(loaded.Identities[0].MACs).To(ConsistOf("daa119000000"))
+		})
+
+		It("recognizes a rotated MAC by its hostname", func() {
+			id, ok := reg.Lookup(rotatedPhone)
+			Expect(ok).To(BeTrue())
+			Expect(id.Name).To(Equal("Anna phone"))
+			_, ok = reg.Lookup(laptop)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("does not strip numeric suffixes that are part of the hostname", func() {
+			reg.Link("Pixel", fritzbox.Landevice{UID: "landevice3", FriendlyName: "pixel", MAC: "00:11:22:33:44:03"})
+			reg.Link("Lena tablet", fritzbox.Landevice{UID: "landevice4", FriendlyName: "lena-tablet-1", MAC: "00:11:22:33:44:04"})
+
+			id, ok := reg.Lookup(fritzbox.Landevice{UID: "landevice5", FriendlyName: "lena-tablet-2", MAC: "00:11:22:33:44:05"})
+			Expect(ok).To(BeFalse(), "matched %s", id.Name)
+
+			// The base hostname is known, but a device with its own fixed MAC
+			// is a different device, not a rotation.
+			id, ok = reg.Lookup(fritzbox.Landevice{UID: "landevice6", FriendlyName: "pixel-7", MAC: "00:11:22:33:44:06"})
+			Expect(ok).To(BeFalse(), "matched %s", id.Name)
+		})
+
+		It("resolves an identity name, UID or MAC to all linked landevices", func() {
+			landevices := []fritzbox.Landevice{oldPhone, rotatedPhone, laptop}
+			for _, key := range []string{"anna phone", "landevice7", "da:a1:19:00:00:00"} {
+				name, matches := reg.Resolve(landevices, key)
+				Expect(name).To(Equal("Anna phone"))
+				Expect(matches).To(ConsistOf(oldPhone, rotatedPhone))
+			}
+			name, matches := reg.Resolve(landevices, "00:11:22:33:44:55")
+			Expect(name).To(BeEmpty())
+			Expect(matches).To(ConsistOf(laptop))
+		})
+
+		It("lists randomized MACs that are not linked yet", func() {
+			stranger := fritzbox.Landevice{UID: "landevice9", FriendlyName: "Guest", MAC: "AE:00:00:00:00:09"}
+			Expect(reg.Unlinked([]fritzbox.Landevice{oldPhone, rotatedPhone, laptop, stranger})).To(ConsistOf(stranger))
+		})
+	})
+})
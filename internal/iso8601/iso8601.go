@@ -0,0 +1,116 @@
+// Package iso8601 formats and parses the ISO 8601 durations and activity
+// blocks used in the API, e.g. "PT1H15M" and "10:00+02:00/PT45M".
+package iso8601
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatDuration formats d as an ISO 8601 duration in hours, minutes and
+// seconds, e.g. PT1H30M or PT0.25S. Zero is PT0S; negative durations get a
+// leading minus sign.
+func FormatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteString("PT")
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	if h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if d > 0 || (h == 0 && m == 0) {
+		b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// FormatMinutes formats a number of minutes as an ISO 8601 duration.
+func FormatMinutes(minutes int) string {
+	return FormatDuration(time.Duration(minutes) * time.Minute)
+}
+
+// ParseDuration parses an ISO 8601 duration of days, hours, minutes and
+// seconds, e.g. P1DT2H, PT15M or -PT30M. Years, months and weeks are
+// rejected since their length varies.
+func ParseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid ISO 8601 duration %q", s)
+	rest, neg := strings.CutPrefix(s, "-")
+	rest, ok := strings.CutPrefix(rest, "P")
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, invalid
+	}
+	units := map[byte]time.Duration{'D': 24 * time.Hour}
+	var total float64
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return 0, invalid
+			}
+			inTime = true
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			rest = rest[1:]
+			continue
+		}
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if i <= 0 {
+			return 0, invalid
+		}
+		unit, ok := units[rest[i]]
+		if !ok {
+			if strings.IndexByte("YMW", rest[i]) >= 0 && !inTime {
+				return 0, errors.New("durations in years, months or weeks are not supported")
+			}
+			return 0, invalid
+		}
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		if err != nil {
+			return 0, invalid
+		}
+		total += n * float64(unit)
+		delete(units, rest[i])
+		rest = rest[i+1:]
+	}
+	if total > math.MaxInt64 {
+		return 0, invalid
+	}
+	d := time.Duration(math.Round(total))
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// ParseBlock parses an activity block such as "10:00+02:00/PT45M" of the day
+// of date. It returns the start, in the block's UTC offset, and the duration.
+func ParseBlock(date time.Time, block string) (time.Time, time.Duration, error) {
+	clock, dur, ok := strings.Cut(block, "/")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid activity block %q", block)
+	}
+	t, err := time.Parse("15:04-07:00", clock)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid activity block %q: %w", block, err)
+	}
+	d, err := ParseDuration(dur)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid activity block %q: %w", block, err)
+	}
+	date = date.In(t.Location())
+	start := time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	return start, d, nil
+}
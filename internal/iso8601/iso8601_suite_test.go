@@ -0,0 +1,13 @@
+package iso8601_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestISO8601(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ISO 8601 Suite")
+}
@@ -0,0 +1,55 @@
+package iso8601_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/iso8601"
+)
+
+var _ = Describe("ISO 8601", func() {
+	DescribeTable("formats durations",
+		func(d time.Duration, want string) {
+			Expect(iso8601.FormatDuration(d)).To(Equal(want))
+		},
+		Entry("zero", time.Duration(0), "PT0S"),
+		Entry("minutes", 45*time.Minute, "PT45M"),
+		Entry("hours and minutes", 75*time.Minute, "PT1H15M"),
+		Entry("more than a day", 36*time.Hour, "PT36H"),
+		Entry("fractional seconds", 1500*time.Millisecond, "PT1.5S"),
+		Entry("negative", -30*time.Minute, "-PT30M"),
+	)
+
+	DescribeTable("parses durations",
+		func(s string, want time.Duration) {
+			d, err := iso8601.ParseDuration(s)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d).To(Equal(want))
+		},
+		Entry("minutes", "PT15M", 15*time.Minute),
+		Entry("days and hours", "P1DT2H", 26*time.Hour),
+		Entry("fractional seconds", "PT0.25S", 250*time.Millisecond),
+		Entry("negative", "-PT30M", -30*time.Minute),
+		Entry("formatted", iso8601.FormatDuration(90*time.Minute+5*time.Second), 90*time.Minute+5*time.Second),
+	)
+
+	It("rejects invalid durations", func() {
+		for _, s := range []string{"", "P", "PT", "15M", "PT15", "PTM", "PT1M1M", "P1M"} {
+			_, err := iso8601.ParseDuration(s)
+			Expect(err).To(HaveOccurred(), s)
+		}
+	})
+
+	It("parses activity blocks on a day", func() {
+		date := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+		start, d, err := iso8601.ParseBlock(date, "10:00+02:00/PT1H15M")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(start.Format(time.RFC3339)).To(Equal("2026-10-18T10:00:00+02:00"))
+		Expect(d).To(Equal(75 * time.Minute))
+
+		_, _, err = iso8601.ParseBlock(date, "10:00")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,339 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Classifier decides which intervals of a device's measurements count as
+// active usage.
+type Classifier interface {
+	// Classify returns one flag per interval of rcv and snd (Byte/s).
+	Classify(rcv, snd []float64) []bool
+	String() string
+}
+
+// Rescaler is implemented by classifiers with settings that count samples.
+// Rescale returns the classifier for samples n times shorter, such as the
+// per-minute samples next to 15-minute intervals, so both classify the same
+// durations. Classifiers without it only compare rates, which do not depend
+// on the sample length.
+type Rescaler interface {
+	Rescale(n int) Classifier
+}
+
+// Thresholds are the receive and send rates (Byte/s) an interval must exceed,
+// in either direction, to count as active.
+type Thresholds struct {
+	Rcv float64 `mapstructure:"rcv" json:"rcv"`
+	Snd float64 `mapstructure:"snd" json:"snd"`
+}
+
+func (t Thresholds) exceeded(rcv, snd []float64, i int) bool {
+	r, s := sampleAt(rcv, snd, i)
+	return r > t.Rcv || s > t.Snd
+}
+
+func (t Thresholds) String() string {
+	if t.Rcv == t.Snd {
+		return fmt.Sprintf("%g B/s", t.Rcv)
+	}
+	return fmt.Sprintf("rcv %g B/s, snd %g B/s", t.Rcv, t.Snd)
+}
+
+// ThresholdClassifier counts every interval above the thresholds as active.
+type ThresholdClassifier struct {
+	Thresholds Thresholds
+}
+
+func (c ThresholdClassifier) Classify(rcv, snd []float64) []bool {
+	active := make([]bool, len(rcv))
+	for i := range rcv {
+		active[i] = c.Thresholds.exceeded(rcv, snd, i)
+	}
+	return active
+}
+
+func (c ThresholdClassifier) String() string {
+	return "threshold " + c.Thresholds.String()
+}
+
+// HysteresisClassifier switches to active above On and back to idle only
+// when the traffic drops to Off or below, so a stream dipping briefly
+// between the two stays active while short bursts from idle need to reach On.
+type HysteresisClassifier struct {
+	On  Thresholds
+	Off Thresholds
+}
+
+func (c HysteresisClassifier) Classify(rcv, snd []float64) []bool {
+	active := make([]bool, len(rcv))
+	on := false
+	for i := range rcv {
+		if on {
+			on = c.Off.exceeded(rcv, snd, i)
+		} else {
+			on = c.On.exceeded(rcv, snd, i)
+		}
+		active[i] = on
+	}
+	return active
+}
+
+func (c HysteresisClassifier) String() string {
+	return fmt.Sprintf("hysteresis on above %s, off at %s", c.On, c.Off)
+}
+
+// SustainedClassifier counts intervals above the thresholds as active only
+// in runs of at least Intervals consecutive intervals, ignoring isolated
+// spikes like app updates or push notifications.
+type SustainedClassifier struct {
+	Thresholds Thresholds
+	Intervals  int
+}
+
+func (c SustainedClassifier) Classify(rcv, snd []float64) []bool {
+	active := make([]bool, len(rcv))
+	run := 0
+	for i := range rcv {
+		if !c.Thresholds.exceeded(rcv, snd, i) {
+			run = 0
+			continue
+		}
+		run++
+		if run == c.Intervals {
+			for j := i - run + 1; j <= i; j++ {
+				active[j] = true
+			}
+		} else if run > c.Intervals {
+			active[i] = true
+		}
+	}
+	return active
+}
+
+// Rescale counts runs of Intervals*n shorter samples.
+func (c SustainedClassifier) Rescale(n int) Classifier {
+	return SustainedClassifier{Thresholds: c.Thresholds, Intervals: c.Intervals * max(n, 1)}
+}
+
+func (c SustainedClassifier) String() string {
+	return fmt.Sprintf("sustained %s for %d intervals", c.Thresholds, c.Intervals)
+}
+
+// ClassifierSpec configures a classifier, e.g. in the "activity" section of
+// the config file. Mode is threshold (default), hysteresis or sustained.
+type ClassifierSpec struct {
+	Mode string `mapstructure:"mode"`
+	// Rcv and Snd are the thresholds, for hysteresis the ones to switch on.
+	Rcv float64 `mapstructure:"rcv"`
+	Snd float64 `mapstructure:"snd"`
+	// OffRcv and OffSnd are the hysteresis thresholds to switch off.
+	OffRcv float64 `mapstructure:"off_rcv"`
+	OffSnd float64 `mapstructure:"off_snd"`
+	// Intervals is the minimum run length for sustained detection.
+	Intervals int `mapstructure:"intervals"`
+}
+
+// Build creates the classifier described by the spec.
+func (s ClassifierSpec) Build() (Classifier, error) {
+	on := Thresholds{Rcv: s.Rcv, Snd: s.Snd}
+	switch strings.ToLower(s.Mode) {
+	case "", "threshold":
+		return ThresholdClassifier{Thresholds: on}, nil
+	case "hysteresis":
+		off := Thresholds{Rcv: s.OffRcv, Snd: s.OffSnd}
+		if off.Rcv > on.Rcv || off.Snd > on.Snd {
+			return nil, fmt.Errorf("hysteresis off thresholds (%s) must not exceed the on thresholds (%s)", off, on)
+		}
+		return HysteresisClassifier{On: on, Off: off}, nil
+	case "sustained":
+		if s.Intervals < 1 {
+			return nil, fmt.Errorf("sustained detection needs intervals >= 1, got %d", s.Intervals)
+		}
+		return SustainedClassifier{Thresholds: on, Intervals: s.Intervals}, nil
+	default:
+		return nil, fmt.Errorf("unknown activity mode %q, use threshold, hysteresis or sustained", s.Mode)
+	}
+}
+
+// ActivityConfig assigns classifiers to device types and devices to types:
+//
+//	activity:
+//	  default: {rcv: 10, snd: 10}
+//	  types:
+//	    phone: {mode: hysteresis, rcv: 2000, snd: 500, off_rcv: 500, off_snd: 100}
+//	    tv: {mode: sustained, rcv: 50000, snd: 1000, intervals: 2}
+//	  devices:
+//	    Anna phone: phone
+//	    aa:bb:cc:dd:ee:ff: tv
+type ActivityConfig struct {
+	Default *ClassifierSpec           `mapstructure:"default"`
+	Types   map[string]ClassifierSpec `mapstructure:"types"`
+	// Devices maps device names, UIDs or MACs to a type.
+	Devices map[string]string `mapstructure:"devices"`
+}
+
+// Classifiers picks the classifier for each device.
+type Classifiers struct {
+	Default Classifier
+	types   map[string]Classifier
+	devices map[string]string
+}
+
+// Build validates the configuration and creates the classifiers. Devices
+// without a type, and the default if none is configured, use fallback.
+func (c ActivityConfig) Build(fallback Classifier) (*Classifiers, error) {
+	def, byType, err := c.BuildTypes()
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		def = fallback
+	}
+	return NewClassifiers(def, byType, c.Devices)
+}
+
+// BuildTypes creates the default classifier, nil if none is configured, and
+// the classifier of each type.
+func (c ActivityConfig) BuildTypes() (Classifier, map[string]Classifier, error) {
+	var def Classifier
+	if c.Default != nil {
+		var err error
+		if def, err = c.Default.Build(); err != nil {
+			return nil, nil, fmt.Errorf("activity default: %w", err)
+		}
+	}
+	byType := make(map[string]Classifier)
+	for name, spec := range c.Types {
+		classifier, err := spec.Build()
+		if err != nil {
+			return nil, nil, fmt.Errorf("activity type %s: %w", name, err)
+		}
+		byType[name] = classifier
+	}
+	return def, byType, nil
+}
+
+// NewClassifiers assigns the classifiers of byType to the devices, which map
+// device names, UIDs or MACs to a type. Devices without a type use def.
+// Types are matched case-insensitively.
+func NewClassifiers(def Classifier, byType map[string]Classifier, devices map[string]string) (*Classifiers, error) {
+	cs := &Classifiers{Default: def, types: make(map[string]Classifier), devices: make(map[string]string)}
+	for name, classifier := range byType {
+		cs.types[strings.ToLower(name)] = classifier
+	}
+	for dev, typ := range devices {
+		if _, ok := cs.types[strings.ToLower(typ)]; !ok {
+			return nil, fmt.Errorf("activity device %s: unknown type %q", dev, typ)
+		}
+		cs.devices[classifierKey(dev)] = strings.ToLower(typ)
+	}
+	return cs, nil
+}
+
+// For returns the classifier of the device with any of the given keys.
+func (cs *Classifiers) For(keys ...string) Classifier {
+	if c, ok := cs.typed(keys...); ok {
+		return c
+	}
+	return cs.Default
+}
+
+// typed returns the classifier of the device's type, if it has one.
+func (cs *Classifiers) typed(keys ...string) (Classifier, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if typ, ok := cs.devices[classifierKey(key)]; ok {
+			return cs.types[typ], true
+		}
+	}
+	return nil, false
+}
+
+// classifierKey normalizes device names and MAC addresses for lookups.
+func classifierKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.Count(s, ":") == 5 {
+		return fritzbox.NormalizeMAC(s)
+	}
+	return s
+}
+
+// classifierFor returns the classifier for a target: the one of its type in
+// opts.Activity, else its learned idle baseline with opts.AutoThreshold, else
+// the default of opts.Activity or the plain ActivityThreshold in both
+// directions. baselines holds the valid baselines by device name.
+func classifierFor(opts Options, t Target, baselines map[string]Baseline) Classifier {
+	if opts.Activity != nil {
+		if c, ok := opts.Activity.typed(t.keys()...); ok {
+			return c
+		}
+	}
+	if b, ok := baselines[t.Name]; ok {
+		return ThresholdClassifier{Thresholds: b.Suggested}
+	}
+	if opts.Activity != nil {
+		return opts.Activity.Default
+	}
+	return ThresholdClassifier{Thresholds: Thresholds{Rcv: opts.ActivityThreshold, Snd: opts.ActivityThreshold}}
+}
+
+// classifierDetector classifies traffic with the target's classifier, see
+// classifierFor. With opts.AutoThreshold, the baselines are learned once per
+// run, on the first target.
+type classifierDetector struct {
+	w    io.Writer
+	opts Options
+	// baselines holds the valid baselines by device name, nil until learned.
+	baselines map[string]Baseline
+}
+
+// Prepare forgets the baselines of the previous run.
+func (d *classifierDetector) Prepare(time.Time) error {
+	d.baselines = nil
+	return nil
+}
+
+func (d *classifierDetector) Detect(t Target, traffic Traffic, now time.Time) Activity {
+	if d.opts.AutoThreshold && d.opts.History != nil && d.baselines == nil {
+		d.baselines = d.learn(now)
+	}
+	classifier := classifierFor(d.opts, t, d.baselines)
+	activity := Activity{Classifier: classifier.String(), Intervals: classifier.Classify(traffic.Rcv, traffic.Snd)}
+	if traffic.MinuteRcv != nil {
+		activity.Minutes = perMinute(classifier, traffic.Interval).Classify(traffic.MinuteRcv, traffic.MinuteSnd)
+	}
+	return activity
+}
+
+// perMinute returns the classifier for per-minute samples next to samples of
+// the given length.
+func perMinute(c Classifier, interval time.Duration) Classifier {
+	if r, ok := c.(Rescaler); ok && interval > time.Minute {
+		return r.Rescale(int(interval / time.Minute))
+	}
+	return c
+}
+
+// learn learns the baselines of all devices. On errors no baseline applies
+// for the rest of the run.
+func (d *classifierDetector) learn(now time.Time) map[string]Baseline {
+	valid := make(map[string]Baseline)
+	baselines, err := LearnBaselines(d.opts.History, "", now, DefaultBaselineOptions)
+	if err != nil {
+		_, _ = fmt.Fprintf(d.w, "%v\n", err)
+	}
+	for _, b := range baselines {
+		if b.Valid {
+			valid[b.Device] = b
+		}
+	}
+	return valid
+}
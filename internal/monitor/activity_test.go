@@ -0,0 +1,47 @@
+package monitor_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/monitor"
+)
+
+var _ = Describe("Activity", func() {
+	// timeline renders classified intervals as * (active) and . (idle).
+	timeline := func(intervals []bool) string {
+		var b strings.Builder
+		for _, active := range intervals {
+			if active {
+				b.WriteString("*")
+			} else {
+				b.WriteString(".")
+			}
+		}
+		return b.String()
+	}
+
+	DescribeTable("classifiers",
+		func(classifier monitor.Classifier, want string) {
+			rcv := []float64{50, 2000, 600, 600, 50, 600, 2000, 50}
+			snd := make([]float64, len(rcv))
+			Expect(timeline(classifier.Classify(rcv, snd))).To(Equal(want))
+		},
+		Entry("threshold", monitor.ThresholdClassifier{Thresholds: monitor.Thresholds{Rcv: 1000, Snd: 1000}}, ".*....*."),
+		Entry("hysteresis", monitor.HysteresisClassifier{On: monitor.Thresholds{Rcv: 1000, Snd: 1000}, Off: monitor.Thresholds{Rcv: 500, Snd: 500}}, ".***..*."),
+		Entry("sustained", monitor.SustainedClassifier{Thresholds: monitor.Thresholds{Rcv: 500, Snd: 500}, Intervals: 3}, ".***...."),
+	)
+
+	DescribeTable("rejects invalid configurations",
+		func(cfg monitor.ActivityConfig, want string) {
+			_, err := cfg.Build(monitor.ThresholdClassifier{})
+			Expect(err).To(MatchError(ContainSubstring(want)))
+		},
+		Entry("unknown mode", monitor.ActivityConfig{Default: &monitor.ClassifierSpec{Mode: "fancy"}}, `unknown activity mode "fancy"`),
+		Entry("sustained without intervals", monitor.ActivityConfig{Types: map[string]monitor.ClassifierSpec{"tv": {Mode: "sustained"}}}, "needs intervals >= 1"),
+		Entry("off above on", monitor.ActivityConfig{Types: map[string]monitor.ClassifierSpec{"tv": {Mode: "hysteresis", Rcv: 10, OffRcv: 20}}}, "must not exceed the on thresholds"),
+		Entry("unknown type", monitor.ActivityConfig{Devices: map[string]string{"tv": "unknown"}}, `unknown type "unknown"`),
+	)
+})
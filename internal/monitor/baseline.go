@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"home-gate/internal/store"
+)
+
+// BaselineOptions control how idle traffic is learned from stored samples.
+type BaselineOptions struct {
+	// Days of history to learn from.
+	Days int
+	// NightFrom and NightTo are the local hours (NightFrom inclusive, NightTo
+	// exclusive) during which devices are assumed to be idle.
+	NightFrom, NightTo int
+	// Percentile of the nightly traffic that counts as idle, e.g. 0.95.
+	Percentile float64
+	// Margin multiplies the idle traffic to get the suggested threshold.
+	Margin float64
+	// MinSamples is the number of nightly samples needed for a suggestion.
+	MinSamples int
+}
+
+// DefaultBaselineOptions learns from 01:00-05:00 over the last week.
+var DefaultBaselineOptions = BaselineOptions{Days: 7, NightFrom: 1, NightTo: 5, Percentile: 0.95, Margin: 1.5, MinSamples: 32}
+
+// Baseline is a device's learned idle background traffic.
+type Baseline struct {
+	Device string `json:"device"`
+	MAC    string `json:"mac"`
+	// Samples is the number of nightly samples learned from.
+	Samples int `json:"samples"`
+	// Idle is the nightly traffic percentile in each direction.
+	Idle Thresholds `json:"idle"`
+	// Suggested is the proposed activity threshold, valid if Samples reached
+	// MinSamples.
+	Suggested Thresholds `json:"suggested"`
+	Valid     bool       `json:"valid"`
+}
+
+// LearnBaselines learns the idle baseline of every device (or only the given
+// one) from the samples stored before now, with nights in now's time zone.
+func LearnBaselines(history *store.Store, device string, now time.Time, opts BaselineOptions) ([]Baseline, error) {
+	samples, err := history.Samples(device, now.AddDate(0, 0, -opts.Days), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read traffic samples: %w", err)
+	}
+	byDevice := make(map[string][]store.Sample)
+	var order []string
+	for _, s := range samples {
+		hour := s.Start.In(now.Location()).Hour()
+		if hour < opts.NightFrom || hour >= opts.NightTo {
+			continue
+		}
+		if _, ok := byDevice[s.Device]; !ok {
+			order = append(order, s.Device)
+		}
+		byDevice[s.Device] = append(byDevice[s.Device], s)
+	}
+	sort.Strings(order)
+	baselines := make([]Baseline, 0, len(order))
+	for _, name := range order {
+		baselines = append(baselines, learnBaseline(name, byDevice[name], opts))
+	}
+	return baselines, nil
+}
+
+func learnBaseline(device string, samples []store.Sample, opts BaselineOptions) Baseline {
+	rcv := make([]float64, len(samples))
+	snd := make([]float64, len(samples))
+	for i, s := range samples {
+		rcv[i], snd[i] = s.Rcv, s.Snd
+	}
+	b := Baseline{
+		Device:  device,
+		MAC:     samples[len(samples)-1].MAC,
+		Samples: len(samples),
+		Idle:    Thresholds{Rcv: percentile(rcv, opts.Percentile), Snd: percentile(snd, opts.Percentile)},
+		Valid:   len(samples) >= opts.MinSamples,
+	}
+	b.Suggested = Thresholds{
+		Rcv: math.Max(math.Ceil(b.Idle.Rcv*opts.Margin), 1),
+		Snd: math.Max(math.Ceil(b.Idle.Snd*opts.Margin), 1),
+	}
+	return b
+}
+
+// percentile returns the nearest-rank percentile p (0..1) of values.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// trafficSamples converts the device's traffic per interval into samples for
+// baseline learning.
+func trafficSamples(now time.Time, name, mac string, rcv, snd []float64) []store.Sample {
+	samples := make([]store.Sample, len(rcv))
+	for i := range rcv {
+		r, s := sampleAt(rcv, snd, i)
+		samples[i] = store.Sample{Start: intervalStart(now, len(rcv), i), Device: name, MAC: mac, Rcv: r, Snd: s}
+	}
+	return samples
+}
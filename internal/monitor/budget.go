@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"home-gate/internal/policy"
+	"home-gate/internal/store"
+)
+
+// applyBudgets sets the device's quota for today from the policy: the daily
+// rule plus banked rollover minutes, capped by the weekly and monthly budgets
+// using the usage history. It reports the bank and the remaining budgets.
+//
+// The devices of a child share the quota, the budgets and the bank, so the
+// history of all of them counts and usage.SharedMinutes receives what the
+// other devices used today. Devices under the default rules are budgeted one
+// by one, using the history of the device with key.
+func applyBudgets(opts Options, pm *policy.PolicyManager, key string, usage *DeviceUsage) error {
+	usage.QuotaMinutes = pm.AllowedToday()
+	if opts.History == nil {
+		return nil
+	}
+
+	now := opts.now()
+	today := now.Format(store.DateFormat)
+	yesterday := now.AddDate(0, 0, -1)
+	weekStart := pm.PeriodStart(policy.PeriodWeek)
+	monthStart := pm.PeriodStart(policy.PeriodMonth)
+	from := weekStart
+	if monthStart.Before(from) {
+		from = monthStart
+	}
+	if rollover, ok := pm.Rollover(); ok {
+		// Look back further than the rollover period so withdrawals are
+		// taken from deposits that expired since.
+		if bankStart := yesterday.AddDate(0, 0, -2*rollover.Days); bankStart.Before(from) {
+			from = bankStart
+		}
+	}
+	records, err := budgetRecords(opts.History, key, usage.Child, from, now)
+	if err != nil {
+		return fmt.Errorf("failed to read usage history: %w", err)
+	}
+	var used policy.Usage
+	var days []policy.DayRecord
+	for _, r := range records {
+		if r.Date == today {
+			if r.Device != key {
+				usage.SharedMinutes += r.ActiveMinutes
+			}
+			continue
+		}
+		if date, err := time.ParseInLocation(store.DateFormat, r.Date, opts.location()); err == nil {
+			quota := max(r.QuotaMinutes-r.BankMinutes-r.BonusMinutes, 0)
+			if n := len(days); n > 0 && days[n-1].Date.Equal(date) {
+				// Another device of the child on the same day: the quota
+				// was shared, the minutes add up.
+				days[n-1].QuotaMinutes = max(days[n-1].QuotaMinutes, quota)
+				days[n-1].ActiveMinutes += r.ActiveMinutes
+			} else {
+				days = append(days, policy.DayRecord{Date: date, QuotaMinutes: quota, ActiveMinutes: r.ActiveMinutes})
+			}
+		}
+		if r.Date >= weekStart.Format(store.DateFormat) {
+			used.Week += r.ActiveMinutes
+		}
+		if r.Date >= monthStart.Format(store.DateFormat) {
+			used.Month += r.ActiveMinutes
+		}
+	}
+	used.Bank = pm.Bank(days)
+	usage.BankMinutes = used.Bank
+	usage.QuotaMinutes = pm.AllowedTodayWithUsage(used)
+	if left, ok := pm.Remaining(policy.PeriodWeek, used.Week+usage.UsedMinutes()); ok {
+		usage.WeeklyRemainingMinutes = &left
+	}
+	if left, ok := pm.Remaining(policy.PeriodMonth, used.Month+usage.UsedMinutes()); ok {
+		usage.MonthlyRemainingMinutes = &left
+	}
+	return nil
+}
+
+// budgetRecords returns the usage records between from and to that count
+// towards the budgets: those of all devices of child, or those of the device
+// with key if it belongs to no child.
+func budgetRecords(history *store.Store, key, child string, from, to time.Time) ([]store.DayUsage, error) {
+	if child == "" {
+		return history.Usage(key, from, to)
+	}
+	records, err := history.Usage("", from, to)
+	if err != nil {
+		return nil, err
+	}
+	var result []store.DayUsage
+	for _, r := range records {
+		if r.Child == child {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// recordUsage stores the usage of the device with key on the day of now.
+func recordUsage(history *store.Store, now time.Time, key string, usage DeviceUsage) error {
+	err := history.PutUsage(store.DayUsage{
+		Date:          now.Format(store.DateFormat),
+		Device:        key,
+		Name:          usage.Name,
+		MAC:           usage.MAC,
+		Child:         usage.Child,
+		ActiveMinutes: usage.DailyActiveMinutes,
+		QuotaMinutes:  usage.QuotaMinutes,
+		BankMinutes:   usage.BankMinutes,
+		BonusMinutes:  usage.BonusMinutes,
+		Active:        usage.Active,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage: %w", err)
+	}
+	return nil
+}
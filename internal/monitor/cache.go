@@ -0,0 +1,326 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Cache keeps the data fetched from the Fritz!Box, or from all routers, so
+// the device list, the day data and the per-minute data can be refreshed on
+// separate schedules. Pipelines built from the cache evaluate the latest data
+// without fetching it again.
+type Cache struct {
+	w       io.Writer
+	opts    Options
+	routers []*cachedRouter
+	// failed holds the routers that could not be connected.
+	failed []error
+
+	mu sync.Mutex
+	// outMu keeps the output of concurrent fetches apart.
+	outMu sync.Mutex
+}
+
+// cachedRouter is a connected router and what was fetched from it. The
+// fetched fields are guarded by Cache.mu.
+type cachedRouter struct {
+	router
+	landevices []fritzbox.Landevice
+	config     fritzbox.MonitorConfig
+	day        []fritzbox.SubsetData
+	hour       []fritzbox.SubsetData
+	// hasLandevices, hasDay and hasHour are set once the fetch succeeded.
+	hasLandevices, hasDay, hasHour bool
+}
+
+// NewCache connects to the Fritz!Box, or to all opts.Routers. Nothing is
+// fetched until one of the Fetch methods or a pipeline run needs it.
+func NewCache(opts Options) (*Cache, error) {
+	w := opts.Out
+	if w == nil {
+		w = io.Discard
+	}
+	routers, failed, err := connectRouters(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{w: w, opts: opts, failed: failed}
+	for _, r := range routers {
+		r.client = &lockedClient{client: r.client}
+		c.routers = append(c.routers, &cachedRouter{router: r})
+	}
+	return c, nil
+}
+
+// lockedClient serializes the requests of a router's client, which the jobs
+// fetching from the cache and the pipelines enforcing share. A reconnect thus
+// never replaces the session while a request is in flight.
+type lockedClient struct {
+	mu     sync.Mutex
+	client fritzbox.Client
+}
+
+func (c *lockedClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.Connect()
+}
+
+func (c *lockedClient) RestGet(path string) ([]byte, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.RestGet(path)
+}
+
+func (c *lockedClient) SID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.SID()
+}
+
+func (c *lockedClient) GetLandevices() ([]fritzbox.Landevice, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.GetLandevices()
+}
+
+func (c *lockedClient) GetMonitorConfig() (fritzbox.MonitorConfig, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.GetMonitorConfig()
+}
+
+func (c *lockedClient) GetMonitorDatasets() ([]fritzbox.Dataset, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.GetMonitorDatasets()
+}
+
+func (c *lockedClient) GetMonitorData(dataset, subset string) ([]fritzbox.SubsetData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.GetMonitorData(dataset, subset)
+}
+
+func (c *lockedClient) BlockDevice(userUID string, block bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.BlockDevice(userUID, block)
+}
+
+func (c *lockedClient) Rights() (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client.Rights()
+}
+
+// FetchLandevices refreshes the device list and the online monitor
+// configuration.
+func (c *Cache) FetchLandevices(ctx context.Context) error {
+	return c.fetch(ctx, "landevices", func(r *cachedRouter) error {
+		landevices, err := r.client.GetLandevices()
+		if err != nil {
+			return fmt.Errorf("failed to fetch landevices: %w", err)
+		}
+		var config fritzbox.MonitorConfig
+		if c.opts.Mac == "" {
+			config, err = r.client.GetMonitorConfig()
+			if err != nil {
+				return fmt.Errorf("failed to fetch monitor config: %w", err)
+			}
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		r.landevices, r.config, r.hasLandevices = landevices, config, true
+		return nil
+	})
+}
+
+// FetchDay refreshes today's traffic in 15-minute intervals.
+func (c *Cache) FetchDay(ctx context.Context) error {
+	return c.fetch(ctx, "day data", func(r *cachedRouter) error {
+		data, err := r.client.GetMonitorData("macaddrs", "subset0002")
+		if err != nil {
+			return fmt.Errorf("failed to fetch monitor data: %w", err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		r.day, r.hasDay = data, true
+		return nil
+	})
+}
+
+// FetchHour refreshes the per-minute traffic of the last hour. Without it,
+// the recent hour is counted in 15-minute intervals.
+func (c *Cache) FetchHour(ctx context.Context) error {
+	return c.fetch(ctx, "hour data", func(r *cachedRouter) error {
+		data, err := r.client.GetMonitorData("macaddrs", "subset0001")
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		r.hour, r.hasHour = data, err == nil
+		if err != nil {
+			return fmt.Errorf("per-minute data unavailable, counting in 15-minute intervals: %w", err)
+		}
+		return nil
+	})
+}
+
+// fetch runs get for every router concurrently. A failed fetch logs in again
+// and is retried once, since the Fritz!Box session may have expired between
+// runs. The reconnect waits for the router's requests in flight, see
+// lockedClient.
+func (c *Cache) fetch(ctx context.Context, what string, get func(r *cachedRouter) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	outs := make([]bytes.Buffer, len(c.routers))
+	errs := make([]error, len(c.routers))
+	var wg sync.WaitGroup
+	for i, r := range c.routers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = fmt.Fprintf(&outs[i], "Fetching %s\n", what)
+			err := get(r)
+			if err == nil {
+				return
+			}
+			_, _ = fmt.Fprintf(&outs[i], "%v, reconnecting\n", err)
+			if cerr := r.client.Connect(); cerr != nil {
+				errs[i] = fmt.Errorf("%w (failed to reconnect: %w)", err, cerr)
+				return
+			}
+			errs[i] = get(r)
+		}()
+	}
+	wg.Wait()
+
+	c.outMu.Lock()
+	defer c.outMu.Unlock()
+	var failed []error
+	for i, r := range c.routers {
+		if r.name == "" {
+			_, _ = io.WriteString(c.w, outs[i].String())
+		} else {
+			writePrefixed(c.w, r.name, outs[i].String())
+		}
+		if errs[i] != nil {
+			if r.name != "" {
+				errs[i] = fmt.Errorf("router %s: %w", r.name, errs[i])
+			}
+			_, _ = fmt.Fprintf(c.w, "%v\n", errs[i])
+			failed = append(failed, errs[i])
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// Pipeline assembles a pipeline for opts that collects from the cache. The
+// router settings of opts are ignored in favour of the cache's routers. Data
+// that was never fetched is fetched on the first run.
+func (c *Cache) Pipeline(opts Options) (*Pipeline, error) {
+	w := opts.Out
+	if w == nil {
+		w = io.Discard
+	}
+	routers := make([]router, len(c.routers))
+	for i, r := range c.routers {
+		routers[i] = r.router
+	}
+	p, err := newPipeline(w, opts, routers)
+	if err != nil {
+		return nil, err
+	}
+	p.Collector = cacheCollector{w: w, cache: c, opts: opts}
+	return p, nil
+}
+
+// cacheCollector builds the collection from the cached data.
+type cacheCollector struct {
+	w     io.Writer
+	cache *Cache
+	opts  Options
+}
+
+func (c cacheCollector) Collect(ctx context.Context) (Collection, error) {
+	if err := ctx.Err(); err != nil {
+		return Collection{}, err
+	}
+	if _, err := periodSubset(c.opts.Period); err != nil {
+		return Collection{}, err
+	}
+	fillErr := c.fill(ctx)
+
+	cache := c.cache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	var cols []Collection
+	var names []string
+	for _, r := range cache.routers {
+		if !r.hasLandevices || (c.opts.Period == PeriodDay && !r.hasDay) || (c.opts.Period == PeriodHour && !r.hasHour) {
+			continue
+		}
+		col := newCollection(r.landevices)
+		col.selectTargets(c.w, c.opts, r.config)
+		col.Period = c.opts.Period
+		if col.Period == PeriodHour {
+			col.Data = r.hour
+		} else {
+			col.Data, col.Minutes = r.day, r.hour
+		}
+		cols = append(cols, col)
+		names = append(names, r.name)
+	}
+	if len(cols) == 0 {
+		if fillErr == nil {
+			fillErr = errors.New("no data fetched yet")
+		}
+		return Collection{}, fillErr
+	}
+	var col Collection
+	if len(cache.routers) == 1 && cache.routers[0].name == "" {
+		col = cols[0]
+	} else {
+		col = mergeCollections(names, cols)
+		col.Errors = append(col.Errors, cache.failed...)
+	}
+	if fillErr != nil {
+		col.Errors = append(col.Errors, fillErr)
+	}
+	return col, nil
+}
+
+// fill fetches what the period needs and was not fetched yet. Routers that
+// fail are left out of the collection; per-minute data is optional for the
+// day period.
+func (c cacheCollector) fill(ctx context.Context) error {
+	cache := c.cache
+	cache.mu.Lock()
+	var landevices, day, hour bool
+	for _, r := range cache.routers {
+		landevices = landevices || !r.hasLandevices
+		day = day || (c.opts.Period == PeriodDay && !r.hasDay)
+		hour = hour || !r.hasHour
+	}
+	cache.mu.Unlock()
+
+	var errs []error
+	if landevices {
+		errs = append(errs, cache.FetchLandevices(ctx))
+	}
+	if day {
+		errs = append(errs, cache.FetchDay(ctx))
+	}
+	if hour {
+		if err := cache.FetchHour(ctx); c.opts.Period == PeriodHour {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
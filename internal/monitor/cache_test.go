@@ -0,0 +1,208 @@
+package monitor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/fritzbox/fritzboxfakes"
+	"home-gate/internal/monitor"
+	"home-gate/internal/policy/policyfakes"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		client *fritzboxfakes.FakeClient
+		clock  *policyfakes.FakeClock
+		out    bytes.Buffer
+		opts   monitor.Options
+	)
+
+	// day returns the day data with the tablet active in intervals from..to-1
+	// of the last 24 hours.
+	day := func(from, to int) []fritzbox.SubsetData {
+		rcv := make([]float64, 96)
+		for i := from; i < to; i++ {
+			rcv[i] = 5000
+		}
+		return []fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: rcv},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, 96)},
+		}
+	}
+
+	BeforeEach(func() {
+		client = &fritzboxfakes.FakeClient{}
+		client.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "tablet", UserUIDs: "user1"}}, nil)
+		client.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+		client.GetMonitorDataStub = func(_, subset string) ([]fritzbox.SubsetData, error) {
+			if subset == "subset0001" {
+				return nil, errors.New("not supported")
+			}
+			return day(90, 92), nil
+		}
+		clock = &policyfakes.FakeClock{}
+		clock.NowReturns(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+		out.Reset()
+		opts = monitor.Options{
+			Username:          "u",
+			Password:          "p",
+			TestClient:        client,
+			Period:            "day",
+			ActivityThreshold: 100,
+			PolicyString:      "MO-SU60",
+			Clock:             clock,
+			Location:          time.UTC,
+			Out:               &out,
+		}
+	})
+
+	It("fetches what was not fetched yet on the first run", func() {
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+		p, err := cache.Pipeline(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary, err := p.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(30))
+		Expect(summary.Devices[0].Precision).To(Equal(monitor.PrecisionQuarterHour))
+		Expect(client.GetLandevicesCallCount()).To(Equal(1))
+	})
+
+	It("evaluates the cached data until it is fetched again", func() {
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cache.FetchLandevices(context.Background())).To(Succeed())
+		Expect(cache.FetchDay(context.Background())).To(Succeed())
+		p, err := cache.Pipeline(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		client.GetMonitorDataStub = func(_, subset string) ([]fritzbox.SubsetData, error) {
+			return day(90, 94), nil
+		}
+		summary, err := p.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(30))
+
+		Expect(cache.FetchDay(context.Background())).To(Succeed())
+		summary, err = p.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(60))
+		Expect(client.GetLandevicesCallCount()).To(Equal(1))
+	})
+
+	It("logs in again when the session expired", func() {
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+		client.GetLandevicesReturnsOnCall(0, nil, errors.New("403 Forbidden"))
+
+		Expect(cache.FetchLandevices(context.Background())).To(Succeed())
+		Expect(client.ConnectCallCount()).To(Equal(2))
+		Expect(out.String()).To(ContainSubstring("reconnecting"))
+	})
+
+	It("does not reconnect while a request is in flight", func() {
+		var inFlight, overlaps atomic.Int32
+		request := func() {
+			if inFlight.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+			inFlight.Add(-1)
+		}
+		client.ConnectStub = func() error {
+			request()
+			return nil
+		}
+		client.GetMonitorDataStub = func(_, subset string) ([]fritzbox.SubsetData, error) {
+			request()
+			return nil, errors.New("403 Forbidden")
+		}
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_ = cache.FetchDay(context.Background())
+			}()
+			go func() {
+				defer wg.Done()
+				_ = cache.FetchHour(context.Background())
+			}()
+		}
+		wg.Wait()
+		Expect(client.ConnectCallCount()).To(Equal(11))
+		Expect(overlaps.Load()).To(BeZero())
+	})
+
+	It("fails a run without any data", func() {
+		client.GetLandevicesReturns(nil, errors.New("unreachable"))
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+		p, err := cache.Pipeline(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = p.Run(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("failed to fetch landevices")))
+	})
+
+	It("counts the same minutes with and without the per-minute data", func() {
+		// The per-minute samples of the last hour cover 11:15-12:14: a
+		// 15-minute burst, then 30 minutes of streaming from 11:45.
+		clock.NowReturns(time.Date(2026, 10, 17, 12, 14, 0, 0, time.UTC))
+		minutes := make([]float64, 60)
+		for j := range minutes {
+			if j < 15 || j >= 30 {
+				minutes[j] = 5000
+			}
+		}
+		quarters := day(92, 96)
+		quarters[0].Measurements[93] = 0
+		withMinutes := false
+		client.GetMonitorDataStub = func(_, subset string) ([]fritzbox.SubsetData, error) {
+			if subset == "subset0001" {
+				if !withMinutes {
+					return nil, errors.New("not supported")
+				}
+				return []fritzbox.SubsetData{
+					{DataSourceName: "rcv_aa11bb22cc33", Measurements: minutes},
+					{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, 60)},
+				}, nil
+			}
+			return quarters, nil
+		}
+		activity, err := monitor.ActivityConfig{
+			Default: &monitor.ClassifierSpec{Mode: "sustained", Rcv: 100, Snd: 100, Intervals: 2},
+		}.Build(nil)
+		Expect(err).ToNot(HaveOccurred())
+		opts.Activity = activity
+		cache, err := monitor.NewCache(opts)
+		Expect(err).ToNot(HaveOccurred())
+		p, err := cache.Pipeline(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		summary, err := p.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].Precision).To(Equal(monitor.PrecisionQuarterHour))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(30))
+
+		withMinutes = true
+		Expect(cache.FetchHour(context.Background())).To(Succeed())
+		summary, err = p.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].Precision).To(Equal(monitor.PrecisionMinute))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(30))
+	})
+})
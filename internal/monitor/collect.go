@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"home-gate/internal/fritzbox"
+)
+
+// fritzboxCollector collects the devices and traffic from the Fritz!Box.
+type fritzboxCollector struct {
+	w      io.Writer
+	client fritzbox.Client
+	opts   Options
+}
+
+// Collect fetches the landevices, resolves the targets and fetches the traffic
+// of opts.Period. On error the collection holds what was fetched so far.
+func (c fritzboxCollector) Collect(ctx context.Context) (Collection, error) {
+	var col Collection
+	if err := ctx.Err(); err != nil {
+		return col, err
+	}
+	_, _ = fmt.Fprintln(c.w, "Fetching landevices")
+	landevices, err := c.client.GetLandevices()
+	if err != nil {
+		return col, fmt.Errorf("failed to fetch landevices: %w", err)
+	}
+	_, _ = fmt.Fprintf(c.w, "Fetched %d devices\n", len(landevices))
+	col = newCollection(landevices)
+
+	var config fritzbox.MonitorConfig
+	if c.opts.Mac == "" {
+		config, err = c.client.GetMonitorConfig()
+		if err != nil {
+			return col, fmt.Errorf("failed to fetch monitor config: %w", err)
+		}
+	}
+	col.selectTargets(c.w, c.opts, config)
+
+	subset, err := periodSubset(c.opts.Period)
+	if err != nil {
+		return col, err
+	}
+	col.Period = c.opts.Period
+
+	col.Data, err = c.client.GetMonitorData("macaddrs", subset)
+	if err != nil {
+		return col, fmt.Errorf("failed to fetch monitor data: %w", err)
+	}
+	if col.Period == PeriodDay {
+		col.Minutes = fetchMinuteData(c.w, c.client)
+	}
+	return col, nil
+}
+
+// newCollection starts a collection from the landevices.
+func newCollection(landevices []fritzbox.Landevice) Collection {
+	col := Collection{Landevices: landevices, UserUIDs: make(map[string]string)}
+	for _, dev := range landevices {
+		if dev.UserUIDs != "" {
+			col.UserUIDs[fritzbox.NormalizeMAC(dev.MAC)] = dev.UserUIDs
+		}
+	}
+	return col
+}
+
+// selectTargets resolves the targets among the collection's landevices.
+func (col *Collection) selectTargets(w io.Writer, opts Options, config fritzbox.MonitorConfig) {
+	col.Targets = selectTargets(w, opts, col.Landevices, config)
+	for i, t := range col.Targets {
+		col.Targets[i].UserUID = t.Device.UserUIDs
+		if col.Targets[i].UserUID == "" {
+			col.Targets[i].UserUID = col.UserUIDs[t.MACs[0]]
+		}
+	}
+}
+
+// periodSubset returns the online monitor subset holding the period's data.
+func periodSubset(period string) (string, error) {
+	switch period {
+	case PeriodHour:
+		return "subset0001", nil
+	case PeriodDay:
+		return "subset0002", nil
+	default:
+		return "", fmt.Errorf("invalid period: %s. Use 'hour' or 'day'", period)
+	}
+}
@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Check is the result of one self-diagnostic check.
+type Check struct {
+	// Router names the router with several, see Options.Routers.
+	Router string
+	Name   string
+	OK     bool
+	// Warning marks a failed check that does not prevent monitoring.
+	Warning bool
+	Detail  string
+}
+
+// Diagnose checks that home-gate can work with the Fritz!Box, or with each
+// of opts.Routers: the credentials, the REST API, the online monitor
+// configuration and the rights needed to block devices. The checks of a
+// router stop at the first one that fails, except for warnings.
+func Diagnose(ctx context.Context, opts Options) []Check {
+	if len(opts.Routers) == 0 {
+		return diagnose(ctx, "", opts)
+	}
+	if err := ValidateRouters(opts.Routers); err != nil {
+		return []Check{{Name: "configuration", Detail: err.Error()}}
+	}
+	var checks []Check
+	for _, r := range opts.Routers {
+		o := opts
+		o.Username, o.Password, o.URL, o.TestClient = r.Username, r.Password, r.URL, r.Client
+		checks = append(checks, diagnose(ctx, r.Name, o)...)
+	}
+	return checks
+}
+
+// diagnose runs the checks for one router.
+func diagnose(ctx context.Context, name string, opts Options) []Check {
+	var checks []Check
+	check := func(title string, err error, detail string) bool {
+		c := Check{Router: name, Name: title, OK: err == nil, Detail: detail}
+		if err != nil {
+			c.Detail = err.Error()
+			if detail != "" {
+				c.Detail += ": " + detail
+			}
+		}
+		checks = append(checks, c)
+		return err == nil
+	}
+	if err := ctx.Err(); err != nil {
+		check("login", err, "")
+		return checks
+	}
+
+	url := opts.URL
+	if url == "" {
+		url = fritzbox.DefaultURL
+	}
+	if opts.TestClient == nil && (opts.Username == "" || opts.Password == "") {
+		check("credentials", errors.New("username and password are required"), "set --username and --password, FRITZBOX_USERNAME and FRITZBOX_PASSWORD or the config file")
+		return checks
+	}
+	client, err := connect(io.Discard, opts)
+	switch {
+	case fritzbox.IsUnreachable(err):
+		check("login", err, "cannot reach "+url)
+		return checks
+	case fritzbox.IsUnauthorized(err):
+		check("login", errors.New("invalid username or password"), "")
+		return checks
+	case !check("login", err, fmt.Sprintf("logged in to %s as %s", url, opts.Username)):
+		return checks
+	}
+
+	landevices, err := client.GetLandevices()
+	if !check("REST API", err, fmt.Sprintf("%d devices", len(landevices))) {
+		checks[len(checks)-1].Detail += " (the REST API needs FRITZ!OS 8 or later)"
+		return checks
+	}
+
+	if opts.Mac == "" {
+		config, err := client.GetMonitorConfig()
+		if err == nil && strings.TrimSpace(config.DisplayHomenetDevices) == "" {
+			err = errors.New("no devices are shown in the online monitor")
+		}
+		detail := fmt.Sprintf("%d devices shown", len(strings.Split(config.DisplayHomenetDevices, ",")))
+		if err != nil {
+			detail = "select the devices in Internet > Online Monitor, or pass --mac"
+		}
+		if !check("online monitor", err, detail) {
+			return checks
+		}
+	}
+	if _, err := client.GetMonitorData("macaddrs", "subset0002"); !check("traffic data", err, "today's traffic available") {
+		return checks
+	}
+	if _, err := client.GetMonitorData("macaddrs", "subset0001"); err != nil {
+		check("per-minute data", err, "the last hour is counted in 15-minute intervals")
+		checks[len(checks)-1].Warning = true
+	} else {
+		check("per-minute data", nil, "the last hour is counted per minute")
+	}
+
+	rights, err := client.Rights()
+	if err == nil && rights["BoxAdmin"] < 2 {
+		err = errors.New("the user may not change the Fritz!Box settings")
+	}
+	detail := "devices can be blocked"
+	if err != nil {
+		detail = "blocking needs the \"FRITZ!Box settings\" right, see System > FRITZ!Box Users"
+	}
+	check("block permissions", err, detail)
+	if err != nil && !opts.Enforce {
+		checks[len(checks)-1].Warning = true
+	}
+	return checks
+}
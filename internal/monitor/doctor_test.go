@@ -0,0 +1,94 @@
+package monitor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	fritzboxlib "github.com/ByteSizedMarius/go-fritzbox-api/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/fritzbox/fritzboxfakes"
+	"home-gate/internal/monitor"
+)
+
+var _ = Describe("Diagnose", func() {
+	var (
+		client *fritzboxfakes.FakeClient
+		opts   monitor.Options
+	)
+
+	names := func(checks []monitor.Check) []string {
+		var names []string
+		for _, c := range checks {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		client = &fritzboxfakes.FakeClient{}
+		client.GetLandevicesReturns([]fritzbox.Landevice{{UID: "landevice1"}}, nil)
+		client.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: "landevice1"}, nil)
+		client.RightsReturns(map[string]int{"BoxAdmin": 2}, nil)
+		opts = monitor.Options{Username: "u", Password: "p", TestClient: client, Enforce: true}
+	})
+
+	It("passes all checks for a well configured Fritz!Box", func() {
+		checks := monitor.Diagnose(context.Background(), opts)
+		Expect(names(checks)).To(Equal([]string{"login", "REST API", "online monitor", "traffic data", "per-minute data", "block permissions"}))
+		for _, c := range checks {
+			Expect(c.OK).To(BeTrue(), c.Name)
+		}
+	})
+
+	It("tells an unreachable Fritz!Box from a rejected login", func() {
+		client.ConnectReturns(&net.OpError{Op: "dial", Err: errors.New("connection refused")})
+		checks := monitor.Diagnose(context.Background(), opts)
+		Expect(checks).To(HaveLen(1))
+		Expect(checks[0].OK).To(BeFalse())
+		Expect(checks[0].Detail).To(ContainSubstring("cannot reach http://192.168.2.1"))
+
+		client.ConnectReturns(fmt.Errorf("authenticate: %w", fritzboxlib.ErrInvalidCredentials))
+		checks = monitor.Diagnose(context.Background(), opts)
+		Expect(checks).To(ConsistOf(HaveField("Detail", "invalid username or password")))
+	})
+
+	It("reports an empty online monitor", func() {
+		client.GetMonitorConfigReturns(fritzbox.MonitorConfig{}, nil)
+		checks := monitor.Diagnose(context.Background(), opts)
+		last := checks[len(checks)-1]
+		Expect(last.Name).To(Equal("online monitor"))
+		Expect(last.OK).To(BeFalse())
+	})
+
+	It("fails without the right to block devices when enforcing", func() {
+		client.RightsReturns(map[string]int{"BoxAdmin": 1, "Dial": 2}, nil)
+		checks := monitor.Diagnose(context.Background(), opts)
+		last := checks[len(checks)-1]
+		Expect(last.Name).To(Equal("block permissions"))
+		Expect(last.OK).To(BeFalse())
+		Expect(last.Warning).To(BeFalse())
+
+		opts.Enforce = false
+		checks = monitor.Diagnose(context.Background(), opts)
+		Expect(checks[len(checks)-1].Warning).To(BeTrue())
+	})
+
+	It("checks each router", func() {
+		other := &fritzboxfakes.FakeClient{}
+		other.GetLandevicesReturns(nil, errors.New("HTTP 404"))
+		opts.Routers = []monitor.Router{
+			{Name: "home", Username: "u", Password: "p", Client: client},
+			{Name: "grandparents", Username: "u", Password: "p", Client: other},
+		}
+		checks := monitor.Diagnose(context.Background(), opts)
+		failed := checks[len(checks)-1]
+		Expect(failed.Router).To(Equal("grandparents"))
+		Expect(failed.Name).To(Equal("REST API"))
+		Expect(failed.Detail).To(ContainSubstring("FRITZ!OS 8"))
+	})
+})
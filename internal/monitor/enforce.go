@@ -0,0 +1,278 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/store"
+)
+
+// Block reasons kept in the enforcement state.
+const (
+	reasonQuota  = "quota"
+	reasonWindow = "window"
+)
+
+// enforcement decides whether a device gets blocked or unblocked, keeping
+// the state in opts.History so decisions stay stable across runs:
+//
+//   - the quota counts as reached once the active minutes reach it (>=);
+//   - a device over its quota is blocked after opts.GracePeriod;
+//   - outside the allowed time windows it is blocked right away;
+//   - a blocked device stays blocked for at least opts.MinBlockDuration;
+//   - a device blocked for its quota is only unblocked on the next policy
+//     day, or when an override or reward takes effect after the block.
+//
+// Without a history there is no state: devices are blocked as soon as the
+// quota is reached and unblocked as soon as they are within policy. The state
+// is kept without opts.Enforce too, so switching enforcement on continues a
+// grace period that already started.
+type enforcement struct {
+	w      io.Writer
+	client fritzbox.Client
+	opts   Options
+	now    time.Time
+	router string
+}
+
+// fritzboxEnforcer blocks and unblocks targets through their Fritz!Box user,
+// see enforcement.
+type fritzboxEnforcer struct {
+	w      io.Writer
+	client fritzbox.Client
+	opts   Options
+	// router names the Fritz!Box with several routers; each keeps its own
+	// enforcement state.
+	router string
+}
+
+func (f fritzboxEnforcer) Enforce(t Target, usage DeviceUsage, eval Evaluation, now time.Time) []error {
+	blockUID := t.UserUID
+	if blockUID == "" {
+		blockUID = t.Device.UID
+	}
+	e := enforcement{w: f.w, client: f.client, opts: f.opts, now: now, router: f.router}
+	return e.apply(eval, usage, t.historyKey(), t.Device, blockUID, t.UserUID)
+}
+
+// action is what enforcement does with a device, see decide.
+type action int
+
+const (
+	// actionNone leaves a device within policy unblocked.
+	actionNone action = iota
+	// actionWait waits for the grace period to end before blocking.
+	actionWait
+	// actionBlock blocks the device, or keeps it blocked.
+	actionBlock
+	// actionKeep keeps a device within policy blocked.
+	actionKeep
+	// actionUnblock unblocks a device within policy.
+	actionUnblock
+)
+
+// decision is what enforcement does with a device and why.
+type decision struct {
+	action action
+	// reason is reasonQuota or reasonWindow with actionBlock, and why the
+	// device stays blocked with actionKeep.
+	reason string
+	// graceLeft is what is left of the grace period with actionWait.
+	graceLeft time.Duration
+	// override is the reason of the override in effect, if any.
+	override string
+	// state is the stored state, with the quota tracking updated to now.
+	state store.Enforcement
+}
+
+// decide decides what to do with a device from its stored enforcement state
+// st and whether it is blocked now. It has no side effects; apply acts on the
+// decision and Explain describes it.
+func decide(opts Options, st store.Enforcement, eval Evaluation, usage DeviceUsage, blocked bool, now time.Time) decision {
+	if today := now.Format(store.DateFormat); st.Date != today {
+		st.Date = today
+		st.QuotaReachedAt = nil
+	}
+	if !eval.QuotaReached {
+		st.QuotaReachedAt = nil
+	} else if st.QuotaReachedAt == nil {
+		st.QuotaReachedAt = &now
+	}
+	d := decision{state: st}
+	if o, ok := eval.Manager.TodayOverride(); ok {
+		d.override = o.Reason
+	}
+	graceLeft := time.Duration(0)
+	if eval.QuotaReached && opts.History != nil {
+		graceLeft = opts.GracePeriod - now.Sub(*st.QuotaReachedAt)
+	}
+
+	switch {
+	case !eval.InWindow:
+		d.action, d.reason = actionBlock, reasonWindow
+	case eval.QuotaReached && graceLeft <= 0:
+		d.action, d.reason = actionBlock, reasonQuota
+	case eval.QuotaReached:
+		d.action, d.graceLeft = actionWait, graceLeft
+	case !blocked:
+		d.action = actionNone
+	default:
+		d.action = actionUnblock
+		if keep := keepBlocked(opts, st, usage, d.override, now); keep != "" {
+			d.action, d.reason = actionKeep, keep
+		}
+	}
+	return d
+}
+
+// enforcementKey returns the key the enforcement state of the device with
+// key is stored under; each router keeps its own.
+func enforcementKey(key, router string) string {
+	if router != "" {
+		return key + "@" + router
+	}
+	return key
+}
+
+// apply enforces the evaluation for the device with key and returns the
+// errors.
+func (e enforcement) apply(eval Evaluation, usage DeviceUsage, key string, device fritzbox.Landevice, blockUID, unblockUID string) []error {
+	var errs []error
+	today := e.now.Format(store.DateFormat)
+	st := store.Enforcement{Device: enforcementKey(key, e.router)}
+	if e.opts.History != nil {
+		loaded, ok, err := e.opts.History.Enforcement(st.Device)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read enforcement state: %w", err))
+		} else if ok {
+			st = loaded
+		}
+	}
+	d := decide(e.opts, st, eval, usage, device.IsBlocked(), e.now)
+	st = d.state
+
+	switch d.action {
+	case actionBlock:
+		if d.reason == reasonWindow {
+			_, _ = fmt.Fprintf(e.w, "Outside allowed time window\n")
+		} else {
+			_, _ = fmt.Fprintf(e.w, "Exceeded policy\n")
+		}
+		if st.BlockedAt == nil || st.Reason != d.reason {
+			st.Reason = d.reason
+			st.Override = d.override
+		}
+		blocked := device.IsBlocked()
+		if e.opts.Enforce && !blocked {
+			if err := e.block(key, device, blockUID, true, e.blockReason(usage, eval.InWindow)); err != nil {
+				errs = append(errs, err)
+				break
+			}
+			blocked = true
+		}
+		if blocked && (st.BlockedAt == nil || !device.IsBlocked()) {
+			now := e.now
+			st.BlockedAt = &now
+			st.BlockedDate = today
+		}
+	case actionWait:
+		_, _ = fmt.Fprintf(e.w, "Exceeded policy, blocking in %s (grace period)\n", d.graceLeft.Round(time.Second))
+	case actionNone:
+		_, _ = fmt.Fprintf(e.w, "Within policy\n")
+		st.BlockedAt, st.BlockedDate, st.Reason, st.Override = nil, "", "", ""
+	case actionKeep:
+		_, _ = fmt.Fprintf(e.w, "Within policy\n")
+		_, _ = fmt.Fprintf(e.w, "Device stays blocked: %s\n", d.reason)
+	case actionUnblock:
+		_, _ = fmt.Fprintf(e.w, "Within policy\n")
+		if e.opts.Enforce {
+			if err := e.block(key, device, unblockUID, false, "within policy"); err != nil {
+				errs = append(errs, err)
+				break
+			}
+			st.BlockedAt, st.BlockedDate, st.Reason, st.Override = nil, "", "", ""
+		}
+	}
+
+	if e.opts.History != nil {
+		if err := e.opts.History.PutEnforcement(st); err != nil {
+			errs = append(errs, fmt.Errorf("failed to store enforcement state: %w", err))
+		}
+	}
+	return errs
+}
+
+// keepBlocked returns why a blocked device within policy stays blocked, or ""
+// if it may be unblocked.
+func keepBlocked(opts Options, st store.Enforcement, usage DeviceUsage, override string, now time.Time) string {
+	if st.BlockedAt == nil {
+		return ""
+	}
+	if left := opts.MinBlockDuration - now.Sub(*st.BlockedAt); left > 0 {
+		return fmt.Sprintf("minimum block duration, %s left", left.Round(time.Second))
+	}
+	if st.Reason != reasonQuota || st.BlockedDate != now.Format(store.DateFormat) {
+		return ""
+	}
+	if override != st.Override {
+		return ""
+	}
+	for _, r := range usage.Rewards {
+		if r.Time.After(*st.BlockedAt) {
+			return ""
+		}
+	}
+	return "quota reached today, unblocked on the next policy day or by an override or reward"
+}
+
+// blockReason describes why a device gets blocked, for the audit log.
+func (e enforcement) blockReason(usage DeviceUsage, inWindow bool) string {
+	if !inWindow {
+		return "outside allowed time window"
+	}
+	return fmt.Sprintf("quota of %d minutes reached (%d active)", usage.QuotaMinutes, usage.UsedMinutes())
+}
+
+// block blocks or unblocks the user of the device with key and records the
+// action in the audit log.
+func (e enforcement) block(key string, device fritzbox.Landevice, userUID string, block bool, reason string) error {
+	verb := store.ActionUnblock
+	if block {
+		verb = store.ActionBlock
+	}
+	if userUID == "" {
+		_, _ = fmt.Fprintf(e.w, "No user UID found for device, cannot %s\n", verb)
+		return fmt.Errorf("cannot %s, no user UID for device", verb)
+	}
+	if block {
+		_, _ = fmt.Fprintf(e.w, "Blocking using UID: %s\n", userUID)
+	}
+	err := e.client.BlockDevice(userUID, block)
+	if e.opts.History != nil {
+		entry := store.AuditEntry{
+			Time:    e.now,
+			Device:  key,
+			Name:    device.FriendlyName,
+			MAC:     device.MAC,
+			Router:  e.router,
+			UserUID: userUID,
+			Action:  verb,
+			Actor:   e.opts.auditActor(),
+			Reason:  reason,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		if auditErr := e.opts.History.AppendAudit(entry); auditErr != nil {
+			_, _ = fmt.Fprintf(e.w, "Failed to write audit log: %v\n", auditErr)
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(e.w, "Failed to %s device: %v\n", verb, err)
+		return fmt.Errorf("failed to %s device: %w", verb, err)
+	}
+	_, _ = fmt.Fprintf(e.w, "Device %sed\n", verb)
+	return nil
+}
@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"home-gate/internal/policy"
+	"home-gate/internal/store"
+)
+
+// IntervalDetail describes one 15-minute interval of today.
+type IntervalDetail struct {
+	Start  time.Time `json:"start"`
+	Rcv    float64   `json:"rcv"`
+	Snd    float64   `json:"snd"`
+	Active bool      `json:"active"`
+}
+
+// Explanation details how the policy decision for a device was reached.
+type Explanation struct {
+	Name string `json:"name"`
+	MAC  string `json:"mac"`
+	// Child is the child the policy document assigns the device to, if any.
+	Child string `json:"child,omitempty"`
+	// Rule is the policy entry matching today; RuleMatched is false if no entry
+	// covers today, in which case the quota defaults to 0 minutes.
+	Rule        policy.Rule `json:"rule"`
+	RuleMatched bool        `json:"rule_matched"`
+	// Override is the reason of the date-based override in effect, if any.
+	Override     string         `json:"override,omitempty"`
+	QuotaMinutes int            `json:"quota"`
+	BankMinutes  int            `json:"bank"`
+	Rewards      []store.Reward `json:"rewards,omitempty"`
+	Threshold    float64        `json:"activity_threshold"`
+	// Classifier describes how intervals were classified as active.
+	Classifier    string           `json:"classifier"`
+	Intervals     []IntervalDetail `json:"intervals"`
+	ActiveMinutes int              `json:"active_minutes"`
+	// SharedMinutes is what the child's other devices used today, see
+	// DeviceUsage.
+	SharedMinutes int `json:"shared,omitempty"`
+	// Precision is the resolution of ActiveMinutes, see DeviceUsage.
+	Precision string `json:"precision"`
+	Blocked   bool   `json:"blocked"`
+	// InWindow is false outside the allowed time windows of the policy.
+	InWindow bool   `json:"in_window"`
+	Decision string `json:"decision"`
+}
+
+// Explain evaluates today's usage of the device given by opts.Mac against the
+// policy without enforcing anything, keeping every intermediate step.
+func Explain(ctx context.Context, opts Options) (Explanation, error) {
+	var exp Explanation
+	if opts.Mac == "" {
+		return exp, errors.New("a MAC address is required")
+	}
+	if opts.PolicyString == "" && opts.Policies == nil {
+		return exp, errors.New("a policy is required")
+	}
+	opts.Out = io.Discard
+	opts.Period = PeriodDay
+	p, err := NewPipeline(opts)
+	if err != nil {
+		return exp, err
+	}
+	col, err := p.Collector.Collect(ctx)
+	if err != nil {
+		return exp, err
+	}
+	t := col.Targets[0]
+	traffic, ok := col.Traffic(t)
+	if !ok {
+		return exp, fmt.Errorf("MAC %s not found in data", t.Name)
+	}
+	now := p.now()
+	activity := p.Detector.Detect(t, traffic, now)
+	usage := p.Aggregator.Aggregate(t, traffic, activity, now)
+	eval, err := p.Evaluator.Evaluate(t, &usage, now)
+	if err != nil {
+		return exp, err
+	}
+	if eval.Manager == nil {
+		return exp, fmt.Errorf("no policy applies to %s", t.Name)
+	}
+	if len(eval.Errors) > 0 {
+		return exp, eval.Errors[0]
+	}
+
+	pm := eval.Manager
+	exp.Name = t.Name
+	exp.MAC = t.MACs[0]
+	exp.Child = usage.Child
+	exp.Rule, exp.RuleMatched = pm.TodayRule()
+	if o, ok := pm.TodayOverride(); ok {
+		exp.Override = fmt.Sprintf("%s (%s)", o.Reason, o.Policy)
+	}
+	exp.Threshold = opts.ActivityThreshold
+	exp.Classifier = activity.Classifier
+	exp.Blocked = t.Device.IsBlocked()
+
+	for i := max(len(traffic.Rcv)-intervalsSinceMidnight(now), 0); i < len(traffic.Rcv); i++ {
+		r, s := sampleAt(traffic.Rcv, traffic.Snd, i)
+		exp.Intervals = append(exp.Intervals, IntervalDetail{
+			Start:  intervalStart(now, len(traffic.Rcv), i),
+			Rcv:    r,
+			Snd:    s,
+			Active: activity.Intervals[i],
+		})
+	}
+	exp.ActiveMinutes = usage.DailyActiveMinutes
+	exp.SharedMinutes = usage.SharedMinutes
+	exp.Precision = usage.Precision
+	exp.BankMinutes = usage.BankMinutes
+	exp.Rewards = usage.Rewards
+	exp.QuotaMinutes = usage.QuotaMinutes
+	exp.InWindow = eval.InWindow
+
+	st := store.Enforcement{Device: enforcementKey(t.historyKey(), t.Router)}
+	if opts.History != nil {
+		loaded, ok, err := opts.History.Enforcement(st.Device)
+		if err != nil {
+			return exp, fmt.Errorf("failed to read enforcement state: %w", err)
+		}
+		if ok {
+			st = loaded
+		}
+	}
+	exp.Decision = describe(decide(opts, st, eval, usage, exp.Blocked, now), exp.Blocked)
+	return exp, nil
+}
+
+// describe explains a decision for a device that is blocked or not.
+func describe(d decision, blocked bool) string {
+	exceeded := "exceeded policy"
+	if d.reason == reasonWindow {
+		exceeded = "outside allowed time window"
+	}
+	switch d.action {
+	case actionWait:
+		return fmt.Sprintf("exceeded policy, device would be blocked in %s (grace period)", d.graceLeft.Round(time.Second))
+	case actionBlock:
+		if blocked {
+			return exceeded + ", device stays blocked"
+		}
+		return exceeded + ", device would be blocked"
+	case actionKeep:
+		return "within policy, device stays blocked: " + d.reason
+	case actionUnblock:
+		return "within policy, device would be unblocked"
+	default:
+		return "within policy, no action"
+	}
+}
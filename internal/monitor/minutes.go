@@ -0,0 +1,129 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Precisions of the daily active minutes, as ISO 8601 durations.
+const (
+	PrecisionQuarterHour = "PT15M"
+	PrecisionMinute      = "PT1M"
+)
+
+// minutesPerHour is the number of samples subset0001 holds for the last hour.
+const minutesPerHour = 60
+
+// fetchMinuteData fetches the per-minute samples of the last hour. Failures
+// are reported and leave accounting at 15-minute precision.
+func fetchMinuteData(w io.Writer, client fritzbox.Client) []fritzbox.SubsetData {
+	response, err := client.GetMonitorData("macaddrs", "subset0001")
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Per-minute data unavailable, counting in 15-minute intervals: %v\n", err)
+		return nil
+	}
+	return response
+}
+
+// minuteMeasurements returns the target's per-minute samples, or nil if the
+// data does not look like one hour of minutes.
+func minuteMeasurements(response []fritzbox.SubsetData, macs []string) ([]float64, []float64) {
+	rcv, snd := measurementsFor(response, macs)
+	if len(rcv) == 0 || len(rcv) > minutesPerHour {
+		return nil, nil
+	}
+	return rcv, snd
+}
+
+// span is a stretch of active time.
+type span struct {
+	start   time.Time
+	minutes int
+}
+
+// dailyActivity counts today's active minutes. 15-minute intervals from
+// dailyStart on are counted in full, except those covered by the per-minute
+// samples of the recent hour, which are counted minute by minute. It returns
+// the active spans (merged where contiguous) and the precision achieved.
+func dailyActivity(now time.Time, quarters []bool, dailyStart int, minutes []bool) (int, []span, string) {
+	const quarter = 15 * time.Minute
+	precision := PrecisionQuarterHour
+	// Intervals starting at or after boundary are covered by minute samples.
+	boundary := time.Time{}
+	var firstMinute time.Time
+	if len(minutes) > 0 {
+		firstMinute = now.Truncate(time.Minute).Add(-time.Duration(len(minutes)-1) * time.Minute)
+		boundary = firstMinute.Truncate(quarter)
+		if boundary.Before(firstMinute) {
+			boundary = boundary.Add(quarter)
+		}
+		precision = PrecisionMinute
+	}
+
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	total := 0
+	var spans []span
+	add := func(start time.Time, length int) {
+		total += length
+		if n := len(spans); n > 0 && spans[n-1].start.Add(time.Duration(spans[n-1].minutes)*time.Minute).Equal(start) {
+			spans[n-1].minutes += length
+			return
+		}
+		spans = append(spans, span{start: start, minutes: length})
+	}
+	for i := max(dailyStart, 0); i < len(quarters); i++ {
+		start := intervalStart(now, len(quarters), i)
+		if len(minutes) > 0 && !start.Before(boundary) {
+			break
+		}
+		if quarters[i] {
+			add(start, 15)
+		}
+	}
+	for j, active := range minutes {
+		start := firstMinute.Add(time.Duration(j) * time.Minute)
+		if active && !start.Before(boundary) && !start.Before(midnight) {
+			add(start, 1)
+		}
+	}
+	return total, spans, precision
+}
+
+// isoBlock formats a span as "15:04-07:00/PT1H15M".
+func (s span) isoBlock() string {
+	h, m := s.minutes/60, s.minutes%60
+	dur := "PT"
+	if h > 0 {
+		dur += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 || h == 0 {
+		dur += fmt.Sprintf("%dM", m)
+	}
+	return s.start.Format("15:04-07:00") + "/" + dur
+}
+
+// dailyAggregator counts today's active minutes, per minute where the
+// per-minute samples of the recent hour are available.
+type dailyAggregator struct{}
+
+func (dailyAggregator) Aggregate(t Target, traffic Traffic, activity Activity, now time.Time) DeviceUsage {
+	dailyStart := max(len(traffic.Rcv)-intervalsSinceMidnight(now), 0)
+	minutes, spans, precision := dailyActivity(now, activity.Intervals, dailyStart, activity.Minutes)
+	var blocks []string
+	for _, sp := range spans {
+		blocks = append(blocks, sp.isoBlock())
+	}
+	return DeviceUsage{
+		MAC:                t.MACs[0],
+		UID:                t.Device.UID,
+		Name:               t.Name,
+		Routers:            t.routers(),
+		DailyActiveMinutes: minutes,
+		Precision:          precision,
+		Active:             blocks,
+	}
+}
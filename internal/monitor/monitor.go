@@ -1,5 +1,6 @@
 // Package monitor provides the core monitoring logic that was previously managed via the CLI command.
 // This package exposes a Run function that handles monitoring based on options suitable for CLI or background use.
+// Run executes a Pipeline of stages (see pipeline.go) that can also be assembled with custom stages.
 package monitor
 
 import (
@@ -7,33 +8,96 @@ import (
 	"errors"
 	"fmt"
 	"home-gate/internal/fritzbox"
+	"home-gate/internal/identity"
 	"home-gate/internal/policy"
+	"home-gate/internal/store"
 	"io"
-	"strings"
 	"time"
 )
 
 // Options holds the arguments for a monitor run.
 type Options struct {
-	Username          string
-	Password          string
+	Username string
+	Password string
+	// URL is the address of the Fritz!Box. Defaults to fritzbox.DefaultURL.
+	URL string
+	// Routers replaces Username, Password and URL with several Fritz!Boxes
+	// that are monitored concurrently. Optional.
+	Routers           []Router
 	Mac               string
 	Period            string
 	ActivityThreshold float64
-	PolicyString      string
-	Enforce           bool
-	Out               io.Writer
-	// TestClient is used only for dependency injection in testing. Leave nil in production.
+	// Activity picks a classifier per device; it replaces ActivityThreshold.
+	// Optional.
+	Activity *Classifiers
+	// AutoThreshold applies the idle baseline learned from the History to
+	// devices without an activity type.
+	AutoThreshold bool
+	PolicyString  string
+	// Policies is the structured policy document. It takes precedence over
+	// PolicyString. Optional.
+	Policies *policy.Document
+	Enforce  bool
+	Out      io.Writer
+	// Identities links devices that rotate their private MAC. Optional.
+	Identities *identity.Registry
+	// Overrides replace the policy's daily rules on specific dates. Optional.
+	Overrides []policy.Override
+	// History persists daily usage; weekly and monthly budgets need it. Optional.
+	History *store.Store
+	// Location is the time zone that decides where a day starts and which
+	// weekday rule applies. Defaults to the local time zone.
+	Location *time.Location
+	// Clock provides the current time. Defaults to the system clock.
+	Clock policy.Clock
+	// GracePeriod delays blocking after the quota is reached. Needs History.
+	GracePeriod time.Duration
+	// MinBlockDuration keeps a blocked device blocked at least this long.
+	// Needs History.
+	MinBlockDuration time.Duration
+	// AuditActor is recorded in the audit log as who blocked or unblocked a
+	// device. Defaults to store.ActorPolicy.
+	AuditActor string
+	// TestClient replaces the Fritz!Box client, e.g. with a fake or a client
+	// created by the caller; Username and Password are not needed with it.
+	// Leave nil to connect with Username and Password.
 	TestClient fritzbox.Client
 }
 
 // DeviceUsage holds per-device activity and usage info for the current day.
 type DeviceUsage struct {
-	MAC                string   `json:"mac"`
-	Name               string   `json:"name"`
+	MAC  string `json:"mac"`
+	UID  string `json:"uid,omitempty"`
+	Name string `json:"name"`
+	// Child is set when the policy document assigns the device to a child.
+	Child string `json:"child,omitempty"`
+	// Routers names the routers that saw the device, with several routers.
+	Routers            []string `json:"routers,omitempty"`
 	DailyActiveMinutes int      `json:"daily_active_minutes"`
-	Active             []string `json:"active"`
-	QuotaMinutes       int      `json:"quota"`
+	// Precision is the resolution of DailyActiveMinutes as an ISO 8601
+	// duration: PT1M when the recent hour was counted per minute, PT15M when
+	// only 15-minute intervals were available.
+	Precision    string   `json:"precision"`
+	Active       []string `json:"active"`
+	QuotaMinutes int      `json:"quota"`
+	// WeeklyRemainingMinutes and MonthlyRemainingMinutes are set when the
+	// policy defines the corresponding budget.
+	WeeklyRemainingMinutes  *int `json:"weekly_remaining,omitempty"`
+	MonthlyRemainingMinutes *int `json:"monthly_remaining,omitempty"`
+	// BankMinutes is the rollover balance included in QuotaMinutes.
+	BankMinutes int `json:"bank"`
+	// BonusMinutes is the sum of today's rewards included in QuotaMinutes.
+	BonusMinutes int            `json:"bonus"`
+	Rewards      []store.Reward `json:"rewards,omitempty"`
+	// SharedMinutes is what the child's other devices used today; they share
+	// the quota with this device.
+	SharedMinutes int `json:"shared,omitempty"`
+}
+
+// UsedMinutes returns the minutes counting against today's quota: the
+// device's own and those of the child's other devices.
+func (u DeviceUsage) UsedMinutes() int {
+	return u.DailyActiveMinutes + u.SharedMinutes
 }
 
 // Summary holds high-level details about a monitoring run.
@@ -47,336 +111,87 @@ type Summary struct {
 }
 
 // Run executes a monitoring run with the given options, returning a summary.
+// It runs the default pipeline, see NewPipeline.
 func Run(ctx context.Context, opts Options) (Summary, error) {
-	start := time.Now()
-	w := opts.Out
-	var summary Summary
-	if w == nil {
-		w = io.Discard
+	p, err := NewPipeline(opts)
+	if err != nil {
+		return Summary{Errors: []error{err}}, err
+	}
+	return p.Run(ctx)
+}
+
+// location returns the configured time zone.
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
 	}
+	return o.Location
+}
 
-	if opts.Username == "" || opts.Password == "" {
-		err := errors.New("username and password are required")
-		summary.Errors = append(summary.Errors, err)
-		return summary, err
+// clock returns the configured clock.
+func (o Options) clock() policy.Clock {
+	if o.Clock == nil {
+		return policy.RealClock{}
 	}
+	return o.Clock
+}
 
+// auditActor returns who the audit log names for enforcement actions.
+func (o Options) auditActor() string {
+	if o.AuditActor == "" {
+		return store.ActorPolicy
+	}
+	return o.AuditActor
+}
+
+// now returns the current time in the configured time zone.
+func (o Options) now() time.Time {
+	return o.clock().Now().In(o.location())
+}
+
+// connect creates the Fritz!Box client for the options and logs in.
+func connect(w io.Writer, opts Options) (fritzbox.Client, error) {
 	var client fritzbox.Client
 	if opts.TestClient != nil {
 		client = opts.TestClient
+	} else if opts.Username == "" || opts.Password == "" {
+		return nil, errors.New("username and password are required")
+	} else if opts.URL != "" {
+		client = fritzbox.NewWithURL(opts.Username, opts.Password, opts.URL)
 	} else {
 		client = fritzbox.New(opts.Username, opts.Password)
 	}
 	_, _ = fmt.Fprintln(w, "Connecting to Fritz!Box")
 	if err := client.Connect(); err != nil {
-		err = fmt.Errorf("failed to connect: %w", err)
-		summary.Errors = append(summary.Errors, err)
-		return summary, err
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 	_, _ = fmt.Fprintln(w, "Connected")
+	return client, nil
+}
 
-	var pm *policy.PolicyManager
-	if opts.PolicyString != "" {
-		var err error
-		pm, err = policy.NewPolicyManager(opts.PolicyString)
-		if err != nil {
-			err = fmt.Errorf("failed to parse policy: %w", err)
-			summary.Errors = append(summary.Errors, err)
-			return summary, err
-		}
-	}
-
-	_, _ = fmt.Fprintln(w, "Fetching landevices")
-	landevices, err := client.GetLandevices()
-	if err != nil {
-		err = fmt.Errorf("failed to fetch landevices: %w", err)
-		summary.Errors = append(summary.Errors, err)
-		return summary, err
-	}
-	_, _ = fmt.Fprintf(w, "Fetched %d devices\n", len(landevices))
-	summary.DevicesChecked = len(landevices)
-
-	macToUserUID := make(map[string]string)
-	for _, dev := range landevices {
-		if dev.UserUIDs != "" {
-			normalizedMac := strings.ToLower(strings.ReplaceAll(dev.MAC, ":", ""))
-			macToUserUID[normalizedMac] = dev.UserUIDs
-		}
-	}
-	summary.UsersFetched = len(macToUserUID)
-
-	var config fritzbox.MonitorConfig
-	if opts.Mac == "" {
-		config, err = client.GetMonitorConfig()
-		if err != nil {
-			err = fmt.Errorf("failed to fetch monitor config: %w", err)
-			summary.Errors = append(summary.Errors, err)
-			return summary, err
-		}
-	}
-
-	var targetMACs []string
-	var targetNames []string
-	if opts.Mac != "" {
-		normalizedMac := strings.ToLower(strings.ReplaceAll(opts.Mac, ":", ""))
-		targetMACs = []string{normalizedMac}
-		targetNames = []string{opts.Mac}
-	} else {
-		_, _ = fmt.Fprintln(w, "No MAC specified, fetching configured devices")
-		uids := strings.Split(config.DisplayHomenetDevices, ",")
-		_, _ = fmt.Fprintf(w, "Configured UIDs: %v\n", uids)
-		for _, uid := range uids {
-			for _, dev := range landevices {
-				if dev.UID == uid {
-					normalizedMac := strings.ToLower(strings.ReplaceAll(dev.MAC, ":", ""))
-					targetMACs = append(targetMACs, normalizedMac)
-					targetNames = append(targetNames, dev.FriendlyName)
-					_, _ = fmt.Fprintf(w, "Added device: %s (%s)\n", dev.FriendlyName, normalizedMac)
-					break
-				}
-			}
-		}
-		_, _ = fmt.Fprintf(w, "Total target devices: %d\n", len(targetMACs))
-	}
-
-	var subset string
-	var intervalSeconds float64
-	switch opts.Period {
-	case "hour":
-		subset = "subset0001"
-		intervalSeconds = 60
-	case "day":
-		subset = "subset0002"
-		intervalSeconds = 900
-	default:
-		err = fmt.Errorf("invalid period: %s. Use 'hour' or 'day'", opts.Period)
-		summary.Errors = append(summary.Errors, err)
-		return summary, err
-	}
-
-	response, err := client.GetMonitorData("macaddrs", subset)
-	if err != nil {
-		err = fmt.Errorf("failed to fetch monitor data: %w", err)
-		summary.Errors = append(summary.Errors, err)
-		return summary, err
+// sampleAt returns the rcv and snd rates (Byte/s) of interval i.
+func sampleAt(rcv, snd []float64, i int) (float64, float64) {
+	s := 0.0
+	if i < len(snd) {
+		s = snd[i]
 	}
+	return rcv[i], s
+}
 
-	for idx, normalizedMac := range targetMACs {
-		name := targetNames[idx]
-		var rcvMeasurements, sndMeasurements []float64
-		for _, sd := range response {
-			if strings.HasSuffix(sd.DataSourceName, normalizedMac) {
-				if strings.HasPrefix(sd.DataSourceName, "rcv_") {
-					rcvMeasurements = sd.Measurements
-				} else if strings.HasPrefix(sd.DataSourceName, "snd_") {
-					sndMeasurements = sd.Measurements
-				}
-			}
-		}
-		if rcvMeasurements == nil || sndMeasurements == nil {
-			_, _ = fmt.Fprintf(w, "MAC %s not found in data\n", name)
-			summary.Errors = append(summary.Errors, fmt.Errorf("MAC %s not found in data", name))
-			continue
-		}
-
-		var device fritzbox.Landevice
-		for _, dev := range landevices {
-			if strings.ToLower(strings.ReplaceAll(dev.MAC, ":", "")) == normalizedMac {
-				device = dev
-				break
-			}
-		}
-
-		if opts.Period == "hour" {
-			var totalRcv, totalSnd int64
-			for _, val := range rcvMeasurements {
-				totalRcv += int64(val * intervalSeconds)
-			}
-			for _, val := range sndMeasurements {
-				totalSnd += int64(val * intervalSeconds)
-			}
-			_, _ = fmt.Fprintf(w, "%s usage in last hour:\n", name)
-			_, _ = fmt.Fprintf(w, "Downstream: %d bytes\n", totalRcv)
-			_, _ = fmt.Fprintf(w, "Upstream: %d bytes\n", totalSnd)
-		} else {
-			now := time.Now()
-			minutesPastMidnight := now.Hour()*60 + now.Minute()
-			intervalsSinceMidnight := minutesPastMidnight / 15
-			dailyStart := len(rcvMeasurements) - intervalsSinceMidnight
-			if dailyStart < 0 {
-				dailyStart = 0
-			}
-			dailyActiveCount := 0
-			for i := dailyStart; i < len(rcvMeasurements); i++ {
-				rcv := rcvMeasurements[i]
-				snd := 0.0
-				if i < len(sndMeasurements) {
-					snd = sndMeasurements[i]
-				}
-				if rcv > opts.ActivityThreshold || snd > opts.ActivityThreshold {
-					dailyActiveCount++
-				}
-			}
-			dailyActiveMinutes := dailyActiveCount * 15
-
-			// Gather active *indexes* for today to group as ISO intervals
-			var activeIndexes []int
-			for i := dailyStart; i < len(rcvMeasurements); i++ {
-				if i < 0 {
-					continue
-				}
-				rcv := rcvMeasurements[i]
-				snd := 0.0
-				if i < len(sndMeasurements) {
-					snd = sndMeasurements[i]
-				}
-				if rcv > opts.ActivityThreshold || snd > opts.ActivityThreshold {
-					activeIndexes = append(activeIndexes, i)
-				}
-			}
-
-			// Collapse contiguous intervals into ISO blocks
-			var activeBlocks []string
-			if len(activeIndexes) > 0 {
-				loc := time.Local
-				const step = 15 // minutes
-				blockStart := 0
-				for i := 1; i <= len(activeIndexes); i++ {
-					if i == len(activeIndexes) || activeIndexes[i] != activeIndexes[i-1]+1 {
-						startIdx := activeIndexes[blockStart]
-						endIdx := activeIndexes[i-1]
-						// Use oldest interval as reference (rolling window fix)
-						intervalCount := len(rcvMeasurements)
-						latestIntervalTime := time.Now().In(loc).Truncate(step * time.Minute)
-						oldestIntervalTime := latestIntervalTime.Add(-time.Duration(intervalCount-1) * step * time.Minute)
-						startTime := oldestIntervalTime.Add(time.Duration(startIdx) * step * time.Minute)
-						durationMin := (endIdx - startIdx + 1) * step
-						h := durationMin / 60
-						m := durationMin % 60
-						tzOffset := startTime.Format("-07:00")
-						isoTime := startTime.Format("15:04") + tzOffset
-						isoDur := "P"
-						if h > 0 {
-							isoDur += "T" + fmt.Sprintf("%dH", h)
-						}
-						if m > 0 {
-							if h == 0 {
-								isoDur += "T"
-							}
-							isoDur += fmt.Sprintf("%dM", m)
-						}
-						activeBlocks = append(activeBlocks, isoTime+"/"+isoDur)
-						blockStart = i
-					}
-				}
-			}
-			deviceUsage := DeviceUsage{
-				MAC:                normalizedMac,
-				Name:               name,
-				DailyActiveMinutes: dailyActiveMinutes,
-				Active:             activeBlocks,
-				QuotaMinutes:       0, // default to 0, will be set below
-			}
-			if pm != nil {
-				deviceUsage.QuotaMinutes = pm.AllowedToday()
-			}
-			summary.Devices = append(summary.Devices, deviceUsage)
-
-			numIntervals := 48
-			start := len(rcvMeasurements) - numIntervals
-			if start < 0 {
-				start = 0
-				numIntervals = len(rcvMeasurements)
-			}
-			activeCount := 0
-			var activity []bool
-			for i := start; i < len(rcvMeasurements); i++ {
-				rcv := rcvMeasurements[i]
-				snd := 0.0
-				if i < len(sndMeasurements) {
-					snd = sndMeasurements[i]
-				}
-				isActive := rcv > opts.ActivityThreshold || snd > opts.ActivityThreshold
-				activity = append(activity, isActive)
-				if isActive {
-					activeCount++
-				}
-			}
-			activeMinutes := activeCount * 15
-
-			intervalsPastMidnight := intervalsSinceMidnight
-			dayStartPos := numIntervals - intervalsPastMidnight
-			var viz strings.Builder
-			for i, act := range activity {
-				if dayStartPos >= 0 && dayStartPos < numIntervals && i == int(dayStartPos) {
-					viz.WriteString("|")
-				} else if act {
-					viz.WriteString("*")
-				} else {
-					viz.WriteString(".")
-				}
-			}
-			_, _ = fmt.Fprintf(w, "%s activity in last 12 hours:\n", name)
-			_, _ = fmt.Fprintf(w, "Active: %d minutes (%d/%d intervals)\n", activeMinutes, activeCount, numIntervals)
-			_, _ = fmt.Fprintf(w, "Daily total: %d minutes (%d/96 intervals)\n", dailyActiveMinutes, dailyActiveCount)
-			if pm != nil {
-				allowed := pm.AllowedToday()
-				if dailyActiveMinutes < allowed {
-					_, _ = fmt.Fprintf(w, "Within policy\n")
-					if opts.Enforce && device.Blocked == "1" {
-						userUID := device.UserUIDs
-						if userUID == "" {
-							if u, ok := macToUserUID[normalizedMac]; ok {
-								userUID = u
-							}
-						}
-						if userUID != "" {
-							err := client.BlockDevice(userUID, false)
-							if err != nil {
-								_, _ = fmt.Fprintf(w, "Failed to unblock device: %v\n", err)
-								summary.Errors = append(summary.Errors, fmt.Errorf("failed to unblock device: %w", err))
-							} else {
-								_, _ = fmt.Fprintf(w, "Device unblocked\n")
-							}
-						} else {
-							_, _ = fmt.Fprintf(w, "No user UID found for device, cannot unblock\n")
-							summary.Errors = append(summary.Errors, fmt.Errorf("cannot unblock, no user UID for device"))
-						}
-					}
-				} else {
-					_, _ = fmt.Fprintf(w, "Exceeded policy\n")
-					if opts.Enforce {
-						userUID := device.UserUIDs
-						if userUID == "" {
-							if u, ok := macToUserUID[normalizedMac]; ok {
-								userUID = u
-							}
-						}
-						if userUID == "" {
-							userUID = device.UID
-						}
-						if userUID != "" {
-							_, _ = fmt.Fprintf(w, "Blocking using UID: %s\n", userUID)
-							err := client.BlockDevice(userUID, true)
-							if err != nil {
-								_, _ = fmt.Fprintf(w, "Failed to block device: %v\n", err)
-								summary.Errors = append(summary.Errors, fmt.Errorf("failed to block device: %w", err))
-							} else {
-								_, _ = fmt.Fprintf(w, "Device blocked\n")
-							}
-						} else {
-							_, _ = fmt.Fprintf(w, "No user UID found for device, cannot block\n")
-							summary.Errors = append(summary.Errors, fmt.Errorf("cannot block, no user UID for device"))
-						}
-					}
-				}
-			}
-			_, _ = fmt.Fprintf(w, "Timeline: %s\n", viz.String())
-		}
-		_, _ = fmt.Fprintln(w)
-	}
+// intervalsSinceMidnight returns the number of completed 15-minute intervals
+// since midnight in now's time zone. It counts elapsed time rather than clock
+// time, so days with a DST change get 92 or 100 intervals.
+func intervalsSinceMidnight(now time.Time) int {
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	return int(now.Sub(midnight) / (15 * time.Minute))
+}
 
-	summary.Duration = time.Since(start)
-	summary.StartTime = start
-	return summary, nil
+// intervalStart returns the start time of interval idx out of count intervals,
+// using the oldest interval as reference (rolling window fix).
+func intervalStart(now time.Time, count, idx int) time.Time {
+	const step = 15 * time.Minute
+	latestIntervalTime := now.Truncate(step)
+	oldestIntervalTime := latestIntervalTime.Add(-time.Duration(count-1) * step)
+	return oldestIntervalTime.Add(time.Duration(idx) * step)
 }
@@ -0,0 +1,374 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/policy"
+)
+
+// A monitor run is a pipeline of stages, each behind an interface so
+// integrations can replace or wrap a stage and tests can drive one alone:
+//
+//	Collector -> Detector -> Aggregator -> Evaluator -> Reporters -> Enforcer
+//
+// The collector fetches devices and traffic once per run; the other stages
+// run per target device.
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_collector.go . Collector
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_detector.go . Detector
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_aggregator.go . Aggregator
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_evaluator.go . Evaluator
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_enforcer.go . Enforcer
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_reporter.go . Reporter
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_preparer.go . Preparer
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_finisher.go . Finisher
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_flusher.go . Flusher
+
+// Collector fetches the devices to monitor and their traffic.
+type Collector interface {
+	Collect(ctx context.Context) (Collection, error)
+}
+
+// Detector classifies a target's traffic into active and idle intervals.
+type Detector interface {
+	Detect(t Target, traffic Traffic, now time.Time) Activity
+}
+
+// Aggregator turns a target's activity into today's usage.
+type Aggregator interface {
+	Aggregate(t Target, traffic Traffic, activity Activity, now time.Time) DeviceUsage
+}
+
+// Evaluator applies the target's policy to its usage, setting the child and
+// quota. An error skips the target.
+type Evaluator interface {
+	Evaluate(t Target, usage *DeviceUsage, now time.Time) (Evaluation, error)
+}
+
+// Enforcer blocks or unblocks a target according to the evaluation.
+type Enforcer interface {
+	Enforce(t Target, usage DeviceUsage, eval Evaluation, now time.Time) []error
+}
+
+// Reporter receives the result for each target, e.g. to print or persist it.
+type Reporter interface {
+	Report(r Report) error
+}
+
+// Preparer is implemented by stages that load what all targets need once per
+// run, e.g. the learned baselines. Prepare is called after collecting.
+type Preparer interface {
+	Prepare(now time.Time) error
+}
+
+// Finisher is implemented by reporters with output that follows the
+// enforcement of a target, e.g. the timeline of the text report. Finish is
+// called after the Enforcer, also without one.
+type Finisher interface {
+	Finish(r Report) error
+}
+
+// Flusher is implemented by reporters that batch their reports, e.g. the
+// traffic samples. Flush is called after the last target of a run.
+type Flusher interface {
+	Flush(now time.Time) error
+}
+
+// Periods a run can cover.
+const (
+	PeriodHour = "hour"
+	PeriodDay  = "day"
+)
+
+// Collection is what a Collector fetched for one run.
+type Collection struct {
+	Landevices []fritzbox.Landevice
+	Targets    []Target
+	// UserUIDs maps normalized MACs to the Fritz!Box user of the device.
+	UserUIDs map[string]string
+	// Period is PeriodHour or PeriodDay.
+	Period string
+	// Data holds the samples of the period, Minutes the per-minute samples
+	// of the last hour if available.
+	Data    []fritzbox.SubsetData
+	Minutes []fritzbox.SubsetData
+	// Errors are problems that did not prevent collecting, e.g. an
+	// unreachable router.
+	Errors []error
+}
+
+// Traffic is the traffic of one target, in Byte/s per sample.
+type Traffic struct {
+	Rcv, Snd []float64
+	// Interval is the length of one sample of Rcv and Snd.
+	Interval time.Duration
+	// MinuteRcv and MinuteSnd hold the per-minute samples of the last hour,
+	// or nil.
+	MinuteRcv, MinuteSnd []float64
+}
+
+// Traffic returns the target's traffic, or false if none of its MACs appear
+// in the data.
+func (c Collection) Traffic(t Target) (Traffic, bool) {
+	rcv, snd := measurementsFor(c.Data, t.MACs)
+	if rcv == nil || snd == nil {
+		return Traffic{}, false
+	}
+	traffic := Traffic{Rcv: rcv, Snd: snd, Interval: 15 * time.Minute}
+	if c.Period == PeriodHour {
+		traffic.Interval = time.Minute
+	}
+	traffic.MinuteRcv, traffic.MinuteSnd = minuteMeasurements(c.Minutes, t.MACs)
+	return traffic, true
+}
+
+// Activity is a target's traffic classified by a Classifier.
+type Activity struct {
+	// Classifier describes the classifier used.
+	Classifier string
+	// Intervals has one entry per sample of Traffic.Rcv, Minutes one per
+	// per-minute sample (nil without them).
+	Intervals []bool
+	Minutes   []bool
+}
+
+// Evaluation is the policy decision for a target.
+type Evaluation struct {
+	// Manager is the target's policy; nil if no policy applies, in which
+	// case the target is not enforced.
+	Manager *policy.PolicyManager
+	// QuotaReached is set once the active minutes reach the quota.
+	QuotaReached bool
+	// InWindow is false outside the allowed time windows.
+	InWindow bool
+	// Errors are problems that did not prevent a decision, e.g. an
+	// unreadable usage history.
+	Errors []error
+}
+
+// Report is the result for one target. Usage and Evaluation are nil for the
+// hour period, which only reports the traffic.
+type Report struct {
+	Target     Target
+	Traffic    Traffic
+	Activity   Activity
+	Usage      *DeviceUsage
+	Evaluation *Evaluation
+	Now        time.Time
+}
+
+// Pipeline runs the stages of a monitor run.
+type Pipeline struct {
+	Collector  Collector
+	Detector   Detector
+	Aggregator Aggregator
+	Evaluator  Evaluator
+	// Enforcer is optional; without it devices are never blocked.
+	Enforcer  Enforcer
+	Reporters []Reporter
+	// Clock and Location default to the system clock and local time zone.
+	Clock    policy.Clock
+	Location *time.Location
+	// Out receives progress and errors. Optional.
+	Out io.Writer
+}
+
+// NewPipeline connects to the Fritz!Box, or to all opts.Routers, and
+// assembles the default stages for opts: the Fritz!Box collector, the configured classifiers, the policy
+// evaluator, the Fritz!Box enforcer, with opts.History a reporter recording
+// usage and traffic samples, and a text reporter writing to opts.Out.
+func NewPipeline(opts Options) (*Pipeline, error) {
+	w := opts.Out
+	if w == nil {
+		w = io.Discard
+	}
+	routers, failed, err := connectRouters(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPipeline(w, opts, routers)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Routers) == 0 {
+		p.Collector = fritzboxCollector{w: w, client: routers[0].client, opts: opts}
+	} else {
+		p.Collector = newRoutersCollector(w, routers, failed, opts)
+	}
+	return p, nil
+}
+
+// newPipeline assembles the stages after the collector for the connected
+// routers.
+func newPipeline(w io.Writer, opts Options, routers []router) (*Pipeline, error) {
+	pols, err := newPolicies(w, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pipeline{
+		Detector:   &classifierDetector{w: w, opts: opts},
+		Aggregator: dailyAggregator{},
+		Evaluator:  policyEvaluator{w: w, opts: opts, policies: pols},
+		Reporters:  []Reporter{textReporter{w: w}},
+		Clock:      opts.clock(),
+		Location:   opts.location(),
+		Out:        w,
+	}
+	if len(routers) == 1 && routers[0].name == "" {
+		p.Enforcer = fritzboxEnforcer{w: w, client: routers[0].client, opts: opts}
+	} else {
+		enforcers := make(map[string]Enforcer)
+		for _, r := range routers {
+			enforcers[r.name] = fritzboxEnforcer{w: w, client: r.client, opts: opts, router: r.name}
+		}
+		p.Enforcer = routersEnforcer{w: w, enforcers: enforcers}
+	}
+	if opts.History != nil {
+		p.Reporters = append([]Reporter{&historyReporter{history: opts.History}}, p.Reporters...)
+	}
+	return p, nil
+}
+
+// Run collects once and runs the per-target stages for each target, preparing
+// and flushing the stages that implement Preparer and Flusher around them. It
+// stops before the next target once ctx is done.
+func (p *Pipeline) Run(ctx context.Context) (Summary, error) {
+	start := time.Now()
+	var summary Summary
+	col, err := p.Collector.Collect(ctx)
+	summary.DevicesChecked = len(col.Landevices)
+	summary.UsersFetched = len(col.UserUIDs)
+	if err != nil {
+		summary.Errors = append(summary.Errors, err)
+		return summary, err
+	}
+	summary.Errors = append(summary.Errors, col.Errors...)
+	summary.Errors = append(summary.Errors, p.prepare()...)
+
+	for _, t := range col.Targets {
+		if err := ctx.Err(); err != nil {
+			summary.Errors = append(summary.Errors, err)
+			summary.Errors = append(summary.Errors, p.flush()...)
+			return summary, err
+		}
+		usage, errs := p.runTarget(col, t)
+		summary.Errors = append(summary.Errors, errs...)
+		if usage != nil {
+			summary.Devices = append(summary.Devices, *usage)
+		}
+		_, _ = fmt.Fprintln(p.out())
+	}
+
+	summary.Errors = append(summary.Errors, p.flush()...)
+	summary.Duration = time.Since(start)
+	summary.StartTime = start
+	return summary, nil
+}
+
+// runTarget runs the per-target stages. It returns the usage for the day
+// period, unless the target was skipped.
+func (p *Pipeline) runTarget(col Collection, t Target) (*DeviceUsage, []error) {
+	w := p.out()
+	traffic, ok := col.Traffic(t)
+	if !ok {
+		_, _ = fmt.Fprintf(w, "MAC %s not found in data\n", t.Name)
+		return nil, []error{fmt.Errorf("MAC %s not found in data", t.Name)}
+	}
+	now := p.now()
+	if col.Period == PeriodHour {
+		return nil, p.report(Report{Target: t, Traffic: traffic, Now: now})
+	}
+
+	activity := p.Detector.Detect(t, traffic, now)
+	usage := p.Aggregator.Aggregate(t, traffic, activity, now)
+	eval, err := p.Evaluator.Evaluate(t, &usage, now)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "%v\n", err)
+		return nil, []error{err}
+	}
+	errs := append([]error(nil), eval.Errors...)
+	rep := Report{Target: t, Traffic: traffic, Activity: activity, Usage: &usage, Evaluation: &eval, Now: now}
+	errs = append(errs, p.report(rep)...)
+	if eval.Manager != nil && p.Enforcer != nil {
+		errs = append(errs, p.Enforcer.Enforce(t, usage, eval, now)...)
+	}
+	errs = append(errs, p.finish(rep)...)
+	return &usage, errs
+}
+
+// finish passes the report to every reporter implementing Finisher.
+func (p *Pipeline) finish(r Report) []error {
+	var errs []error
+	for _, reporter := range p.Reporters {
+		if finisher, ok := reporter.(Finisher); ok {
+			if err := finisher.Finish(r); err != nil {
+				_, _ = fmt.Fprintf(p.out(), "%v\n", err)
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// prepare prepares the stages implementing Preparer for a run.
+func (p *Pipeline) prepare() []error {
+	var errs []error
+	for _, stage := range []any{p.Collector, p.Detector, p.Aggregator, p.Evaluator, p.Enforcer} {
+		if preparer, ok := stage.(Preparer); ok {
+			if err := preparer.Prepare(p.now()); err != nil {
+				_, _ = fmt.Fprintf(p.out(), "%v\n", err)
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// flush flushes the reporters implementing Flusher after a run.
+func (p *Pipeline) flush() []error {
+	var errs []error
+	for _, reporter := range p.Reporters {
+		if flusher, ok := reporter.(Flusher); ok {
+			if err := flusher.Flush(p.now()); err != nil {
+				_, _ = fmt.Fprintf(p.out(), "%v\n", err)
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// report passes the report to every reporter.
+func (p *Pipeline) report(r Report) []error {
+	var errs []error
+	for _, reporter := range p.Reporters {
+		if err := reporter.Report(r); err != nil {
+			_, _ = fmt.Fprintf(p.out(), "%v\n", err)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (p *Pipeline) out() io.Writer {
+	if p.Out == nil {
+		return io.Discard
+	}
+	return p.Out
+}
+
+// now returns the current time in the pipeline's time zone.
+func (p *Pipeline) now() time.Time {
+	clock, loc := p.Clock, p.Location
+	if clock == nil {
+		clock = policy.RealClock{}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return clock.Now().In(loc)
+}
@@ -0,0 +1,220 @@
+package monitor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/monitor"
+	"home-gate/internal/monitor/monitorfakes"
+	"home-gate/internal/policy"
+	"home-gate/internal/policy/policyfakes"
+)
+
+var _ = Describe("Pipeline", func() {
+	var (
+		collector  *monitorfakes.FakeCollector
+		detector   *monitorfakes.FakeDetector
+		aggregator *monitorfakes.FakeAggregator
+		evaluator  *monitorfakes.FakeEvaluator
+		enforcer   *monitorfakes.FakeEnforcer
+		reporter   *monitorfakes.FakeReporter
+		pipeline   *monitor.Pipeline
+		out        bytes.Buffer
+		ipad       monitor.Target
+	)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		collector = &monitorfakes.FakeCollector{}
+		detector = &monitorfakes.FakeDetector{}
+		aggregator = &monitorfakes.FakeAggregator{}
+		evaluator = &monitorfakes.FakeEvaluator{}
+		enforcer = &monitorfakes.FakeEnforcer{}
+		reporter = &monitorfakes.FakeReporter{}
+		clock := &policyfakes.FakeClock{}
+		clock.NowReturns(now)
+		out.Reset()
+		pipeline = &monitor.Pipeline{
+			Collector:  collector,
+			Detector:   detector,
+			Aggregator: aggregator,
+			Evaluator:  evaluator,
+			Enforcer:   enforcer,
+			Reporters:  []monitor.Reporter{reporter},
+			Clock:      clock,
+			Location:   time.UTC,
+			Out:        &out,
+		}
+
+		ipad = monitor.Target{Name: "iPad", MACs: []string{"aa11bb22cc33"}}
+		collector.CollectReturns(monitor.Collection{
+			Landevices: []fritzbox.Landevice{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33"}},
+			Targets:    []monitor.Target{ipad},
+			Period:     monitor.PeriodDay,
+			Data: []fritzbox.SubsetData{
+				{DataSourceName: "rcv_aa11bb22cc33", Measurements: []float64{0, 5000}},
+				{DataSourceName: "snd_aa11bb22cc33", Measurements: []float64{0, 100}},
+			},
+		}, nil)
+		detector.DetectReturns(monitor.Activity{Classifier: "fake", Intervals: []bool{false, true}})
+		aggregator.AggregateReturns(monitor.DeviceUsage{Name: "iPad", DailyActiveMinutes: 15})
+	})
+
+	It("passes each target through the stages", func() {
+		pm, err := policy.NewPolicyManager("MO-SU60")
+		Expect(err).ToNot(HaveOccurred())
+		evaluator.EvaluateStub = func(t monitor.Target, usage *monitor.DeviceUsage, _ time.Time) (monitor.Evaluation, error) {
+			usage.QuotaMinutes = 60
+			return monitor.Evaluation{Manager: pm, InWindow: true}, nil
+		}
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.DevicesChecked).To(Equal(1))
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].QuotaMinutes).To(Equal(60))
+
+		t, traffic, at := detector.DetectArgsForCall(0)
+		Expect(t).To(Equal(ipad))
+		Expect(traffic.Rcv).To(Equal([]float64{0, 5000}))
+		Expect(traffic.Interval).To(Equal(15 * time.Minute))
+		Expect(at).To(Equal(now))
+
+		_, _, activity, _ := aggregator.AggregateArgsForCall(0)
+		Expect(activity.Classifier).To(Equal("fake"))
+
+		Expect(reporter.ReportCallCount()).To(Equal(1))
+		Expect(reporter.ReportArgsForCall(0).Usage.QuotaMinutes).To(Equal(60))
+
+		Expect(enforcer.EnforceCallCount()).To(Equal(1))
+		_, usage, eval, _ := enforcer.EnforceArgsForCall(0)
+		Expect(usage.DailyActiveMinutes).To(Equal(15))
+		Expect(eval.Manager).To(BeIdenticalTo(pm))
+	})
+
+	It("prepares and flushes the stages once per run", func() {
+		preparer := &monitorfakes.FakePreparer{}
+		flusher := &monitorfakes.FakeFlusher{}
+		pipeline.Detector = struct {
+			*monitorfakes.FakeDetector
+			*monitorfakes.FakePreparer
+		}{detector, preparer}
+		pipeline.Reporters = append(pipeline.Reporters, struct {
+			*monitorfakes.FakeReporter
+			*monitorfakes.FakeFlusher
+		}{&monitorfakes.FakeReporter{}, flusher})
+		flusher.FlushReturns(errors.New("failed to record traffic samples"))
+		col, _ := collector.Collect(context.Background())
+		col.Targets = append(col.Targets, monitor.Target{Name: "iPad (2)", MACs: ipad.MACs})
+		collector.CollectReturns(col, nil)
+		evaluator.EvaluateReturns(monitor.Evaluation{InWindow: true}, nil)
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detector.DetectCallCount()).To(Equal(2))
+		Expect(preparer.PrepareCallCount()).To(Equal(1))
+		Expect(preparer.PrepareArgsForCall(0)).To(Equal(now))
+		Expect(flusher.FlushCallCount()).To(Equal(1))
+		Expect(summary.Errors).To(ConsistOf(MatchError("failed to record traffic samples")))
+	})
+
+	It("finishes the reports after enforcing", func() {
+		pm, err := policy.NewPolicyManager("MO-SU60")
+		Expect(err).ToNot(HaveOccurred())
+		evaluator.EvaluateReturns(monitor.Evaluation{Manager: pm, InWindow: true}, nil)
+		var calls []string
+		finisher := &monitorfakes.FakeFinisher{}
+		reporter.ReportStub = func(monitor.Report) error { calls = append(calls, "report"); return nil }
+		enforcer.EnforceStub = func(monitor.Target, monitor.DeviceUsage, monitor.Evaluation, time.Time) []error {
+			calls = append(calls, "enforce")
+			return nil
+		}
+		finisher.FinishStub = func(monitor.Report) error { calls = append(calls, "finish"); return nil }
+		pipeline.Reporters = []monitor.Reporter{struct {
+			*monitorfakes.FakeReporter
+			*monitorfakes.FakeFinisher
+		}{reporter, finisher}}
+
+		_, err = pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal([]string{"report", "enforce", "finish"}))
+		Expect(finisher.FinishArgsForCall(0).Usage.DailyActiveMinutes).To(Equal(15))
+	})
+
+	It("does not enforce targets without a policy", func() {
+		evaluator.EvaluateReturns(monitor.Evaluation{InWindow: true}, nil)
+
+		_, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reporter.ReportCallCount()).To(Equal(1))
+		Expect(enforcer.EnforceCallCount()).To(Equal(0))
+	})
+
+	It("skips targets the evaluator rejects", func() {
+		evaluator.EvaluateReturns(monitor.Evaluation{}, errors.New("failed to load policy"))
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Errors).To(ConsistOf(MatchError("failed to load policy")))
+		Expect(summary.Devices).To(BeEmpty())
+		Expect(reporter.ReportCallCount()).To(Equal(0))
+	})
+
+	It("collects reporter errors without stopping the run", func() {
+		evaluator.EvaluateReturns(monitor.Evaluation{InWindow: true}, nil)
+		reporter.ReportReturns(errors.New("disk full"))
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Errors).To(ConsistOf(MatchError("disk full")))
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(out.String()).To(ContainSubstring("disk full"))
+	})
+
+	It("reports targets missing from the data", func() {
+		collector.CollectReturns(monitor.Collection{
+			Targets: []monitor.Target{ipad},
+			Period:  monitor.PeriodDay,
+		}, nil)
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Errors).To(ConsistOf(MatchError("MAC iPad not found in data")))
+		Expect(detector.DetectCallCount()).To(Equal(0))
+	})
+
+	It("only reports the traffic for the hour period", func() {
+		collector.CollectReturns(monitor.Collection{
+			Targets: []monitor.Target{ipad},
+			Period:  monitor.PeriodHour,
+			Data: []fritzbox.SubsetData{
+				{DataSourceName: "rcv_aa11bb22cc33", Measurements: []float64{10}},
+				{DataSourceName: "snd_aa11bb22cc33", Measurements: []float64{1}},
+			},
+		}, nil)
+
+		_, err := pipeline.Run(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(detector.DetectCallCount()).To(Equal(0))
+		Expect(evaluator.EvaluateCallCount()).To(Equal(0))
+		Expect(reporter.ReportCallCount()).To(Equal(1))
+		report := reporter.ReportArgsForCall(0)
+		Expect(report.Usage).To(BeNil())
+		Expect(report.Traffic.Interval).To(Equal(time.Minute))
+	})
+
+	It("fails when collecting fails", func() {
+		collector.CollectReturns(monitor.Collection{}, errors.New("failed to fetch landevices"))
+
+		summary, err := pipeline.Run(context.Background())
+		Expect(err).To(MatchError("failed to fetch landevices"))
+		Expect(summary.Errors).To(HaveLen(1))
+		Expect(detector.DetectCallCount()).To(Equal(0))
+	})
+})
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"home-gate/internal/policy"
+)
+
+// policies resolves the policy manager for each monitored device from the
+// policy document, creating one manager per child.
+type policies struct {
+	w         io.Writer
+	clock     policy.Clock
+	loc       *time.Location
+	doc       *policy.Document
+	overrides []policy.Override
+	managers  map[string]*policy.PolicyManager
+}
+
+// newPolicies uses opts.Policies, or converts opts.PolicyString into a
+// document. Without either, no device has a policy.
+func newPolicies(w io.Writer, opts Options) (*policies, error) {
+	doc := opts.Policies
+	if doc == nil && opts.PolicyString != "" {
+		var err error
+		if doc, err = policy.FromString(opts.PolicyString); err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+	}
+	return &policies{w: w, clock: opts.clock(), loc: opts.location(), doc: doc, overrides: opts.Overrides, managers: make(map[string]*policy.PolicyManager)}, nil
+}
+
+// forTarget returns the child the target belongs to and its policy manager,
+// or a nil manager if no policy applies.
+func (p *policies) forTarget(t Target) (string, *policy.PolicyManager, error) {
+	if p.doc == nil {
+		return "", nil, nil
+	}
+	child, rules, ok := p.doc.RulesFor(t.keys()...)
+	if !ok {
+		return "", nil, nil
+	}
+	if pm, ok := p.managers[child]; ok {
+		return child, pm, nil
+	}
+	pm, err := rules.Manager(p.clock, p.loc)
+	if err != nil {
+		return child, nil, fmt.Errorf("failed to load policy: %w", err)
+	}
+	pm.AddOverrides(p.overrides...)
+	if o, ok := pm.TodayOverride(); ok {
+		_, _ = fmt.Fprintf(p.w, "Policy override today: %s (%s)\n", o.Reason, o.Policy)
+	}
+	p.managers[child] = pm
+	return child, pm, nil
+}
+
+// policyEvaluator sets a target's quota from its policy, the usage history
+// and today's rewards.
+type policyEvaluator struct {
+	w        io.Writer
+	opts     Options
+	policies *policies
+}
+
+func (e policyEvaluator) Evaluate(t Target, usage *DeviceUsage, now time.Time) (Evaluation, error) {
+	eval := Evaluation{InWindow: true}
+	child, pm, err := e.policies.forTarget(t)
+	if err != nil {
+		return eval, err
+	}
+	usage.Child = child
+	if pm == nil {
+		return eval, nil
+	}
+	eval.Manager = pm
+	if err := applyBudgets(e.opts, pm, t.historyKey(), usage); err != nil {
+		_, _ = fmt.Fprintf(e.w, "Failed to apply usage history: %v\n", err)
+		eval.Errors = append(eval.Errors, err)
+	}
+	if e.opts.History != nil {
+		if err := applyRewards(e.opts.History, now, usage); err != nil {
+			_, _ = fmt.Fprintf(e.w, "%v\n", err)
+			eval.Errors = append(eval.Errors, err)
+		}
+		for _, r := range usage.Rewards {
+			_, _ = fmt.Fprintf(e.w, "Reward: %+d minutes (%s)\n", r.Minutes, r.Reason)
+		}
+	}
+	eval.QuotaReached = usage.UsedMinutes() >= usage.QuotaMinutes
+	eval.InWindow = pm.InWindow(now)
+	return eval, nil
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"home-gate/internal/store"
+)
+
+// textReporter prints the traffic of the last hour, or the activity of the
+// last 12 hours and, once the target was enforced, its timeline.
+type textReporter struct {
+	w io.Writer
+}
+
+func (r textReporter) Report(rep Report) error {
+	name := rep.Target.Name
+	if rep.Usage == nil {
+		var totalRcv, totalSnd int64
+		seconds := rep.Traffic.Interval.Seconds()
+		for _, val := range rep.Traffic.Rcv {
+			totalRcv += int64(val * seconds)
+		}
+		for _, val := range rep.Traffic.Snd {
+			totalSnd += int64(val * seconds)
+		}
+		_, _ = fmt.Fprintf(r.w, "%s usage in last hour:\n", name)
+		_, _ = fmt.Fprintf(r.w, "Downstream: %d bytes\n", totalRcv)
+		_, _ = fmt.Fprintf(r.w, "Upstream: %d bytes\n", totalSnd)
+		return nil
+	}
+
+	intervals := lastHalfDay(rep.Activity.Intervals)
+	activeCount := 0
+	for _, active := range intervals {
+		if active {
+			activeCount++
+		}
+	}
+	_, _ = fmt.Fprintf(r.w, "%s activity in last 12 hours:\n", name)
+	_, _ = fmt.Fprintf(r.w, "Active: %d minutes (%d/%d intervals)\n", activeCount*15, activeCount, len(intervals))
+	_, _ = fmt.Fprintf(r.w, "Daily total: %d minutes (precision %s)\n", rep.Usage.DailyActiveMinutes, rep.Usage.Precision)
+	if rep.Usage.SharedMinutes > 0 {
+		_, _ = fmt.Fprintf(r.w, "%s's total: %d minutes on all devices\n", rep.Usage.Child, rep.Usage.UsedMinutes())
+	}
+	return nil
+}
+
+// Finish prints the timeline of the last 12 hours, with | at midnight.
+func (r textReporter) Finish(rep Report) error {
+	if rep.Usage == nil {
+		return nil
+	}
+	intervals := lastHalfDay(rep.Activity.Intervals)
+	dayStartPos := len(intervals) - intervalsSinceMidnight(rep.Now)
+	var viz strings.Builder
+	for i, act := range intervals {
+		if dayStartPos >= 0 && i == dayStartPos {
+			viz.WriteString("|")
+		} else if act {
+			viz.WriteString("*")
+		} else {
+			viz.WriteString(".")
+		}
+	}
+	_, _ = fmt.Fprintf(r.w, "Timeline: %s\n", viz.String())
+	return nil
+}
+
+// lastHalfDay returns the last 48 intervals (12 hours), or all if there are
+// fewer.
+func lastHalfDay(intervals []bool) []bool {
+	return intervals[max(len(intervals)-48, 0):]
+}
+
+// historyReporter records the daily usage and the traffic samples for
+// budgets and baseline learning. The samples of all targets are written
+// together when the run is flushed.
+type historyReporter struct {
+	history *store.Store
+	samples []store.Sample
+}
+
+func (r *historyReporter) Report(rep Report) error {
+	if rep.Usage == nil {
+		return nil
+	}
+	r.samples = append(r.samples, trafficSamples(rep.Now, rep.Usage.Name, rep.Usage.MAC, rep.Traffic.Rcv, rep.Traffic.Snd)...)
+	return recordUsage(r.history, rep.Now, rep.Target.historyKey(), *rep.Usage)
+}
+
+// Flush stores the traffic samples of the run.
+func (r *historyReporter) Flush(now time.Time) error {
+	if len(r.samples) == 0 {
+		return nil
+	}
+	samples := r.samples
+	r.samples = nil
+	if err := r.history.PutSamples(samples, now); err != nil {
+		return fmt.Errorf("failed to record traffic samples: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/store"
+)
+
+// applyRewards adds today's earned-time rewards for the device on top of its
+// quota. A reward's child matches the device name, MAC address or the child
+// the policy document assigns the device to.
+func applyRewards(history *store.Store, now time.Time, usage *DeviceUsage) error {
+	rewards, err := history.Rewards("", now, now)
+	if err != nil {
+		return fmt.Errorf("failed to read rewards: %w", err)
+	}
+	for _, r := range rewards {
+		if !strings.EqualFold(r.Child, usage.Name) && fritzbox.NormalizeMAC(r.Child) != usage.MAC &&
+			(usage.Child == "" || !strings.EqualFold(r.Child, usage.Child)) {
+			continue
+		}
+		usage.BonusMinutes += r.Minutes
+		usage.Rewards = append(usage.Rewards, r)
+	}
+	usage.QuotaMinutes = max(usage.QuotaMinutes+usage.BonusMinutes, 0)
+	return nil
+}
@@ -0,0 +1,282 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Router is one Fritz!Box of a setup with several, e.g. a mesh or a second
+// household:
+//
+//	routers:
+//	  - name: home
+//	    url: http://192.168.2.1
+//	    username: home-gate
+//	    password: secret
+//	  - name: grandparents
+//	    url: https://grandparents.myfritz.net
+//	    username: home-gate
+//	    password: other-secret
+type Router struct {
+	Name     string `mapstructure:"name"`
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Client replaces the Fritz!Box client, e.g. with a fake. Optional.
+	Client fritzbox.Client `mapstructure:"-"`
+}
+
+// ValidateRouters checks that every router has a unique name and credentials
+// or a client.
+func ValidateRouters(routers []Router) error {
+	var errs []error
+	seen := make(map[string]bool)
+	for i, r := range routers {
+		name := strings.ToLower(r.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("routers[%d]: name is required", i))
+		case seen[name]:
+			errs = append(errs, fmt.Errorf("routers[%d]: duplicate name %q", i, r.Name))
+		}
+		seen[name] = true
+		if r.Client == nil && (r.Username == "" || r.Password == "") {
+			errs = append(errs, fmt.Errorf("routers[%d]: username and password are required", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// router is a connected Fritz!Box; name is empty for the single router of
+// Options.Username and Options.Password.
+type router struct {
+	name   string
+	client fritzbox.Client
+}
+
+// connectRouters connects to opts.Routers concurrently, or to the single
+// router of opts without them. Routers that fail to connect are reported,
+// returned as errors and left out; it fails only if none connects.
+func connectRouters(w io.Writer, opts Options) ([]router, []error, error) {
+	if len(opts.Routers) == 0 {
+		client, err := connect(w, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []router{{client: client}}, nil, nil
+	}
+	if err := ValidateRouters(opts.Routers); err != nil {
+		return nil, nil, err
+	}
+
+	outs := make([]bytes.Buffer, len(opts.Routers))
+	routers := make([]router, len(opts.Routers))
+	errs := make([]error, len(opts.Routers))
+	var wg sync.WaitGroup
+	for i, r := range opts.Routers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o := opts
+			o.Username, o.Password, o.URL, o.TestClient = r.Username, r.Password, r.URL, r.Client
+			client, err := connect(&outs[i], o)
+			if err != nil {
+				errs[i] = fmt.Errorf("router %s: %w", r.Name, err)
+				return
+			}
+			routers[i] = router{name: r.Name, client: client}
+		}()
+	}
+	wg.Wait()
+
+	var connected []router
+	var failed []error
+	for i, r := range opts.Routers {
+		writePrefixed(w, r.Name, outs[i].String())
+		if errs[i] != nil {
+			_, _ = fmt.Fprintf(w, "%v\n", errs[i])
+			failed = append(failed, errs[i])
+			continue
+		}
+		connected = append(connected, routers[i])
+	}
+	if len(connected) == 0 {
+		return nil, nil, errors.Join(failed...)
+	}
+	return connected, failed, nil
+}
+
+// routersCollector collects from several routers concurrently and merges
+// the collections, see mergeCollections.
+type routersCollector struct {
+	w          io.Writer
+	routers    []router
+	collectors []Collector
+	// failed holds the routers that could not be connected.
+	failed []error
+}
+
+func newRoutersCollector(w io.Writer, routers []router, failed []error, opts Options) routersCollector {
+	c := routersCollector{w: w, routers: routers, failed: failed}
+	for _, r := range routers {
+		c.collectors = append(c.collectors, fritzboxCollector{client: r.client, opts: opts})
+	}
+	return c
+}
+
+func (c routersCollector) Collect(ctx context.Context) (Collection, error) {
+	outs := make([]bytes.Buffer, len(c.routers))
+	cols := make([]Collection, len(c.routers))
+	errs := make([]error, len(c.routers))
+	var wg sync.WaitGroup
+	for i := range c.routers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector := c.collectors[i]
+			if fc, ok := collector.(fritzboxCollector); ok {
+				fc.w = &outs[i]
+				collector = fc
+			}
+			cols[i], errs[i] = collector.Collect(ctx)
+		}()
+	}
+	wg.Wait()
+
+	var ok []Collection
+	var names []string
+	failed := append([]error(nil), c.failed...)
+	for i, r := range c.routers {
+		writePrefixed(c.w, r.name, outs[i].String())
+		if errs[i] != nil {
+			err := fmt.Errorf("router %s: %w", r.name, errs[i])
+			_, _ = fmt.Fprintf(c.w, "%v\n", err)
+			failed = append(failed, err)
+			continue
+		}
+		ok = append(ok, cols[i])
+		names = append(names, r.name)
+	}
+	if len(ok) == 0 {
+		return Collection{}, errors.Join(failed...)
+	}
+	merged := mergeCollections(names, ok)
+	merged.Errors = append(merged.Errors, failed...)
+	return merged, nil
+}
+
+// mergeCollections combines the collections of several routers. A device
+// seen by more than one router, matched by MAC address or linked identity,
+// becomes one target whose traffic is summed over the routers; the other
+// routers' targets are kept in Target.Others for enforcement.
+func mergeCollections(names []string, cols []Collection) Collection {
+	merged := Collection{UserUIDs: make(map[string]string)}
+	for i, col := range cols {
+		merged.Landevices = append(merged.Landevices, col.Landevices...)
+		merged.Data = append(merged.Data, col.Data...)
+		merged.Minutes = append(merged.Minutes, col.Minutes...)
+		merged.Errors = append(merged.Errors, col.Errors...)
+		for mac, uid := range col.UserUIDs {
+			merged.UserUIDs[mac] = uid
+		}
+		if merged.Period == "" {
+			merged.Period = col.Period
+		}
+		for _, t := range col.Targets {
+			t.Router = names[i]
+			if j := matchTarget(merged.Targets, t); j >= 0 {
+				merged.Targets[j] = merged.Targets[j].merge(t)
+				continue
+			}
+			merged.Targets = append(merged.Targets, t)
+		}
+	}
+	return merged
+}
+
+// matchTarget returns the index of the target from another router that is
+// the same device as t, or -1. Devices match by a shared MAC address or the
+// same linked identity, never by name: the routers may see different devices
+// called "iPad".
+func matchTarget(targets []Target, t Target) int {
+	for i, other := range targets {
+		if other.Router == t.Router || slices.ContainsFunc(other.Others, func(o Target) bool { return o.Router == t.Router }) {
+			continue
+		}
+		if t.Identity != "" && strings.EqualFold(other.Identity, t.Identity) {
+			return i
+		}
+		for _, mac := range t.MACs {
+			if slices.Contains(other.MACs, mac) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// merge adds the same device seen by another router. A target the router
+// actually knows (with a landevice) is preferred as the primary one.
+func (t Target) merge(other Target) Target {
+	primary, secondary := t, other
+	if t.Device.UID == "" && other.Device.UID != "" {
+		primary, secondary = other, t
+		primary.Others, secondary.Others = secondary.Others, nil
+	}
+	for _, mac := range secondary.MACs {
+		if !slices.Contains(primary.MACs, mac) {
+			primary.MACs = append(primary.MACs, mac)
+		}
+	}
+	primary.Others = append(primary.Others, secondary)
+	return primary
+}
+
+// routers returns the names of the routers that saw the target.
+func (t Target) routers() []string {
+	if t.Router == "" {
+		return nil
+	}
+	names := []string{t.Router}
+	for _, o := range t.Others {
+		names = append(names, o.Router)
+	}
+	return names
+}
+
+// routersEnforcer enforces a target on every router that saw it.
+type routersEnforcer struct {
+	w         io.Writer
+	enforcers map[string]Enforcer
+}
+
+func (e routersEnforcer) Enforce(t Target, usage DeviceUsage, eval Evaluation, now time.Time) []error {
+	var errs []error
+	for _, rt := range append([]Target{t}, t.Others...) {
+		enforcer, ok := e.enforcers[rt.Router]
+		if !ok || rt.Device.UID == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(e.w, "Router %s:\n", rt.Router)
+		errs = append(errs, enforcer.Enforce(rt, usage, eval, now)...)
+	}
+	return errs
+}
+
+// writePrefixed writes each line of s to w, prefixed with the router name.
+func writePrefixed(w io.Writer, name, s string) {
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			_, _ = fmt.Fprintf(w, "[%s] %s", name, line)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package monitor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/fritzbox"
+	"home-gate/internal/fritzbox/fritzboxfakes"
+	"home-gate/internal/identity"
+	"home-gate/internal/monitor"
+	"home-gate/internal/policy"
+	"home-gate/internal/policy/policyfakes"
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Routers", func() {
+	var (
+		home, grandparents *fritzboxfakes.FakeClient
+		clock              *policyfakes.FakeClock
+		out                bytes.Buffer
+	)
+
+	// router returns a fake Fritz!Box seeing the iPad under mac, active in
+	// the intervals from..to-1 of the day's 96.
+	router := func(mac, uid string, from, to int) *fritzboxfakes.FakeClient {
+		rcv := make([]float64, 96)
+		for i := from; i < to; i++ {
+			rcv[i] = 5000
+		}
+		fake := &fritzboxfakes.FakeClient{}
+		fake.GetLandevicesReturns([]fritzbox.Landevice{{UID: uid, MAC: mac, FriendlyName: "iPad", UserUIDs: "user-" + uid}}, nil)
+		fake.GetMonitorConfigReturns(fritzbox.MonitorConfig{DisplayHomenetDevices: uid}, nil)
+		fake.GetMonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_" + fritzbox.NormalizeMAC(mac), Measurements: rcv},
+			{DataSourceName: "snd_" + fritzbox.NormalizeMAC(mac), Measurements: make([]float64, 96)},
+		}, nil)
+		return fake
+	}
+
+	BeforeEach(func() {
+		// The iPad uses a different private MAC in each network.
+		home = router("AA:11:BB:22:CC:33", "landevice1", 93, 96)
+		grandparents = router("DE:AD:BE:EF:00:01", "landevice7", 80, 82)
+		clock = &policyfakes.FakeClock{}
+		clock.NowReturns(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+		out.Reset()
+	})
+
+	options := func() monitor.Options {
+		// The iPad is linked to the same identity in both networks.
+		identities, err := identity.Load(filepath.Join(GinkgoT().TempDir(), "identities.json"))
+		Expect(err).ToNot(HaveOccurred())
+		identities.Link("Lena's iPad", fritzbox.Landevice{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"})
+		identities.Link("Lena's iPad", fritzbox.Landevice{UID: "landevice7", MAC: "DE:AD:BE:EF:00:01", FriendlyName: "iPad"})
+		return monitor.Options{
+			Identities: identities,
+			Routers: []monitor.Router{
+				{Name: "home", Username: "u", Password: "p", Client: home},
+				{Name: "grandparents", Username: "u", Password: "p", Client: grandparents},
+			},
+			Period:            "day",
+			ActivityThreshold: 100,
+			PolicyString:      "MO-SU60",
+			Enforce:           true,
+			Clock:             clock,
+			Location:          time.UTC,
+			Out:               &out,
+		}
+	}
+
+	It("sums a device's usage over the routers and blocks it on each", func() {
+		summary, err := monitor.Run(context.Background(), options())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Errors).To(BeEmpty())
+		Expect(summary.Devices).To(HaveLen(1))
+		usage := summary.Devices[0]
+		Expect(usage.DailyActiveMinutes).To(Equal(75))
+		Expect(usage.Routers).To(Equal([]string{"home", "grandparents"}))
+
+		Expect(home.BlockDeviceCallCount()).To(Equal(1))
+		uid, block := home.BlockDeviceArgsForCall(0)
+		Expect(uid).To(Equal("user-landevice1"))
+		Expect(block).To(BeTrue())
+		Expect(grandparents.BlockDeviceCallCount()).To(Equal(1))
+		uid, _ = grandparents.BlockDeviceArgsForCall(0)
+		Expect(uid).To(Equal("user-landevice7"))
+		Expect(out.String()).To(ContainSubstring("[grandparents] Connected"))
+	})
+
+	It("keeps devices apart that only share a name", func() {
+		opts := options()
+		opts.Identities = nil
+
+		summary, err := monitor.Run(context.Background(), opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(2))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(45))
+		Expect(summary.Devices[1].DailyActiveMinutes).To(Equal(30))
+	})
+
+	It("merges a device with the same MAC on both routers", func() {
+		grandparents = router("AA:11:BB:22:CC:33", "landevice7", 80, 82)
+		opts := options()
+		opts.Identities = nil
+
+		summary, err := monitor.Run(context.Background(), opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(75))
+	})
+
+	It("shares a child's quota between its devices on different routers", func() {
+		history, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		opts := options()
+		opts.Identities = nil
+		opts.History = history
+		opts.Policies = &policy.Document{Version: 1, Children: []policy.Child{{
+			Name:    "Lena",
+			Devices: []string{"AA:11:BB:22:CC:33", "DE:AD:BE:EF:00:01"},
+			Rules:   policy.Rules{Policy: "MO-SU60"},
+		}}}
+
+		summary, err := monitor.Run(context.Background(), opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(2))
+		Expect(summary.Devices[1].Child).To(Equal("Lena"))
+		Expect(summary.Devices[1].DailyActiveMinutes).To(Equal(30))
+		Expect(summary.Devices[1].SharedMinutes).To(Equal(45))
+		Expect(grandparents.BlockDeviceCallCount()).To(Equal(1))
+	})
+
+	It("counts time active on both routers once", func() {
+		grandparents = router("DE:AD:BE:EF:00:01", "landevice7", 94, 96)
+
+		summary, err := monitor.Run(context.Background(), options())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(45))
+	})
+
+	It("keeps the enforcement state per router", func() {
+		history, err := store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+		opts := options()
+		opts.History = history
+
+		_, err = monitor.Run(context.Background(), opts)
+		Expect(err).ToNot(HaveOccurred())
+		for _, key := range []string{"Lena's iPad@home", "Lena's iPad@grandparents"} {
+			st, ok, err := history.Enforcement(key)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(st.BlockedAt).ToNot(BeNil())
+		}
+		entries, err := history.Audit("Lena's iPad", time.Time{}, time.Now().AddDate(1, 0, 0))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect([]string{entries[0].Router, entries[1].Router}).To(ConsistOf("home", "grandparents"))
+	})
+
+	It("monitors the other routers when one is unreachable", func() {
+		grandparents.ConnectReturns(errors.New("timeout"))
+
+		summary, err := monitor.Run(context.Background(), options())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Errors).To(ConsistOf(MatchError(ContainSubstring("router grandparents"))))
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(45))
+		Expect(home.BlockDeviceCallCount()).To(Equal(0))
+	})
+
+	It("rejects routers without a name or credentials", func() {
+		err := monitor.ValidateRouters([]monitor.Router{{Name: "home"}, {Username: "u", Password: "p"}, {Name: "HOME", Username: "u", Password: "p"}})
+		Expect(err).To(MatchError(ContainSubstring("routers[0]: username and password are required")))
+		Expect(err).To(MatchError(ContainSubstring("routers[1]: name is required")))
+		Expect(err).To(MatchError(ContainSubstring(`routers[2]: duplicate name "HOME"`)))
+	})
+
+	It("accepts routers with a client instead of credentials", func() {
+		Expect(monitor.ValidateRouters([]monitor.Router{{Name: "home", Client: &fritzboxfakes.FakeClient{}}})).To(Succeed())
+	})
+})
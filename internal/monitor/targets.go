@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Target is a device to monitor. A device that rotated its private MAC has
+// several landevices, whose measurements are combined.
+type Target struct {
+	Name string
+	// Identity is the name of the linked identity the device belongs to, if
+	// any.
+	Identity string
+	// MACs holds the normalized MAC addresses, the primary (active) one first.
+	MACs   []string
+	Device fritzbox.Landevice
+	// UserUID is the Fritz!Box user the device is blocked through, if any.
+	UserUID string
+	// Router is the name of the router that saw the device, empty with a
+	// single router. Others holds the same device seen by other routers;
+	// their traffic is included in the target's.
+	Router string
+	Others []Target
+}
+
+// keys returns the names, UID and MACs policies and classifiers match on.
+func (t Target) keys() []string {
+	return append([]string{t.Name, t.Device.FriendlyName, t.Device.UID}, t.MACs...)
+}
+
+// historyKey returns the key the target's usage history and enforcement
+// state are stored under: the identity name, or else the primary normalized
+// MAC address. Unlike Name it is the same whether the device was addressed
+// by --mac or found in the Fritz!Box monitor.
+func (t Target) historyKey() string {
+	if t.Identity != "" {
+		return t.Identity
+	}
+	return t.MACs[0]
+}
+
+// selectTargets resolves the devices to monitor: the device addressed by
+// opts.Mac (a MAC, landevice UID or identity name), or otherwise every device
+// configured in the Fritz!Box monitor, grouped by identity.
+func selectTargets(w io.Writer, opts Options, landevices []fritzbox.Landevice, config fritzbox.MonitorConfig) []Target {
+	if opts.Mac != "" {
+		identity, matches := opts.Identities.Resolve(landevices, opts.Mac)
+		if len(matches) == 0 {
+			return []Target{{Name: opts.Mac, MACs: []string{fritzbox.NormalizeMAC(opts.Mac)}}}
+		}
+		name := identity
+		if name == "" {
+			name = opts.Mac
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].IsActive() && !matches[j].IsActive()
+		})
+		t := Target{Name: name, Identity: identity, Device: matches[0]}
+		for _, dev := range matches {
+			t.MACs = append(t.MACs, fritzbox.NormalizeMAC(dev.MAC))
+		}
+		if len(matches) > 1 {
+			_, _ = fmt.Fprintf(w, "Resolved %s to MACs %v\n", name, t.MACs)
+		}
+		return []Target{t}
+	}
+
+	_, _ = fmt.Fprintln(w, "No MAC specified, fetching configured devices")
+	uids := strings.Split(config.DisplayHomenetDevices, ",")
+	_, _ = fmt.Fprintf(w, "Configured UIDs: %v\n", uids)
+	var targets []Target
+	byIdentity := make(map[string]int)
+	for _, uid := range uids {
+		for _, dev := range landevices {
+			if dev.UID != uid {
+				continue
+			}
+			normalizedMac := fritzbox.NormalizeMAC(dev.MAC)
+			if id, ok := opts.Identities.Lookup(dev); ok {
+				if idx, seen := byIdentity[id.Name]; seen {
+					if dev.IsActive() && !targets[idx].Device.IsActive() {
+						targets[idx].Device = dev
+						targets[idx].MACs = append([]string{normalizedMac}, targets[idx].MACs...)
+					} else {
+						targets[idx].MACs = append(targets[idx].MACs, normalizedMac)
+					}
+					_, _ = fmt.Fprintf(w, "Linked device: %s (%s) to %s\n", dev.FriendlyName, normalizedMac, id.Name)
+					break
+				}
+				byIdentity[id.Name] = len(targets)
+				targets = append(targets, Target{Name: id.Name, Identity: id.Name, MACs: []string{normalizedMac}, Device: dev})
+			} else {
+				targets = append(targets, Target{Name: dev.FriendlyName, MACs: []string{normalizedMac}, Device: dev})
+			}
+			_, _ = fmt.Fprintf(w, "Added device: %s (%s)\n", dev.FriendlyName, normalizedMac)
+			break
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Total target devices: %d\n", len(targets))
+
+	if opts.Identities != nil {
+		for _, dev := range opts.Identities.Unlinked(landevices) {
+			_, _ = fmt.Fprintf(w, "New randomized MAC: %s (%s, %s), link it with 'home-gate devices link'\n",
+				dev.FriendlyName, dev.MAC, dev.UID)
+		}
+	}
+	return targets
+}
+
+// measurementsFor sums the rcv/snd measurements of all the target's MACs,
+// aligned on the most recent interval. It returns nil slices if none of the
+// MACs appear in the data.
+func measurementsFor(response []fritzbox.SubsetData, macs []string) (rcv, snd []float64) {
+	for _, mac := range macs {
+		for _, sd := range response {
+			if !strings.HasSuffix(sd.DataSourceName, mac) {
+				continue
+			}
+			if strings.HasPrefix(sd.DataSourceName, "rcv_") {
+				rcv = addAligned(rcv, sd.Measurements)
+			} else if strings.HasPrefix(sd.DataSourceName, "snd_") {
+				snd = addAligned(snd, sd.Measurements)
+			}
+		}
+	}
+	return rcv, snd
+}
+
+// addAligned adds src to dst element-wise, aligning both on their last element.
+func addAligned(dst, src []float64) []float64 {
+	if dst == nil {
+		return append([]float64{}, src...)
+	}
+	if len(src) > len(dst) {
+		grown := make([]float64, len(src))
+		copy(grown[len(src)-len(dst):], dst)
+		dst = grown
+	}
+	offset := len(dst) - len(src)
+	for i, v := range src {
+		dst[offset+i] += v
+	}
+	return dst
+}
@@ -0,0 +1,83 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is the time span a budget applies to.
+type Period string
+
+const (
+	PeriodWeek  Period = "WEEK"
+	PeriodMonth Period = "MONTH"
+)
+
+// Budget limits the total minutes over a week (starting Monday) or a calendar
+// month, on top of the daily rules, e.g. "WEEK600".
+type Budget struct {
+	Period  Period
+	Minutes int
+	// Pos is the byte offset of the budget in the policy string.
+	Pos int
+}
+
+// String formats the budget in policy string notation.
+func (b Budget) String() string {
+	return fmt.Sprintf("%s%d", b.Period, b.Minutes)
+}
+
+// Usage is the number of minutes used earlier in the current week and month,
+// not counting today, and the banked minutes available today.
+type Usage struct {
+	Week  int
+	Month int
+	Bank  int
+}
+
+// Budget returns the budget for the period, if the policy defines one.
+func (pm *PolicyManager) Budget(period Period) (Budget, bool) {
+	for _, b := range pm.budgets {
+		if b.Period == period {
+			return b, true
+		}
+	}
+	return Budget{}, false
+}
+
+// PeriodStart returns the start of the current week (Monday) or month.
+func (pm *PolicyManager) PeriodStart(period Period) time.Time {
+	now := pm.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if period == PeriodMonth {
+		return today.AddDate(0, 0, 1-today.Day())
+	}
+	return today.AddDate(0, 0, -dayIndex(today.Weekday()))
+}
+
+// Remaining returns the minutes left in the period's budget after the given
+// total usage, or false if the policy has no budget for the period.
+func (pm *PolicyManager) Remaining(period Period, used int) (int, bool) {
+	b, ok := pm.Budget(period)
+	if !ok {
+		return 0, false
+	}
+	return max(b.Minutes-used, 0), true
+}
+
+// AllowedTodayWithUsage returns today's quota: the daily rule plus the banked
+// minutes, capped by what is left of the weekly and monthly budgets. A policy
+// consisting of budgets only has no daily cap.
+func (pm *PolicyManager) AllowedTodayWithUsage(usage Usage) int {
+	allowed := pm.getTodayAllowed() + usage.Bank
+	if _, overridden := pm.TodayOverride(); len(pm.rules) == 0 && !overridden {
+		allowed = 24 * 60
+	}
+	if left, ok := pm.Remaining(PeriodWeek, usage.Week); ok {
+		allowed = min(allowed, left)
+	}
+	if left, ok := pm.Remaining(PeriodMonth, usage.Month); ok {
+		allowed = min(allowed, left)
+	}
+	return allowed
+}
@@ -0,0 +1,366 @@
+package policy
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+	"home-gate/internal/calendar"
+)
+
+// DocumentVersion is the version of the policy document format.
+const DocumentVersion = 1
+
+// Schema is the JSON Schema of the policy document.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Document is the structured policy format, loaded from YAML or JSON (see
+// schema.json). The compact policy string converts into a document with
+// default rules only (FromString).
+type Document struct {
+	Version int `mapstructure:"version" json:"version"`
+	// Default applies to monitored devices that belong to no child.
+	Default  *Rules  `mapstructure:"default" json:"default,omitempty"`
+	Children []Child `mapstructure:"children" json:"children,omitempty"`
+}
+
+// Child groups the devices of one child under shared rules.
+type Child struct {
+	Name string `mapstructure:"name" json:"name"`
+	// Devices are device names, linked device names, landevice UIDs or MACs.
+	Devices []string `mapstructure:"devices" json:"devices"`
+	Rules   `mapstructure:",squash"`
+}
+
+// Rules are the limits, time windows and exceptions applying to a device.
+type Rules struct {
+	// Policy is the compact policy string shorthand, e.g. "MO-FR90SA-SU180".
+	// It is combined with Limits.
+	Policy     string      `mapstructure:"policy" json:"policy,omitempty"`
+	Limits     Limits      `mapstructure:"limits" json:"limits,omitempty"`
+	Windows    []Window    `mapstructure:"windows" json:"windows,omitempty"`
+	Exceptions []Exception `mapstructure:"exceptions" json:"exceptions,omitempty"`
+}
+
+// Limits are the daily minutes per day or day range, plus budgets and rollover.
+type Limits struct {
+	Days     map[string]int `mapstructure:"days" json:"days,omitempty"`
+	Week     int            `mapstructure:"week" json:"week,omitempty"`
+	Month    int            `mapstructure:"month" json:"month,omitempty"`
+	Rollover *RolloverSpec  `mapstructure:"rollover" json:"rollover,omitempty"`
+}
+
+// RolloverSpec is the document form of a ROLLOVER rule.
+type RolloverSpec struct {
+	Minutes int `mapstructure:"minutes" json:"minutes"`
+	Days    int `mapstructure:"days" json:"days,omitempty"`
+}
+
+// Window is a time of day during which devices may be used, e.g. 07:00-20:00
+// on MO-FR. Outside the windows covering a day, devices are blocked; days no
+// window covers are unrestricted.
+type Window struct {
+	Days string `mapstructure:"days" json:"days"`
+	From string `mapstructure:"from" json:"from"`
+	To   string `mapstructure:"to" json:"to"`
+}
+
+// Exception switches to another policy string on a date range or on the
+// days of the events of an iCalendar file.
+type Exception struct {
+	From     string `mapstructure:"from" json:"from,omitempty"`
+	To       string `mapstructure:"to" json:"to,omitempty"`
+	Calendar string `mapstructure:"calendar" json:"calendar,omitempty"`
+	Policy   string `mapstructure:"policy" json:"policy"`
+	Reason   string `mapstructure:"reason" json:"reason,omitempty"`
+}
+
+// FromString converts a compact policy string into a document applying it to
+// all monitored devices.
+func FromString(policyStr string) (*Document, error) {
+	doc := &Document{Version: DocumentVersion, Default: &Rules{Policy: policyStr}}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Validate checks the whole document and reports every problem found, each
+// prefixed with its location in the document.
+func (d *Document) Validate() error {
+	var errs []error
+	addErr := func(path string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %w", path, err))
+	}
+	switch {
+	case d.Version == 0:
+		addErr("version", errors.New("missing, expected 1"))
+	case d.Version != DocumentVersion:
+		addErr("version", fmt.Errorf("unsupported version %d, expected %d", d.Version, DocumentVersion))
+	}
+	if d.Default == nil && len(d.Children) == 0 {
+		errs = append(errs, errors.New("document defines neither default rules nor children"))
+	}
+	if d.Default != nil {
+		for _, err := range d.Default.validate() {
+			addErr("default", err)
+		}
+	}
+	names := make(map[string]bool)
+	owners := make(map[string]string)
+	for i, c := range d.Children {
+		path := fmt.Sprintf("children[%d]", i)
+		if strings.TrimSpace(c.Name) == "" {
+			addErr(path+".name", errors.New("is required"))
+		} else if names[strings.ToLower(c.Name)] {
+			addErr(path+".name", fmt.Errorf("duplicate child %q", c.Name))
+		}
+		names[strings.ToLower(c.Name)] = true
+		if len(c.Devices) == 0 {
+			addErr(path+".devices", errors.New("at least one device is required"))
+		}
+		for _, dev := range c.Devices {
+			key := deviceKey(dev)
+			if owner, ok := owners[key]; ok {
+				addErr(path+".devices", fmt.Errorf("device %q already belongs to %s", dev, owner))
+			}
+			owners[key] = c.Name
+		}
+		for _, err := range c.validate() {
+			addErr(path, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rules) validate() []error {
+	var errs []error
+	if limits := r.Compact(); limits != "" {
+		if _, err := parse(limits); err != nil {
+			errs = append(errs, fmt.Errorf("limits %q: %w", limits, err))
+		}
+	} else if len(r.Windows) == 0 && len(r.Exceptions) == 0 {
+		errs = append(errs, errors.New("limits, windows or exceptions are required"))
+	}
+	for i, w := range r.Windows {
+		if _, err := w.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("windows[%d]: %w", i, err))
+		}
+	}
+	for i, e := range r.Exceptions {
+		if err := e.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("exceptions[%d]: %w", i, err))
+		}
+	}
+	return errs
+}
+
+// Compact renders the policy shorthand and limits as one policy string.
+func (r Rules) Compact() string {
+	parts := []string{}
+	if r.Policy != "" {
+		parts = append(parts, r.Policy)
+	}
+	keys := make([]string, 0, len(r.Limits.Days))
+	for k := range r.Limits.Days {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s%d", strings.ToUpper(k), r.Limits.Days[k]))
+	}
+	if r.Limits.Week > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d", PeriodWeek, r.Limits.Week))
+	}
+	if r.Limits.Month > 0 {
+		parts = append(parts, fmt.Sprintf("%s%d", PeriodMonth, r.Limits.Month))
+	}
+	if ro := r.Limits.Rollover; ro != nil {
+		days := ro.Days
+		if days == 0 {
+			days = defaultRolloverDays
+		}
+		parts = append(parts, fmt.Sprintf("%s%dD%d", rolloverKeyword, ro.Minutes, days))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (e Exception) validate() error {
+	if e.Policy == "" {
+		return errors.New("policy is required")
+	}
+	if e.Calendar != "" {
+		if e.From != "" || e.To != "" {
+			return errors.New("use either calendar or from/to")
+		}
+		_, err := calendar.LoadFile(e.Calendar, time.Local)
+		return err
+	}
+	_, err := e.overrides(time.Local)
+	return err
+}
+
+// overrides converts the exception into policy overrides.
+func (e Exception) overrides(loc *time.Location) ([]Override, error) {
+	if e.Calendar != "" {
+		events, err := calendar.LoadFile(e.Calendar, loc)
+		if err != nil {
+			return nil, err
+		}
+		var overrides []Override
+		for _, ev := range events {
+			from, to := ev.DaysIn(loc)
+			reason := ev.Summary
+			if e.Reason != "" {
+				reason = e.Reason + ": " + ev.Summary
+			}
+			o, err := NewOverride(from, to, e.Policy, reason)
+			if err != nil {
+				return nil, err
+			}
+			overrides = append(overrides, o)
+		}
+		return overrides, nil
+	}
+	if e.From == "" {
+		return nil, errors.New("from (or calendar) is required")
+	}
+	from, err := time.ParseInLocation(dateFormat, e.From, loc)
+	if err != nil {
+		return nil, fmt.Errorf("from: %w", err)
+	}
+	to := from
+	if e.To != "" {
+		if to, err = time.ParseInLocation(dateFormat, e.To, loc); err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+	}
+	reason := e.Reason
+	if reason == "" {
+		reason = e.From + ".." + to.Format(dateFormat)
+	}
+	o, err := NewOverride(from, to, e.Policy, reason)
+	if err != nil {
+		return nil, err
+	}
+	return []Override{o}, nil
+}
+
+// RulesFor returns the child a device belongs to and the rules applying to
+// it. Any of the device's keys (name, linked name, UID, MAC) may match. Devices
+// of no child get the default rules; ok is false if there are none.
+func (d *Document) RulesFor(keys ...string) (child string, rules Rules, ok bool) {
+	for _, c := range d.Children {
+		for _, dev := range c.Devices {
+			for _, key := range keys {
+				if key != "" && deviceKey(dev) == deviceKey(key) {
+					return c.Name, c.Rules, true
+				}
+			}
+		}
+	}
+	if d.Default != nil {
+		return "", *d.Default, true
+	}
+	return "", Rules{}, false
+}
+
+// unlimitedPolicy allows the whole day to rules without limits, which are
+// restricted by their windows and exceptions only.
+const unlimitedPolicy = "MO-SU1440"
+
+// Manager creates a policy manager for the rules evaluated in loc, which also
+// applies to the dates of exceptions.
+func (r Rules) Manager(clock Clock, loc *time.Location) (*PolicyManager, error) {
+	limits := r.Compact()
+	if limits == "" {
+		limits = unlimitedPolicy
+	}
+	pm, err := NewPolicyManagerWithClock(limits, clock)
+	if err != nil {
+		return nil, err
+	}
+	pm.SetLocation(loc)
+	for _, w := range r.Windows {
+		cw, err := w.compile()
+		if err != nil {
+			return nil, err
+		}
+		pm.windows = append(pm.windows, cw)
+	}
+	for _, e := range r.Exceptions {
+		overrides, err := e.overrides(loc)
+		if err != nil {
+			return nil, err
+		}
+		pm.AddOverrides(overrides...)
+	}
+	return pm, nil
+}
+
+// deviceKey normalizes device references: MAC addresses lose their colons,
+// and everything is compared case-insensitively.
+func deviceKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if stripped := strings.ReplaceAll(s, ":", ""); len(stripped) == 12 && strings.Count(s, ":") == 5 {
+		return stripped
+	}
+	return s
+}
+
+// LoadDocument reads a YAML or JSON policy document (by file extension) and
+// validates it.
+func LoadDocument(path string) (*Document, error) {
+	return LoadSection(path, "")
+}
+
+// LoadSection reads the policy document under key of the YAML or JSON file
+// at path, e.g. the "policies" section of a config file.
+func LoadSection(path, key string) (*Document, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read policy document: %w", err)
+	}
+	return DecodeDocument(v, key)
+}
+
+// DecodeDocument decodes the policy document under key (the whole
+// configuration if key is empty) and validates it. Unknown fields are errors.
+func DecodeDocument(v *viper.Viper, key string) (*Document, error) {
+	var doc Document
+	opts := []viper.DecoderConfigOption{
+		viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(dateToStringHook, mapstructure.StringToTimeDurationHookFunc())),
+		func(c *mapstructure.DecoderConfig) { c.ErrorUnused = true },
+	}
+	var err error
+	if key == "" {
+		err = v.Unmarshal(&doc, opts...)
+	} else {
+		err = v.UnmarshalKey(key, &doc, opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy document: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy document: %w", err)
+	}
+	return &doc, nil
+}
+
+// dateToStringHook keeps unquoted YAML dates (decoded as timestamps) usable
+// for the string date fields of exceptions.
+func dateToStringHook(from, to reflect.Type, data any) (any, error) {
+	if t, ok := data.(time.Time); ok && to.Kind() == reflect.String {
+		return t.Format(dateFormat), nil
+	}
+	return data, nil
+}
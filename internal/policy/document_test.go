@@ -0,0 +1,172 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/policy"
+	"home-gate/internal/policy/policyfakes"
+)
+
+var _ = Describe("Document", func() {
+	write := func(name, content string) string {
+		path := filepath.Join(GinkgoT().TempDir(), name)
+		Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+		return path
+	}
+
+	const familyYAML = `
+version: 1
+default:
+  policy: MO-SU60
+children:
+  - name: Anna
+    devices: [Anna-Phone, "AA:BB:CC:DD:EE:FF"]
+    limits:
+      days:
+        MO-FR: 90
+        SA-SU: 180
+      week: 600
+      rollover:
+        minutes: 30
+    windows:
+      - days: MO-FR
+        from: "07:00"
+        to: "20:00"
+    exceptions:
+      - from: 2026-12-24
+        to: 2027-01-06
+        policy: MO-SU240
+        reason: Christmas
+`
+
+	It("loads and validates a YAML document", func() {
+		doc, err := policy.LoadDocument(write("policy.yaml", familyYAML))
+		Expect(err).To(BeNil())
+		Expect(doc.Version).To(Equal(1))
+		Expect(doc.Children).To(HaveLen(1))
+		Expect(doc.Children[0].Compact()).To(Equal("MO-FR90 SA-SU180 WEEK600 ROLLOVER30D7"))
+		Expect(doc.Children[0].Exceptions[0].From).To(Equal("2026-12-24"))
+	})
+
+	It("loads a JSON document", func() {
+		doc, err := policy.LoadDocument(write("policy.json", `{"version": 1, "default": {"limits": {"days": {"MO-SU": 45}}}}`))
+		Expect(err).To(BeNil())
+		Expect(doc.Default.Compact()).To(Equal("MO-SU45"))
+	})
+
+	It("reports every problem with its location", func() {
+		_, err := policy.LoadDocument(write("policy.yaml", `
+version: 2
+children:
+  - name: Anna
+    devices: [tablet]
+    policy: MO-XX90
+    windows:
+      - {days: MO-FR, from: "20:00", to: "07:00"}
+  - name: anna
+    devices: [Tablet]
+    exceptions:
+      - {policy: MO-SU90}
+`))
+		Expect(err).To(MatchError(ContainSubstring("version: unsupported version 2")))
+		Expect(err).To(MatchError(ContainSubstring(`children[0]: limits "MO-XX90": position 3: unknown day code "XX"`)))
+		Expect(err).To(MatchError(ContainSubstring("children[0]: windows[0]: window 20:00-07:00 ends before it starts")))
+		Expect(err).To(MatchError(ContainSubstring(`children[1].name: duplicate child "anna"`)))
+		Expect(err).To(MatchError(ContainSubstring(`children[1].devices: device "Tablet" already belongs to Anna`)))
+		Expect(err).To(MatchError(ContainSubstring("children[1]: exceptions[0]: from (or calendar) is required")))
+	})
+
+	It("accepts rules restricted by time windows only", func() {
+		_, err := policy.LoadDocument(write("policy.yaml", `
+version: 1
+children:
+  - name: Ben
+    devices: [Ben-Switch]
+    windows:
+      - {days: MO-SU, from: "15:00", to: "18:00"}
+  - name: Clara
+    devices: [Clara-Phone]
+`))
+		Expect(err).To(MatchError(ContainSubstring("children[1]: limits, windows or exceptions are required")))
+		Expect(err).NotTo(MatchError(ContainSubstring("children[0]")))
+
+		doc, err := policy.LoadDocument(write("policy.yaml", `
+version: 1
+children:
+  - name: Ben
+    devices: [Ben-Switch]
+    windows:
+      - {days: MO-SU, from: "15:00", to: "18:00"}
+`))
+		Expect(err).To(BeNil())
+		fakeClock := &policyfakes.FakeClock{}
+		fakeClock.NowReturns(time.Date(2026, 10, 14, 16, 0, 0, 0, time.Local))
+		pm, err := doc.Children[0].Manager(fakeClock, time.Local)
+		Expect(err).To(BeNil())
+		Expect(pm.AllowedToday()).To(Equal(24 * 60))
+		Expect(pm.InWindow(time.Date(2026, 10, 14, 14, 59, 0, 0, time.Local))).To(BeFalse())
+		Expect(pm.InWindow(time.Date(2026, 10, 14, 16, 0, 0, 0, time.Local))).To(BeTrue())
+	})
+
+	It("rejects unknown fields", func() {
+		_, err := policy.LoadDocument(write("policy.yaml", "version: 1\ndefault:\n  polcy: MO-SU60\n"))
+		Expect(err).To(MatchError(ContainSubstring("polcy")))
+	})
+
+	It("converts the policy string shorthand", func() {
+		doc, err := policy.FromString("MO-FR90SA-SU180")
+		Expect(err).To(BeNil())
+		child, rules, ok := doc.RulesFor("any device")
+		Expect(ok).To(BeTrue())
+		Expect(child).To(BeEmpty())
+		Expect(rules.Compact()).To(Equal("MO-FR90SA-SU180"))
+
+		_, err = policy.FromString("MO-FR")
+		Expect(err).To(MatchError(ContainSubstring("expected allowed minutes")))
+	})
+
+	It("finds the child of a device by any key", func() {
+		doc, err := policy.LoadDocument(write("policy.yaml", familyYAML))
+		Expect(err).To(BeNil())
+		child, _, ok := doc.RulesFor("Unknown", "aabbccddeeff")
+		Expect(ok).To(BeTrue())
+		Expect(child).To(Equal("Anna"))
+		child, rules, ok := doc.RulesFor("Laptop")
+		Expect(ok).To(BeTrue())
+		Expect(child).To(BeEmpty())
+		Expect(rules.Policy).To(Equal("MO-SU60"))
+	})
+
+	Describe("Manager", func() {
+		var fakeClock *policyfakes.FakeClock
+		var pm *policy.PolicyManager
+
+		BeforeEach(func() {
+			doc, err := policy.LoadDocument(write("policy.yaml", familyYAML))
+			Expect(err).To(BeNil())
+			fakeClock = &policyfakes.FakeClock{}
+			fakeClock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)) // Wednesday
+			pm, err = doc.Children[0].Manager(fakeClock, time.Local)
+			Expect(err).To(BeNil())
+		})
+
+		It("applies limits and exceptions", func() {
+			Expect(pm.AllowedToday()).To(Equal(90))
+			fakeClock.NowReturns(time.Date(2026, 12, 28, 12, 0, 0, 0, time.Local))
+			Expect(pm.AllowedToday()).To(Equal(240))
+		})
+
+		It("restricts usage to the time windows", func() {
+			Expect(pm.InWindow(time.Date(2026, 10, 14, 6, 59, 0, 0, time.Local))).To(BeFalse())
+			Expect(pm.InWindow(time.Date(2026, 10, 14, 7, 0, 0, 0, time.Local))).To(BeTrue())
+			Expect(pm.InWindow(time.Date(2026, 10, 14, 20, 0, 0, 0, time.Local))).To(BeFalse())
+			// Weekends have no window and are unrestricted.
+			Expect(pm.InWindow(time.Date(2026, 10, 17, 23, 0, 0, 0, time.Local))).To(BeTrue())
+		})
+	})
+})
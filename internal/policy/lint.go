@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity of a lint issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found while linting a policy string.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Pos      int      `json:"pos"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: position %d: %s", i.Severity, i.Pos, i.Message)
+}
+
+// Lint validates a policy string. Besides parse errors it warns about days
+// covered by several rules (reporting which one takes precedence), about
+// days no rule covers, which default to 0 minutes and are fully blocked, and
+// about budgets that can never be reached because of the daily limits.
+func Lint(policyStr string) []Issue {
+	def, err := parse(policyStr)
+	if err != nil {
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			return []Issue{{Severity: SeverityError, Pos: perr.Pos, Message: perr.Msg}}
+		}
+		return []Issue{{Severity: SeverityError, Message: err.Error()}}
+	}
+
+	rules := def.rules
+	var issues []Issue
+	for i, r := range rules {
+		for _, other := range rules[i+1:] {
+			var shared []string
+			for d := range days {
+				if r.covers(d) && other.covers(d) {
+					shared = append(shared, days[d])
+				}
+			}
+			if len(shared) == 0 {
+				continue
+			}
+			winner := r
+			if other.precedes(r) {
+				winner = other
+			}
+			issues = append(issues, Issue{
+				Severity: SeverityWarning,
+				Pos:      other.Pos,
+				Message: fmt.Sprintf("%s overlaps %s on %s, %s takes precedence",
+					other, r, strings.Join(shared, ", "), winner),
+			})
+		}
+	}
+
+	var missing []string
+	for d := range days {
+		if _, ok := ruleFor(rules, d); !ok {
+			missing = append(missing, days[d])
+		}
+	}
+	if len(missing) > 0 && len(rules) > 0 {
+		issues = append(issues, Issue{
+			Severity: SeverityWarning,
+			Pos:      len(policyStr),
+			Message:  fmt.Sprintf("no rule for %s, these days default to 0 minutes (fully blocked)", strings.Join(missing, ", ")),
+		})
+	}
+	for _, b := range def.budgets {
+		if len(rules) == 0 {
+			continue
+		}
+		maxDays := 7
+		if b.Period == PeriodMonth {
+			maxDays = 31
+		}
+		possible := 0
+		for d := 0; d < maxDays; d++ {
+			if r, ok := ruleFor(rules, d%7); ok {
+				possible += r.Minutes
+			}
+		}
+		if possible <= b.Minutes {
+			issues = append(issues, Issue{
+				Severity: SeverityWarning,
+				Pos:      b.Pos,
+				Message:  fmt.Sprintf("%s budget is never reached, the daily limits allow at most %d minutes", b, possible),
+			})
+		}
+	}
+	return issues
+}
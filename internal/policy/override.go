@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Override replaces the daily rules of the policy on the days from From to
+// To (inclusive), e.g. weekend limits every day during school holidays.
+// Budgets keep applying.
+type Override struct {
+	From   time.Time
+	To     time.Time
+	Reason string
+	Policy string
+
+	rules []Rule
+}
+
+// NewOverride creates an override applying policyStr on the days from..to.
+func NewOverride(from, to time.Time, policyStr, reason string) (Override, error) {
+	def, err := parse(policyStr)
+	if err != nil {
+		return Override{}, fmt.Errorf("override policy %q: %w", policyStr, err)
+	}
+	if len(def.rules) == 0 {
+		return Override{}, fmt.Errorf("override policy %q has no daily rules", policyStr)
+	}
+	if to.Before(from) {
+		return Override{}, fmt.Errorf("override ends (%s) before it starts (%s)", to.Format(dateFormat), from.Format(dateFormat))
+	}
+	return Override{From: from, To: to, Reason: reason, Policy: policyStr, rules: def.rules}, nil
+}
+
+const dateFormat = "2006-01-02"
+
+// ParseOverride parses "FROM[..TO]=POLICY", e.g. "2026-12-24..2027-01-06=MO-SU180".
+// Dates are interpreted in loc.
+func ParseOverride(spec string, loc *time.Location) (Override, error) {
+	dates, policyStr, ok := strings.Cut(spec, "=")
+	if !ok {
+		return Override{}, fmt.Errorf("override %q: expected FROM[..TO]=POLICY", spec)
+	}
+	fromStr, toStr, isRange := strings.Cut(dates, "..")
+	if !isRange {
+		toStr = fromStr
+	}
+	from, err := time.ParseInLocation(dateFormat, strings.TrimSpace(fromStr), loc)
+	if err != nil {
+		return Override{}, fmt.Errorf("override %q: %w", spec, err)
+	}
+	to, err := time.ParseInLocation(dateFormat, strings.TrimSpace(toStr), loc)
+	if err != nil {
+		return Override{}, fmt.Errorf("override %q: %w", spec, err)
+	}
+	return NewOverride(from, to, strings.TrimSpace(policyStr), dates)
+}
+
+// Covers reports whether the override applies on the day of t.
+func (o Override) Covers(t time.Time) bool {
+	day := t.In(o.From.Location()).Format(dateFormat)
+	return day >= o.From.Format(dateFormat) && day <= o.To.In(o.From.Location()).Format(dateFormat)
+}
+
+// days returns the number of days the override spans.
+func (o Override) days() int {
+	return int(math.Round(o.To.Sub(o.From).Hours()/24)) + 1
+}
+
+// AddOverrides registers date-based overrides. When several cover the same
+// day, the shortest one wins, so a single-day event beats a holiday period;
+// between equally long overrides the one added last wins.
+func (pm *PolicyManager) AddOverrides(overrides ...Override) {
+	pm.overrides = append(pm.overrides, overrides...)
+}
+
+// TodayOverride returns the override in effect today, if any.
+func (pm *PolicyManager) TodayOverride() (Override, bool) {
+	now := pm.now()
+	var best Override
+	found := false
+	for _, o := range pm.overrides {
+		if o.Covers(now) && (!found || o.days() <= best.days()) {
+			best = o
+			found = true
+		}
+	}
+	return best, found
+}
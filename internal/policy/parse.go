@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseError reports a malformed policy string and where it went wrong.
+type ParseError struct {
+	// Pos is the byte offset in the policy string.
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// parser is a small scanner for policy strings such as "MO-TH90FR120SA-SU180".
+// Entries may be separated by whitespace or commas. Besides day rules a policy
+// may hold WEEK and MONTH budgets, e.g. "MO-SU180WEEK600", and a ROLLOVER
+// rule, e.g. "ROLLOVER30D7".
+type parser struct {
+	input string
+	pos   int
+}
+
+// definition is a parsed policy string.
+type definition struct {
+	rules    []Rule
+	budgets  []Budget
+	rollover *Rollover
+}
+
+func parse(policyStr string) (definition, error) {
+	p := &parser{input: policyStr}
+	var def definition
+	for {
+		p.skipSeparators()
+		if p.done() {
+			break
+		}
+		if strings.HasPrefix(p.input[p.pos:], rolloverKeyword) {
+			start := p.pos
+			if def.rollover != nil {
+				return definition{}, &ParseError{Pos: start, Msg: "duplicate rollover rule"}
+			}
+			rollover, err := p.rollover()
+			if err != nil {
+				return definition{}, err
+			}
+			def.rollover = &rollover
+			continue
+		}
+		if period, ok := p.budgetPeriod(); ok {
+			start := p.pos
+			p.pos += len(period)
+			minutes, err := p.number()
+			if err != nil {
+				return definition{}, err
+			}
+			for _, b := range def.budgets {
+				if b.Period == period {
+					return definition{}, &ParseError{Pos: start, Msg: fmt.Sprintf("duplicate %s budget", period)}
+				}
+			}
+			def.budgets = append(def.budgets, Budget{Period: period, Minutes: minutes, Pos: start})
+			continue
+		}
+		rule, err := p.rule()
+		if err != nil {
+			return definition{}, err
+		}
+		def.rules = append(def.rules, rule)
+	}
+	if len(def.rules) == 0 && len(def.budgets) == 0 {
+		return definition{}, &ParseError{Pos: 0, Msg: "no valid policy entries found"}
+	}
+	return def, nil
+}
+
+// rollover parses ROLLOVER MINUTES ["D" DAYS].
+func (p *parser) rollover() (Rollover, error) {
+	r := Rollover{Days: defaultRolloverDays, Pos: p.pos}
+	p.pos += len(rolloverKeyword)
+	minutes, err := p.minutes()
+	if err != nil {
+		return Rollover{}, err
+	}
+	r.Minutes = minutes
+	if !p.done() && p.input[p.pos] == 'D' {
+		p.pos++
+		start := p.pos
+		if r.Days, err = p.number(); err != nil {
+			return Rollover{}, err
+		}
+		if r.Days == 0 {
+			return Rollover{}, &ParseError{Pos: start, Msg: "rollover must last at least 1 day"}
+		}
+	}
+	return r, nil
+}
+
+// budgetPeriod checks whether a budget entry (WEEK or MONTH) starts at the
+// current position. WEEK is checked before the day codes since it starts like WE.
+func (p *parser) budgetPeriod() (Period, bool) {
+	for _, period := range []Period{PeriodWeek, PeriodMonth} {
+		if strings.HasPrefix(p.input[p.pos:], string(period)) {
+			return period, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSeparators() {
+	for !p.done() && strings.ContainsRune(" \t\n,", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// rule parses DAY ["-" DAY] MINUTES.
+func (p *parser) rule() (Rule, error) {
+	start := p.pos
+	first, err := p.day()
+	if err != nil {
+		return Rule{}, err
+	}
+	last := first
+	if !p.done() && p.input[p.pos] == '-' {
+		p.pos++
+		if last, err = p.day(); err != nil {
+			return Rule{}, err
+		}
+		if last < first {
+			return Rule{}, &ParseError{Pos: start, Msg: fmt.Sprintf("range %s-%s ends before it starts", days[first], days[last])}
+		}
+	}
+	daysEnd := p.pos
+	minutes, err := p.minutes()
+	if err != nil {
+		return Rule{}, err
+	}
+	return Rule{Days: p.input[start:daysEnd], Minutes: minutes, Pos: start, first: first, last: last}, nil
+}
+
+func (p *parser) day() (int, error) {
+	if p.pos+2 > len(p.input) {
+		return 0, &ParseError{Pos: p.pos, Msg: "expected a day code (MO, TU, WE, TH, FR, SA, SU)"}
+	}
+	code := p.input[p.pos : p.pos+2]
+	for i, d := range days {
+		if d == code {
+			p.pos += 2
+			return i, nil
+		}
+	}
+	return 0, &ParseError{Pos: p.pos, Msg: fmt.Sprintf("unknown day code %q, expected one of %s", code, strings.Join(days, ", "))}
+}
+
+func (p *parser) minutes() (int, error) {
+	start := p.pos
+	minutes, err := p.number()
+	if err != nil {
+		return 0, err
+	}
+	if minutes > 24*60 {
+		return 0, &ParseError{Pos: start, Msg: fmt.Sprintf("%d minutes exceeds a full day", minutes)}
+	}
+	return minutes, nil
+}
+
+func (p *parser) number() (int, error) {
+	start := p.pos
+	for !p.done() && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, &ParseError{Pos: start, Msg: "expected allowed minutes"}
+	}
+	minutes, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		return 0, &ParseError{Pos: start, Msg: err.Error()}
+	}
+	return minutes, nil
+}
@@ -2,9 +2,6 @@ package policy
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 )
 
@@ -21,8 +18,13 @@ func (rc RealClock) Now() time.Time {
 }
 
 type PolicyManager struct {
-	policy map[string]int
-	clock  Clock
+	rules     []Rule
+	budgets   []Budget
+	overrides []Override
+	rollover  *Rollover
+	windows   []timeWindow
+	clock     Clock
+	loc       *time.Location
 }
 
 func NewPolicyManager(policyStr string) (*PolicyManager, error) {
@@ -30,11 +32,29 @@ func NewPolicyManager(policyStr string) (*PolicyManager, error) {
 }
 
 func NewPolicyManagerWithClock(policyStr string, clock Clock) (*PolicyManager, error) {
-	policy, err := parse(policyStr)
+	def, err := parse(policyStr)
 	if err != nil {
 		return nil, err
 	}
-	return &PolicyManager{policy: policy, clock: clock}, nil
+	return &PolicyManager{rules: def.rules, budgets: def.budgets, rollover: def.rollover, clock: clock}, nil
+}
+
+// SetLocation sets the time zone that decides the weekday, the day boundaries
+// and the time windows. By default the clock's zone is used.
+func (pm *PolicyManager) SetLocation(loc *time.Location) {
+	pm.loc = loc
+}
+
+// now returns the clock's time in the policy's time zone.
+func (pm *PolicyManager) now() time.Time {
+	return pm.in(pm.clock.Now())
+}
+
+func (pm *PolicyManager) in(t time.Time) time.Time {
+	if pm.loc == nil {
+		return t
+	}
+	return t.In(pm.loc)
 }
 
 func (pm *PolicyManager) IsWithinPolicy(activeMinutes int) bool {
@@ -42,60 +62,62 @@ func (pm *PolicyManager) IsWithinPolicy(activeMinutes int) bool {
 	return activeMinutes <= allowed
 }
 
-func parse(policyStr string) (map[string]int, error) {
-	policy := make(map[string]int)
-	re := regexp.MustCompile(`([A-Z-]+)(\d+)`)
-	matches := re.FindAllStringSubmatch(policyStr, -1)
-	for _, match := range matches {
-		if len(match) == 3 {
-			min, err := strconv.Atoi(match[2])
-			if err != nil {
-				return nil, err
-			}
-			policy[match[1]] = min
-		}
-	}
-	if len(policy) == 0 {
-		return nil, fmt.Errorf("no valid policy entries found")
+// Rule is a single policy entry, e.g. "MO-TH" with 90 minutes.
+type Rule struct {
+	Days    string
+	Minutes int
+	// Pos is the byte offset of the rule in the policy string.
+	Pos int
+
+	first, last int
+}
+
+// IsRange reports whether the rule covers a range of days rather than a single day.
+func (r Rule) IsRange() bool {
+	return r.first != r.last
+}
+
+// String formats the rule in policy string notation.
+func (r Rule) String() string {
+	return fmt.Sprintf("%s%d", r.Days, r.Minutes)
+}
+
+// covers reports whether the rule applies to the day index (0 = Monday).
+func (r Rule) covers(day int) bool {
+	return day >= r.first && day <= r.last
+}
+
+// span is the number of days the rule covers.
+func (r Rule) span() int {
+	return r.last - r.first + 1
+}
+
+// precedes reports whether r takes precedence over other on days both cover:
+// the rule covering fewer days wins (so a single day beats a range), and
+// between equally specific rules the later one in the policy string wins.
+func (r Rule) precedes(other Rule) bool {
+	if r.span() != other.span() {
+		return r.span() < other.span()
 	}
-	return policy, nil
+	return r.Pos > other.Pos
 }
 
-func (pm *PolicyManager) getTodayAllowed() int {
-	now := pm.clock.Now()
-	weekday := now.Weekday()
-	var dayKey string
-	switch weekday {
-	case time.Monday:
-		dayKey = "MO"
-	case time.Tuesday:
-		dayKey = "TU"
-	case time.Wednesday:
-		dayKey = "WE"
-	case time.Thursday:
-		dayKey = "TH"
-	case time.Friday:
-		dayKey = "FR"
-	case time.Saturday:
-		dayKey = "SA"
-	case time.Sunday:
-		dayKey = "SU"
+// TodayRule returns the policy entry that applies today, if any, taking
+// date-based overrides into account.
+func (pm *PolicyManager) TodayRule() (Rule, bool) {
+	rules := pm.rules
+	if o, ok := pm.TodayOverride(); ok {
+		rules = o.rules
 	}
+	return ruleFor(rules, dayIndex(pm.now().Weekday()))
+}
 
-	// Check ranges
-	for key, min := range pm.policy {
-		if strings.Contains(key, "-") {
-			parts := strings.Split(key, "-")
-			if len(parts) == 2 {
-				if dayInRange(dayKey, parts[0], parts[1]) {
-					return min
-				}
-			}
-		} else if key == dayKey {
-			return min
-		}
+func (pm *PolicyManager) getTodayAllowed() int {
+	rule, ok := pm.TodayRule()
+	if !ok {
+		return 0 // Default if not found
 	}
-	return 0 // Default if not found
+	return rule.Minutes
 }
 
 // AllowedToday returns the allowed minutes for today according to the policy.
@@ -103,22 +125,27 @@ func (pm *PolicyManager) AllowedToday() int {
 	return pm.getTodayAllowed()
 }
 
-func dayInRange(day, start, end string) bool {
-	days := []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
-	startIdx, endIdx := -1, -1
-	for i, d := range days {
-		if d == start {
-			startIdx = i
-		}
-		if d == end {
-			endIdx = i
-		}
-	}
-	dayIdx := -1
-	for i, d := range days {
-		if d == day {
-			dayIdx = i
+// Rules returns the parsed policy entries in the order they were given.
+func (pm *PolicyManager) Rules() []Rule {
+	return append([]Rule(nil), pm.rules...)
+}
+
+// ruleFor returns the rule with the highest precedence covering the day.
+func ruleFor(rules []Rule, day int) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, r := range rules {
+		if r.covers(day) && (!found || r.precedes(best)) {
+			best = r
+			found = true
 		}
 	}
-	return dayIdx >= startIdx && dayIdx <= endIdx
+	return best, found
+}
+
+var days = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// dayIndex maps a weekday to its position in days (Monday first).
+func dayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
 }
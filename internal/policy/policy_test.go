@@ -1,6 +1,7 @@
 package policy_test
 
 import (
+	"errors"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -24,6 +25,29 @@ var _ = Describe("Policy", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(pm).To(BeNil())
 		})
+
+		It("should reject unknown day codes with their position", func() {
+			_, err := policy.NewPolicyManager("MO-XX90")
+			var perr *policy.ParseError
+			Expect(errors.As(err, &perr)).To(BeTrue())
+			Expect(perr.Pos).To(Equal(3))
+			Expect(perr.Msg).To(ContainSubstring(`unknown day code "XX"`))
+		})
+
+		It("should reject trailing garbage and missing minutes", func() {
+			_, err := policy.NewPolicyManager("MO-FR90 junk")
+			Expect(err).To(MatchError(ContainSubstring("position 8")))
+			_, err = policy.NewPolicyManager("MO-FR")
+			Expect(err).To(MatchError(ContainSubstring("expected allowed minutes")))
+			_, err = policy.NewPolicyManager("FR-MO90")
+			Expect(err).To(MatchError(ContainSubstring("ends before it starts")))
+		})
+
+		It("should accept separators between entries", func() {
+			pm, err := policy.NewPolicyManager("MO-FR90, SA-SU180")
+			Expect(err).To(BeNil())
+			Expect(pm.Rules()).To(HaveLen(2))
+		})
 	})
 
 	Describe("IsWithinPolicy", func() {
@@ -80,4 +104,255 @@ var _ = Describe("Policy", func() {
 		})
 	})
 
+	Describe("precedence", func() {
+		var fakeClock *policyfakes.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = &policyfakes.FakeClock{}
+			fakeClock.NowReturns(time.Date(2023, 1, 4, 12, 0, 0, 0, time.UTC)) // Wednesday
+		})
+
+		It("prefers a single day over a range regardless of order", func() {
+			for _, str := range []string{"MO-FR90WE60", "WE60MO-FR90"} {
+				pm, err := policy.NewPolicyManagerWithClock(str, fakeClock)
+				Expect(err).To(BeNil())
+				Expect(pm.AllowedToday()).To(Equal(60))
+			}
+		})
+
+		It("prefers the narrower range, then the later rule", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU30TU-TH45", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedToday()).To(Equal(45))
+
+			pm, err = policy.NewPolicyManagerWithClock("WE30WE50", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedToday()).To(Equal(50))
+		})
+	})
+
+	Describe("Lint", func() {
+		It("reports no issues for a complete policy", func() {
+			Expect(policy.Lint("MO-TH90FR120SA-SU180")).To(BeEmpty())
+		})
+
+		It("reports parse errors", func() {
+			issues := policy.Lint("MO-XX90")
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Severity).To(Equal(policy.SeverityError))
+			Expect(issues[0].Pos).To(Equal(3))
+		})
+
+		It("warns about overlaps and gaps", func() {
+			issues := policy.Lint("MO-FR90WE60")
+			Expect(issues).To(HaveLen(2))
+			Expect(issues[0].Severity).To(Equal(policy.SeverityWarning))
+			Expect(issues[0].Message).To(Equal("WE60 overlaps MO-FR90 on WE, WE60 takes precedence"))
+			Expect(issues[1].Message).To(ContainSubstring("no rule for SA, SU"))
+		})
+	})
+
+	Describe("TodayRule", func() {
+		var fakeClock *policyfakes.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = &policyfakes.FakeClock{}
+		})
+
+		It("returns the matching range rule", func() {
+			fakeClock.NowReturns(time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)) // Tuesday
+			pm, err := policy.NewPolicyManagerWithClock("MO-TH90FR120SA-SU180", fakeClock)
+			Expect(err).To(BeNil())
+			rule, ok := pm.TodayRule()
+			Expect(ok).To(BeTrue())
+			Expect(rule.IsRange()).To(BeTrue())
+			Expect(rule.String()).To(Equal("MO-TH90"))
+		})
+
+		It("returns the matching single day rule", func() {
+			fakeClock.NowReturns(time.Date(2023, 1, 6, 12, 0, 0, 0, time.UTC)) // Friday
+			pm, err := policy.NewPolicyManagerWithClock("MO-TH90FR120SA-SU180", fakeClock)
+			Expect(err).To(BeNil())
+			rule, ok := pm.TodayRule()
+			Expect(ok).To(BeTrue())
+			Expect(rule.IsRange()).To(BeFalse())
+			Expect(rule.Minutes).To(Equal(120))
+		})
+
+		It("reports when no rule covers today", func() {
+			fakeClock.NowReturns(time.Date(2023, 1, 8, 12, 0, 0, 0, time.UTC)) // Sunday
+			pm, err := policy.NewPolicyManagerWithClock("MO-FR90", fakeClock)
+			Expect(err).To(BeNil())
+			_, ok := pm.TodayRule()
+			Expect(ok).To(BeFalse())
+			Expect(pm.AllowedToday()).To(Equal(0))
+		})
+
+		It("selects the weekday in the policy's time zone", func() {
+			tokyo, err := time.LoadLocation("Asia/Tokyo")
+			Expect(err).To(BeNil())
+			fakeClock.NowReturns(time.Date(2023, 1, 8, 20, 0, 0, 0, time.UTC)) // Sunday, Monday 05:00 in Tokyo
+			pm, err := policy.NewPolicyManagerWithClock("MO-FR90SA-SU180", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedToday()).To(Equal(180))
+			pm.SetLocation(tokyo)
+			Expect(pm.AllowedToday()).To(Equal(90))
+			Expect(pm.PeriodStart(policy.PeriodWeek)).To(Equal(time.Date(2023, 1, 9, 0, 0, 0, 0, tokyo)))
+		})
+	})
+
+	Describe("budgets", func() {
+		var fakeClock *policyfakes.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = &policyfakes.FakeClock{}
+			fakeClock.NowReturns(time.Date(2023, 1, 4, 12, 0, 0, 0, time.UTC)) // Wednesday
+		})
+
+		It("caps the daily limit by the remaining weekly budget", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU180WEEK600", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Week: 300})).To(Equal(180))
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Week: 500})).To(Equal(100))
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Week: 700})).To(Equal(0))
+		})
+
+		It("applies the tighter of weekly and monthly budgets", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU180 WEEK600 MONTH2000", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Week: 100, Month: 1950})).To(Equal(50))
+			left, ok := pm.Remaining(policy.PeriodWeek, 650)
+			Expect(ok).To(BeTrue())
+			Expect(left).To(Equal(0))
+		})
+
+		It("has no daily cap when only budgets are given", func() {
+			pm, err := policy.NewPolicyManagerWithClock("WEEK600", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Week: 120})).To(Equal(480))
+			_, ok := pm.Remaining(policy.PeriodMonth, 0)
+			Expect(ok).To(BeFalse())
+		})
+
+		It("computes the start of the week and month", func() {
+			pm, err := policy.NewPolicyManagerWithClock("WEEK600", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.PeriodStart(policy.PeriodWeek)).To(Equal(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)))
+			Expect(pm.PeriodStart(policy.PeriodMonth)).To(Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("rejects duplicate budgets and lints unreachable ones", func() {
+			_, err := policy.NewPolicyManager("WEEK600WEEK300")
+			Expect(err).To(MatchError(ContainSubstring("duplicate WEEK budget")))
+			issues := policy.Lint("MO-SU60WEEK600")
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Message).To(ContainSubstring("WEEK600 budget is never reached"))
+		})
+	})
+
+	Describe("overrides", func() {
+		var fakeClock *policyfakes.FakeClock
+		var pm *policy.PolicyManager
+
+		BeforeEach(func() {
+			fakeClock = &policyfakes.FakeClock{}
+			fakeClock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)) // Wednesday
+			var err error
+			pm, err = policy.NewPolicyManagerWithClock("MO-FR90SA-SU180", fakeClock)
+			Expect(err).To(BeNil())
+		})
+
+		It("switches the rules on covered dates", func() {
+			holidays, err := policy.ParseOverride("2026-10-12..2026-10-23=MO-SU180", time.UTC)
+			Expect(err).To(BeNil())
+			pm.AddOverrides(holidays)
+			Expect(pm.AllowedToday()).To(Equal(180))
+			o, ok := pm.TodayOverride()
+			Expect(ok).To(BeTrue())
+			Expect(o.Reason).To(Equal("2026-10-12..2026-10-23"))
+
+			fakeClock.NowReturns(time.Date(2026, 10, 26, 12, 0, 0, 0, time.UTC)) // Monday after
+			Expect(pm.AllowedToday()).To(Equal(90))
+		})
+
+		It("prefers the shortest override", func() {
+			holidays, err := policy.ParseOverride("2026-10-12..2026-10-23=MO-SU180", time.UTC)
+			Expect(err).To(BeNil())
+			event, err := policy.NewOverride(
+				time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), "MO-SU30", "Dentist")
+			Expect(err).To(BeNil())
+			pm.AddOverrides(event, holidays)
+			Expect(pm.AllowedToday()).To(Equal(30))
+		})
+
+		It("rejects malformed overrides", func() {
+			_, err := policy.ParseOverride("2026-10-12", time.UTC)
+			Expect(err).To(MatchError(ContainSubstring("expected FROM[..TO]=POLICY")))
+			_, err = policy.ParseOverride("2026-10-12..2026-10-01=MO-SU180", time.UTC)
+			Expect(err).To(MatchError(ContainSubstring("before it starts")))
+			_, err = policy.ParseOverride("2026-10-12=WEEK600", time.UTC)
+			Expect(err).To(MatchError(ContainSubstring("no daily rules")))
+		})
+	})
+
+	Describe("rollover", func() {
+		var fakeClock *policyfakes.FakeClock
+
+		day := func(d int) time.Time {
+			return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC)
+		}
+
+		BeforeEach(func() {
+			fakeClock = &policyfakes.FakeClock{}
+			fakeClock.NowReturns(time.Date(2023, 1, 14, 12, 0, 0, 0, time.UTC)) // Saturday
+		})
+
+		It("parses the rollover rule with a default expiry", func() {
+			pm, err := policy.NewPolicyManager("MO-SU90ROLLOVER30")
+			Expect(err).To(BeNil())
+			r, ok := pm.Rollover()
+			Expect(ok).To(BeTrue())
+			Expect(r.String()).To(Equal("ROLLOVER30D7"))
+
+			_, err = policy.NewPolicyManager("MO-SU90ROLLOVER30D0")
+			Expect(err).To(MatchError(ContainSubstring("at least 1 day")))
+		})
+
+		It("banks unused minutes up to the limit and adds them to the quota", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU90ROLLOVER30D7", fakeClock)
+			Expect(err).To(BeNil())
+			bank := pm.Bank([]policy.DayRecord{
+				{Date: day(10), QuotaMinutes: 90, ActiveMinutes: 0},  // deposits 30
+				{Date: day(11), QuotaMinutes: 90, ActiveMinutes: 75}, // deposits 15
+				{Date: day(12), QuotaMinutes: 90, ActiveMinutes: 90}, // nothing
+			})
+			Expect(bank).To(Equal(45))
+			Expect(pm.AllowedTodayWithUsage(policy.Usage{Bank: bank})).To(Equal(135))
+		})
+
+		It("withdraws overdrafts from the oldest deposits", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU90ROLLOVER30D7", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.Bank([]policy.DayRecord{
+				{Date: day(10), QuotaMinutes: 90, ActiveMinutes: 60},
+				{Date: day(11), QuotaMinutes: 90, ActiveMinutes: 60},
+				{Date: day(12), QuotaMinutes: 90, ActiveMinutes: 130},
+			})).To(Equal(20))
+		})
+
+		It("expires deposits after the rollover period", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU90ROLLOVER30D3", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.Bank([]policy.DayRecord{
+				{Date: day(10), QuotaMinutes: 90, ActiveMinutes: 0}, // expired on the 14th
+				{Date: day(11), QuotaMinutes: 90, ActiveMinutes: 80},
+			})).To(Equal(10))
+		})
+
+		It("has no bank without a rollover rule", func() {
+			pm, err := policy.NewPolicyManagerWithClock("MO-SU90", fakeClock)
+			Expect(err).To(BeNil())
+			Expect(pm.Bank([]policy.DayRecord{{Date: day(13), QuotaMinutes: 90}})).To(Equal(0))
+		})
+	})
 })
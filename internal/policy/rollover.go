@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	rolloverKeyword     = "ROLLOVER"
+	defaultRolloverDays = 7
+)
+
+// Rollover lets unused minutes be banked: up to Minutes unused minutes per day
+// are carried over and can be spent within Days days, e.g. "ROLLOVER30D7".
+type Rollover struct {
+	Minutes int
+	Days    int
+	// Pos is the byte offset of the rule in the policy string.
+	Pos int
+}
+
+// String formats the rollover rule in policy string notation.
+func (r Rollover) String() string {
+	return fmt.Sprintf("%s%dD%d", rolloverKeyword, r.Minutes, r.Days)
+}
+
+// DayRecord is the usage of one earlier day, as needed to compute the bank.
+type DayRecord struct {
+	Date time.Time
+	// QuotaMinutes is the day's quota without banked minutes.
+	QuotaMinutes  int
+	ActiveMinutes int
+}
+
+// Rollover returns the rollover rule, if the policy defines one.
+func (pm *PolicyManager) Rollover() (Rollover, bool) {
+	if pm.rollover == nil {
+		return Rollover{}, false
+	}
+	return *pm.rollover, true
+}
+
+// Bank returns the banked minutes available today. Every earlier day deposits
+// its unused minutes (up to the rollover limit); days over their quota
+// withdraw from the oldest deposits first, and deposits expire after the
+// rollover period.
+func (pm *PolicyManager) Bank(history []DayRecord) int {
+	if pm.rollover == nil {
+		return 0
+	}
+	now := pm.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	records := append([]DayRecord(nil), history...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+
+	type deposit struct {
+		day     time.Time
+		minutes int
+	}
+	var deposits []deposit
+	expire := func(day time.Time) {
+		for len(deposits) > 0 && deposits[0].day.AddDate(0, 0, pm.rollover.Days).Before(day) {
+			deposits = deposits[1:]
+		}
+	}
+	for _, r := range records {
+		day := time.Date(r.Date.Year(), r.Date.Month(), r.Date.Day(), 0, 0, 0, 0, today.Location())
+		if !day.Before(today) {
+			continue
+		}
+		expire(day)
+		if unused := r.QuotaMinutes - r.ActiveMinutes; unused > 0 {
+			deposits = append(deposits, deposit{day: day, minutes: min(unused, pm.rollover.Minutes)})
+			continue
+		}
+		overdraft := r.ActiveMinutes - r.QuotaMinutes
+		for overdraft > 0 && len(deposits) > 0 {
+			spent := min(overdraft, deposits[0].minutes)
+			deposits[0].minutes -= spent
+			overdraft -= spent
+			if deposits[0].minutes == 0 {
+				deposits = deposits[1:]
+			}
+		}
+	}
+	expire(today)
+	balance := 0
+	for _, d := range deposits {
+		balance += d.minutes
+	}
+	return balance
+}
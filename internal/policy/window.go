@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeWindow is a compiled Window: minutes after midnight, To exclusive.
+type timeWindow struct {
+	days     Rule
+	from, to int
+}
+
+func (w Window) compile() (timeWindow, error) {
+	p := &parser{input: w.Days}
+	first, err := p.day()
+	if err != nil {
+		return timeWindow{}, fmt.Errorf("days %q: %w", w.Days, err)
+	}
+	last := first
+	if !p.done() && p.input[p.pos] == '-' {
+		p.pos++
+		if last, err = p.day(); err != nil {
+			return timeWindow{}, fmt.Errorf("days %q: %w", w.Days, err)
+		}
+	}
+	if !p.done() || last < first {
+		return timeWindow{}, fmt.Errorf("days %q: expected a day or a range like MO-FR", w.Days)
+	}
+	from, err := clockMinutes(w.From)
+	if err != nil {
+		return timeWindow{}, fmt.Errorf("from: %w", err)
+	}
+	to, err := clockMinutes(w.To)
+	if err != nil {
+		return timeWindow{}, fmt.Errorf("to: %w", err)
+	}
+	if to <= from {
+		return timeWindow{}, fmt.Errorf("window %s-%s ends before it starts", w.From, w.To)
+	}
+	return timeWindow{days: Rule{Days: w.Days, first: first, last: last}, from: from, to: to}, nil
+}
+
+// clockMinutes parses "HH:MM" (24:00 allowed) into minutes after midnight.
+func clockMinutes(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// InWindow reports whether t falls within an allowed time window. Days
+// without any window are unrestricted.
+func (pm *PolicyManager) InWindow(t time.Time) bool {
+	t = pm.in(t)
+	day := dayIndex(t.Weekday())
+	minute := t.Hour()*60 + t.Minute()
+	restricted := false
+	for _, w := range pm.windows {
+		if !w.days.covers(day) {
+			continue
+		}
+		restricted = true
+		if minute >= w.from && minute < w.to {
+			return true
+		}
+	}
+	return !restricted
+}
@@ -0,0 +1,224 @@
+// Package scheduler runs jobs on independent intervals. A job never overlaps
+// itself: a run that is due while the previous one is still going is
+// skipped. Jobs can also be triggered to run right away.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownJob is returned by Trigger for a job that does not exist.
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrRunning is returned by Trigger while the job is running.
+	ErrRunning = errors.New("job is already running")
+)
+
+// Job is a task run every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Jitter delays each run by a random duration up to Jitter, so jobs with
+	// the same interval do not hit the Fritz!Box at the same moment.
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Status describes a job for the API.
+type Status struct {
+	Name     string        `json:"name"`
+	Interval time.Duration `json:"interval"`
+	Running  bool          `json:"running"`
+	Runs     int           `json:"runs"`
+	// Skipped counts runs that were due while the job was still running.
+	Skipped   int       `json:"skipped"`
+	LastStart time.Time `json:"last_start,omitzero"`
+	LastEnd   time.Time `json:"last_end,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+	NextRun   time.Time `json:"next_run,omitzero"`
+}
+
+// Scheduler runs jobs until its context is done.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*entry
+	wg   sync.WaitGroup
+	// stopped is set by Wait; no run starts after it.
+	stopped bool
+}
+
+type entry struct {
+	job     Job
+	status  Status
+	trigger chan struct{}
+}
+
+// New creates a scheduler for the jobs. Job names must be unique.
+func New(jobs ...Job) (*Scheduler, error) {
+	s := &Scheduler{jobs: make(map[string]*entry)}
+	for _, job := range jobs {
+		if job.Name == "" || job.Run == nil {
+			return nil, errors.New("a job needs a name and a run function")
+		}
+		if job.Interval <= 0 {
+			return nil, fmt.Errorf("job %s: interval must be positive, got %s", job.Name, job.Interval)
+		}
+		if _, ok := s.jobs[job.Name]; ok {
+			return nil, fmt.Errorf("duplicate job %s", job.Name)
+		}
+		s.jobs[job.Name] = &entry{
+			job:     job,
+			status:  Status{Name: job.Name, Interval: job.Interval},
+			trigger: make(chan struct{}, 1),
+		}
+	}
+	return s, nil
+}
+
+// Start runs every job once right away and then on its interval, until ctx
+// is done. Use Wait to wait for runs in progress after that.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, e := range s.jobs {
+		go s.loop(ctx, e)
+	}
+}
+
+// Wait blocks until no job is running. Call it once the context passed to
+// Start is done; no run starts after Wait was called.
+func (s *Scheduler) Wait() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// SetInterval changes a job's interval from its next run on.
+func (s *Scheduler) SetInterval(name string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	e.job.Interval = interval
+	e.status.Interval = interval
+	return nil
+}
+
+// Trigger runs the job now, without waiting for it to finish. It returns
+// ErrRunning if the job is running and ErrUnknownJob if there is no such job.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	e, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	running := e.status.Running
+	s.mu.Unlock()
+	if running {
+		return fmt.Errorf("%w: %s", ErrRunning, name)
+	}
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+		// A trigger is already pending.
+	}
+	return nil
+}
+
+// Status returns the status of all jobs, sorted by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// loop runs the job on its interval and on triggers.
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	delay := s.jitter(e)
+	for {
+		s.mu.Lock()
+		e.status.NextRun = time.Now().Add(delay)
+		s.mu.Unlock()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-e.trigger:
+			timer.Stop()
+		}
+		s.run(ctx, e)
+		s.mu.Lock()
+		delay = e.job.Interval
+		s.mu.Unlock()
+		delay += s.jitter(e)
+	}
+}
+
+// run runs the job in the background unless it is still running from
+// before, in which case the run is skipped. Nothing runs once ctx is done or
+// Wait was called, so Wait never misses a run that is just starting.
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	s.mu.Lock()
+	if s.stopped || ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	if e.status.Running {
+		e.status.Skipped++
+		s.mu.Unlock()
+		return
+	}
+	e.status.Running = true
+	e.status.LastStart = time.Now()
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		err := e.job.Run(ctx)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		e.status.Running = false
+		e.status.Runs++
+		e.status.LastEnd = time.Now()
+		e.status.LastError = ""
+		if err != nil {
+			e.status.LastError = err.Error()
+		}
+	}()
+}
+
+func (s *Scheduler) jitter(e *entry) time.Duration {
+	if e.job.Jitter <= 0 {
+		return 0
+	}
+	return rand.N(e.job.Jitter)
+}
+
+// Stalled reports whether the job looks wedged at now: a run has been going
+// for more than twice the interval plus slack, or a due run did not start
+// within slack.
+func (st Status) Stalled(now time.Time, slack time.Duration) bool {
+	if st.Running {
+		return now.Sub(st.LastStart) > 2*st.Interval+slack
+	}
+	return !st.NextRun.IsZero() && now.Sub(st.NextRun) > slack
+}
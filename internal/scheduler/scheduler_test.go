@@ -0,0 +1,131 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/scheduler"
+)
+
+var _ = Describe("Scheduler", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("runs jobs right away and on their own intervals", func() {
+		var fast, slow atomic.Int32
+		s, err := scheduler.New(
+			scheduler.Job{Name: "fast", Interval: 10 * time.Millisecond, Run: func(context.Context) error { fast.Add(1); return nil }},
+			scheduler.Job{Name: "slow", Interval: time.Hour, Run: func(context.Context) error { slow.Add(1); return nil }},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		s.Start(ctx)
+
+		Eventually(fast.Load).Should(BeNumerically(">=", 3))
+		Expect(slow.Load()).To(Equal(int32(1)))
+	})
+
+	It("skips runs while the job is still running", func() {
+		release := make(chan struct{})
+		var runs atomic.Int32
+		s, err := scheduler.New(scheduler.Job{Name: "day", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+			runs.Add(1)
+			<-release
+			return nil
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		s.Start(ctx)
+
+		Eventually(func() int { return s.Status()[0].Skipped }).Should(BeNumerically(">=", 2))
+		Expect(runs.Load()).To(Equal(int32(1)))
+		Expect(s.Status()[0].Running).To(BeTrue())
+		Expect(s.Trigger("day")).To(MatchError(scheduler.ErrRunning))
+		close(release)
+		s.Wait()
+	})
+
+	It("starts no runs once stopped", func() {
+		var runs atomic.Int32
+		s, err := scheduler.New(scheduler.Job{Name: "hour", Interval: time.Millisecond, Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		s.Start(ctx)
+		Eventually(runs.Load).Should(BeNumerically(">=", 3))
+
+		cancel()
+		s.Wait()
+		n := runs.Load()
+		Expect(s.Trigger("hour")).To(Succeed())
+		Consistently(runs.Load, 20*time.Millisecond).Should(Equal(n))
+		Expect(s.Status()[0].Running).To(BeFalse())
+	})
+
+	It("runs a job on demand and records its result", func() {
+		var runs atomic.Int32
+		s, err := scheduler.New(scheduler.Job{Name: "enforce", Interval: time.Hour, Run: func(context.Context) error {
+			runs.Add(1)
+			return errors.New("router unreachable")
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		s.Start(ctx)
+		Eventually(func() int { return s.Status()[0].Runs }).Should(Equal(1))
+
+		Expect(s.Trigger("enforce")).To(Succeed())
+		Eventually(func() int { return s.Status()[0].Runs }).Should(Equal(2))
+		status := s.Status()[0]
+		Expect(status.LastError).To(Equal("router unreachable"))
+		Expect(status.NextRun).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+
+		Expect(s.Trigger("report")).To(MatchError(scheduler.ErrUnknownJob))
+	})
+
+	It("delays runs by up to the jitter", func() {
+		started := make(chan time.Time, 1)
+		s, err := scheduler.New(scheduler.Job{Name: "hour", Interval: time.Hour, Jitter: 20 * time.Millisecond, Run: func(context.Context) error {
+			started <- time.Now()
+			return nil
+		}})
+		Expect(err).ToNot(HaveOccurred())
+		start := time.Now()
+		s.Start(ctx)
+		Eventually(started).Should(Receive(BeTemporally("<", start.Add(time.Second))))
+	})
+
+	It("rejects invalid jobs", func() {
+		run := func(context.Context) error { return nil }
+		_, err := scheduler.New(scheduler.Job{Name: "day", Run: run})
+		Expect(err).To(MatchError(ContainSubstring("interval must be positive")))
+		_, err = scheduler.New(scheduler.Job{Name: "day", Interval: time.Minute, Run: run}, scheduler.Job{Name: "day", Interval: time.Minute, Run: run})
+		Expect(err).To(MatchError("duplicate job day"))
+	})
+
+	It("reports stalled jobs", func() {
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		status := scheduler.Status{Interval: 5 * time.Minute, NextRun: now.Add(time.Minute)}
+		Expect(status.Stalled(now, time.Minute)).To(BeFalse())
+
+		status.NextRun = now.Add(-2 * time.Minute)
+		Expect(status.Stalled(now, time.Minute)).To(BeTrue())
+
+		status = scheduler.Status{Interval: 5 * time.Minute, Running: true, LastStart: now.Add(-10 * time.Minute)}
+		Expect(status.Stalled(now, time.Minute)).To(BeFalse())
+		status.LastStart = now.Add(-12 * time.Minute)
+		Expect(status.Stalled(now, time.Minute)).To(BeTrue())
+	})
+})
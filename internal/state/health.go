@@ -0,0 +1,56 @@
+package state
+
+import (
+	"time"
+
+	"home-gate/internal/fritzbox"
+)
+
+// Health describes whether the daemon's monitoring works.
+type Health struct {
+	Started time.Time `json:"started"`
+	// LastRun and LastSuccess are the start of the latest monitoring run and
+	// of the latest one that succeeded.
+	LastRun     time.Time `json:"last_run,omitzero"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	// ConsecutiveFailures counts the failed runs since the last success.
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastError           string `json:"last_error,omitempty"`
+	// Reachable and LoggedIn describe the Fritz!Box as of LastFetch.
+	LastFetch time.Time `json:"last_fetch,omitzero"`
+	Reachable bool      `json:"fritzbox_reachable"`
+	LoggedIn  bool      `json:"fritzbox_logged_in"`
+}
+
+var health = Health{Started: time.Now()}
+
+// RecordRun records the result of a monitoring run started at start.
+func RecordRun(start time.Time, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	health.LastRun = start
+	if err != nil {
+		health.ConsecutiveFailures++
+		health.LastError = err.Error()
+		return
+	}
+	health.LastSuccess = start
+	health.ConsecutiveFailures = 0
+	health.LastError = ""
+}
+
+// RecordFetch records the result of fetching data from the Fritz!Box.
+func RecordFetch(at time.Time, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	health.LastFetch = at
+	health.Reachable = !fritzbox.IsUnreachable(err)
+	health.LoggedIn = err == nil || (health.Reachable && !fritzbox.IsUnauthorized(err))
+}
+
+// GetHealth returns the current health.
+func GetHealth() Health {
+	mu.RLock()
+	defer mu.RUnlock()
+	return health
+}
@@ -0,0 +1,32 @@
+package state
+
+import "time"
+
+// Reload is the result of (re)loading the daemon configuration.
+type Reload struct {
+	Time time.Time `json:"time"`
+	// Source is what triggered the reload: startup, config file, SIGHUP or api.
+	Source string `json:"source"`
+	OK     bool   `json:"ok"`
+	// Error explains why the new configuration was rejected; the previous one
+	// stays active.
+	Error string `json:"error,omitempty"`
+	// Policy describes the policy in effect after the reload.
+	Policy string `json:"policy,omitempty"`
+}
+
+var lastReload Reload
+
+// SetReload stores the result of the latest reload.
+func SetReload(r Reload) {
+	mu.Lock()
+	defer mu.Unlock()
+	lastReload = r
+}
+
+// LastReload returns the result of the latest reload.
+func LastReload() Reload {
+	mu.RLock()
+	defer mu.RUnlock()
+	return lastReload
+}
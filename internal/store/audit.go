@@ -0,0 +1,156 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	auditTable = "audit"
+	// AuditLogFile is the append-only JSON lines copy of the audit log in
+	// the data directory, for log shippers and tail -f.
+	AuditLogFile = "audit.jsonl"
+)
+
+// AuditRetention is how long entries are kept in the audit table; the JSON
+// lines file keeps all of them.
+const AuditRetention = 90 * 24 * time.Hour
+
+// Actors of audited actions: the policy blocks and unblocks, rewards and
+// reloads come through the API or the CLI.
+const (
+	ActorPolicy = "policy"
+	ActorAPI    = "api"
+	ActorCLI    = "cli"
+)
+
+// Audited actions.
+const (
+	ActionBlock   = "block"
+	ActionUnblock = "unblock"
+	ActionReward  = "reward"
+	ActionReload  = "reload"
+)
+
+// AuditEntry records one action: a block or unblock of a device, a reward or
+// a configuration reload.
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Device is the key of the device as in the usage history and the
+	// enforcement state (see DayUsage), the child of a reward, or empty for
+	// a reload.
+	Device string `json:"device"`
+	// Name is the device name as shown in reports.
+	Name    string `json:"name,omitempty"`
+	MAC     string `json:"mac,omitempty"`
+	UserUID string `json:"user_uid,omitempty"`
+	// Router names the Fritz!Box with several routers.
+	Router string `json:"router,omitempty"`
+	// Action is block or unblock.
+	Action string `json:"action"`
+	// Actor is who triggered the action, e.g. ActorPolicy.
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+	// Error is set if the Fritz!Box rejected the action.
+	Error string `json:"error,omitempty"`
+}
+
+// AppendAudit appends an entry to the audit log: the JSON lines file and the
+// audit table. Entries are never changed; the table drops those older than
+// AuditRetention before e.
+func (s *Store) AppendAudit(e AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, AuditLogFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	var entries []AuditEntry
+	if err := s.readTable(auditTable, &entries); err != nil {
+		return err
+	}
+	cutoff := e.Time.Add(-AuditRetention)
+	kept := entries[:0]
+	for _, entry := range entries {
+		if !entry.Time.Before(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	return s.writeTable(auditTable, append(kept, e))
+}
+
+// Audit returns the entries from from (inclusive) to to (exclusive), oldest
+// first. The device is matched case-insensitively against key, name and MAC;
+// empty returns all entries.
+func (s *Store) Audit(device string, from, to time.Time) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []AuditEntry
+	if err := s.readTable(auditTable, &entries); err != nil {
+		return nil, err
+	}
+	var result []AuditEntry
+	for _, e := range entries {
+		if device != "" && !strings.EqualFold(e.Device, device) && !strings.EqualFold(e.Name, device) && !strings.EqualFold(e.MAC, device) {
+			continue
+		}
+		if !e.Time.Before(from) && e.Time.Before(to) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// AuditRange parses the from and to filters of an audit query. Both accept an
+// RFC 3339 timestamp or a date, which is interpreted in loc; a to date
+// includes the whole day. from defaults to 7 days before now, to to now.
+func AuditRange(from, to string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	start, end := now.AddDate(0, 0, -7), now.Add(time.Nanosecond)
+	if from != "" {
+		t, err := parseAuditTime(from, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid from: %w", err)
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := parseAuditTime(to, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid to: %w", err)
+		}
+		if _, err := time.ParseInLocation(DateFormat, to, loc); err == nil {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = t
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+func parseAuditTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(DateFormat, s, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("%q is neither a date (YYYY-MM-DD) nor an RFC 3339 time", s)
+	}
+	return t, nil
+}
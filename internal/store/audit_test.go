@@ -0,0 +1,103 @@
+package store_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Audit", func() {
+	var s *store.Store
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("appends to the table and the JSON lines file", func() {
+		Expect(s.AppendAudit(store.AuditEntry{Time: now, Device: "iPad", MAC: "aa11bb22cc33", Action: "block", Actor: store.ActorPolicy, Reason: "quota"})).To(Succeed())
+		Expect(s.AppendAudit(store.AuditEntry{Time: now.Add(time.Hour), Device: "Laptop", Action: "unblock", Actor: store.ActorAPI})).To(Succeed())
+
+		entries, err := s.Audit("", now, now.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+
+		data, err := os.ReadFile(filepath.Join(s.Dir(), store.AuditLogFile))
+		Expect(err).ToNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines).To(HaveLen(2))
+		Expect(lines[0]).To(ContainSubstring(`"action":"block"`))
+	})
+
+	It("keeps the table for AuditRetention and the file for good", func() {
+		old := now.Add(-store.AuditRetention - time.Hour)
+		Expect(s.AppendAudit(store.AuditEntry{Time: old, Device: "iPad", Action: "block"})).To(Succeed())
+		Expect(s.AppendAudit(store.AuditEntry{Time: now, Device: "iPad", Action: "unblock"})).To(Succeed())
+
+		entries, err := s.Audit("", old, now.Add(time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("unblock"))
+
+		data, err := os.ReadFile(filepath.Join(s.Dir(), store.AuditLogFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Split(strings.TrimSpace(string(data)), "\n")).To(HaveLen(2))
+	})
+
+	It("filters by device and time", func() {
+		Expect(s.AppendAudit(store.AuditEntry{Time: now, Device: "iPad", MAC: "aa11bb22cc33", Action: "block"})).To(Succeed())
+		Expect(s.AppendAudit(store.AuditEntry{Time: now.Add(time.Hour), Device: "iPad", Action: "unblock"})).To(Succeed())
+		Expect(s.AppendAudit(store.AuditEntry{Time: now, Device: "Laptop", Action: "block"})).To(Succeed())
+		Expect(s.AppendAudit(store.AuditEntry{Time: now, Device: "lena", Name: "Lena phone", Action: "block"})).To(Succeed())
+
+		entries, err := s.Audit("lena phone", now, now.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+
+		entries, err = s.Audit("AA11BB22CC33", now, now.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+
+		entries, err = s.Audit("ipad", now.Add(time.Minute), now.Add(2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("unblock"))
+	})
+})
+
+var _ = Describe("AuditRange", func() {
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	It("defaults to the last 7 days", func() {
+		from, to, err := store.AuditRange("", "", now, time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(from).To(Equal(now.AddDate(0, 0, -7)))
+		Expect(to.After(now)).To(BeTrue())
+	})
+
+	It("includes the whole to date", func() {
+		from, to, err := store.AuditRange("2023-01-18", "2023-01-19", now, time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(from).To(Equal(time.Date(2023, 1, 18, 0, 0, 0, 0, time.UTC)))
+		Expect(to).To(Equal(time.Date(2023, 1, 20, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("accepts RFC 3339 times and rejects other input", func() {
+		from, _, err := store.AuditRange("2023-01-19T08:00:00+01:00", "", now, time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(from).To(BeTemporally("==", time.Date(2023, 1, 19, 7, 0, 0, 0, time.UTC)))
+
+		_, _, err = store.AuditRange("yesterday", "", now, time.UTC)
+		Expect(err).To(MatchError(ContainSubstring("invalid from")))
+		_, _, err = store.AuditRange("2023-01-20", "2023-01-19", now, time.UTC)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,55 @@
+package store
+
+import "time"
+
+const enforcementTable = "enforcement"
+
+// Enforcement is the enforcement state of a device, kept across runs.
+type Enforcement struct {
+	Device string `json:"device"`
+	// Date is the policy day QuotaReachedAt belongs to.
+	Date string `json:"date"`
+	// QuotaReachedAt is when the device first reached its quota on Date.
+	QuotaReachedAt *time.Time `json:"quota_reached_at,omitempty"`
+	// BlockedAt is when home-gate blocked the device, nil while unblocked.
+	BlockedAt *time.Time `json:"blocked_at,omitempty"`
+	// BlockedDate is the policy day the device was blocked on.
+	BlockedDate string `json:"blocked_date,omitempty"`
+	// Reason is why the device was blocked: quota or window.
+	Reason string `json:"reason,omitempty"`
+	// Override is the policy override in effect when the device was blocked.
+	Override string `json:"override,omitempty"`
+}
+
+// Enforcement returns the state of the device; false if there is none.
+func (s *Store) Enforcement(device string) (Enforcement, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Enforcement
+	if err := s.readTable(enforcementTable, &records); err != nil {
+		return Enforcement{}, false, err
+	}
+	for _, r := range records {
+		if r.Device == device {
+			return r, true, nil
+		}
+	}
+	return Enforcement{}, false, nil
+}
+
+// PutEnforcement stores the state of a device, replacing the earlier one.
+func (s *Store) PutEnforcement(e Enforcement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Enforcement
+	if err := s.readTable(enforcementTable, &records); err != nil {
+		return err
+	}
+	for i, r := range records {
+		if r.Device == e.Device {
+			records[i] = e
+			return s.writeTable(enforcementTable, records)
+		}
+	}
+	return s.writeTable(enforcementTable, append(records, e))
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const rewardsTable = "rewards"
+
+// Reward is an entry of the earned-time ledger: bonus minutes awarded to a
+// child (e.g. for chores) on the day of Time.
+type Reward struct {
+	Time    time.Time `json:"time"`
+	Child   string    `json:"child"`
+	Minutes int       `json:"minutes"`
+	Reason  string    `json:"reason"`
+}
+
+// Validate checks that the reward names a child and awards a non-zero amount.
+// Negative minutes take time away.
+func (r Reward) Validate() error {
+	if strings.TrimSpace(r.Child) == "" {
+		return errors.New("child is required")
+	}
+	if r.Minutes == 0 {
+		return errors.New("minutes must not be zero")
+	}
+	return nil
+}
+
+// AddReward appends a reward to the ledger.
+func (s *Store) AddReward(r Reward) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ledger []Reward
+	if err := s.readTable(rewardsTable, &ledger); err != nil {
+		return err
+	}
+	ledger = append(ledger, r)
+	return s.writeTable(rewardsTable, ledger)
+}
+
+// AwardReward adds a reward given by actor, e.g. ActorAPI, and records it in
+// the audit log.
+func (s *Store) AwardReward(r Reward, actor string) error {
+	if err := s.AddReward(r); err != nil {
+		return err
+	}
+	err := s.AppendAudit(AuditEntry{
+		Time:   r.Time,
+		Device: r.Child,
+		Action: ActionReward,
+		Actor:  actor,
+		Reason: fmt.Sprintf("%+d minutes (%s)", r.Minutes, r.Reason),
+	})
+	if err != nil {
+		return fmt.Errorf("reward added, but failed to write the audit log: %w", err)
+	}
+	return nil
+}
+
+// Rewards returns the ledger entries awarded between from and to (inclusive
+// dates). The child is matched case-insensitively; empty returns all children.
+func (s *Store) Rewards(child string, from, to time.Time) ([]Reward, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ledger []Reward
+	if err := s.readTable(rewardsTable, &ledger); err != nil {
+		return nil, err
+	}
+	fromKey, toKey := from.Format(DateFormat), to.Format(DateFormat)
+	var result []Reward
+	for _, r := range ledger {
+		if child != "" && !strings.EqualFold(r.Child, child) {
+			continue
+		}
+		day := r.Time.In(from.Location()).Format(DateFormat)
+		if day >= fromKey && day <= toKey {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package store_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Rewards", func() {
+	var s *store.Store
+
+	BeforeEach(func() {
+		var err error
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("appends rewards to the ledger and filters by child and day", func() {
+		monday := time.Date(2023, 1, 2, 18, 0, 0, 0, time.UTC)
+		tuesday := monday.AddDate(0, 0, 1)
+		Expect(s.AddReward(store.Reward{Time: monday, Child: "iPad", Minutes: 30, Reason: "dishes"})).To(Succeed())
+		Expect(s.AddReward(store.Reward{Time: tuesday, Child: "iPad", Minutes: 15, Reason: "homework"})).To(Succeed())
+		Expect(s.AddReward(store.Reward{Time: tuesday, Child: "Laptop", Minutes: -10, Reason: "late"})).To(Succeed())
+
+		rewards, err := s.Rewards("ipad", tuesday, tuesday)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rewards).To(HaveLen(1))
+		Expect(rewards[0].Reason).To(Equal("homework"))
+
+		rewards, err = s.Rewards("", monday, tuesday)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rewards).To(HaveLen(3))
+	})
+
+	It("records awarded rewards in the audit log", func() {
+		now := time.Date(2023, 1, 2, 18, 0, 0, 0, time.UTC)
+		Expect(s.AwardReward(store.Reward{Time: now, Child: "Anna", Minutes: 30, Reason: "dishes"}, store.ActorCLI)).To(Succeed())
+		Expect(s.AwardReward(store.Reward{Time: now, Child: "Anna"}, store.ActorCLI)).To(MatchError("minutes must not be zero"))
+
+		entries, err := s.Audit("anna", now, now.Add(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(ConsistOf(store.AuditEntry{
+			Time: now, Device: "Anna", Action: store.ActionReward, Actor: store.ActorCLI, Reason: "+30 minutes (dishes)",
+		}))
+	})
+
+	It("rejects invalid rewards", func() {
+		Expect(s.AddReward(store.Reward{Child: "", Minutes: 30})).To(MatchError("child is required"))
+		Expect(s.AddReward(store.Reward{Child: "iPad"})).To(MatchError("minutes must not be zero"))
+	})
+})
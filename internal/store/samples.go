@@ -0,0 +1,83 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+const samplesTable = "samples"
+
+// SampleRetention is how long traffic samples are kept.
+const SampleRetention = 14 * 24 * time.Hour
+
+// Sample is a device's average traffic (Byte/s) in one 15-minute interval.
+type Sample struct {
+	Start  time.Time `json:"start"`
+	Device string    `json:"device"`
+	MAC    string    `json:"mac"`
+	Rcv    float64   `json:"rcv"`
+	Snd    float64   `json:"snd"`
+}
+
+// PutSamples stores samples, replacing earlier ones of the same device and
+// interval, and drops samples older than SampleRetention before now.
+func (s *Store) PutSamples(samples []Sample, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Sample
+	if err := s.readTable(samplesTable, &records); err != nil {
+		return err
+	}
+	type key struct {
+		device string
+		start  int64
+	}
+	index := make(map[key]int, len(records))
+	for i, r := range records {
+		index[key{r.Device, r.Start.Unix()}] = i
+	}
+	for _, sample := range samples {
+		k := key{sample.Device, sample.Start.Unix()}
+		if i, ok := index[k]; ok {
+			records[i] = sample
+			continue
+		}
+		index[k] = len(records)
+		records = append(records, sample)
+	}
+	cutoff := now.Add(-SampleRetention)
+	kept := records[:0]
+	for _, r := range records {
+		if !r.Start.Before(cutoff) {
+			kept = append(kept, r)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if !kept[i].Start.Equal(kept[j].Start) {
+			return kept[i].Start.Before(kept[j].Start)
+		}
+		return kept[i].Device < kept[j].Device
+	})
+	return s.writeTable(samplesTable, kept)
+}
+
+// Samples returns the samples starting from from (inclusive) to to
+// (exclusive), ordered by time. An empty device returns all devices.
+func (s *Store) Samples(device string, from, to time.Time) ([]Sample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Sample
+	if err := s.readTable(samplesTable, &records); err != nil {
+		return nil, err
+	}
+	var result []Sample
+	for _, r := range records {
+		if device != "" && r.Device != device {
+			continue
+		}
+		if !r.Start.Before(from) && r.Start.Before(to) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package store_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Samples", func() {
+	var s *store.Store
+	now := time.Date(2023, 1, 20, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		var err error
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("replaces samples of the same device and interval", func() {
+		Expect(s.PutSamples([]store.Sample{
+			{Start: now.Add(-30 * time.Minute), Device: "iPad", Rcv: 10},
+			{Start: now.Add(-15 * time.Minute), Device: "iPad", Rcv: 20},
+		}, now)).To(Succeed())
+		Expect(s.PutSamples([]store.Sample{
+			{Start: now.Add(-15 * time.Minute), Device: "iPad", Rcv: 25},
+			{Start: now.Add(-15 * time.Minute), Device: "Laptop", Rcv: 5},
+		}, now)).To(Succeed())
+
+		samples, err := s.Samples("iPad", now.Add(-time.Hour), now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[1].Rcv).To(Equal(25.0))
+
+		all, err := s.Samples("", now.Add(-time.Hour), now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(all).To(HaveLen(3))
+	})
+
+	It("drops samples past the retention", func() {
+		old := store.Sample{Start: now.Add(-store.SampleRetention - time.Minute), Device: "iPad"}
+		Expect(s.PutSamples([]store.Sample{old, {Start: now, Device: "iPad"}}, now)).To(Succeed())
+
+		samples, err := s.Samples("", now.AddDate(-1, 0, 0), now.Add(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(samples).To(HaveLen(1))
+	})
+})
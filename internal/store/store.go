@@ -0,0 +1,69 @@
+// Package store persists home-gate state across runs as JSON files (tables)
+// inside a data directory.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store is a directory of JSON tables. It is safe for concurrent use within a
+// process; writes replace the table file atomically.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open returns the store in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the data directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// readTable decodes the named table into v. A missing table leaves v untouched.
+func (s *Store) readTable(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse table %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeTable encodes v into the named table.
+func (s *Store) writeTable(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, name+".json"))
+}
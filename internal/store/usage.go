@@ -0,0 +1,83 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+const usageTable = "usage"
+
+// DateFormat is the layout of the Date field of stored records.
+const DateFormat = "2006-01-02"
+
+// DayUsage is a device's usage on one day.
+type DayUsage struct {
+	Date string `json:"date"`
+	// Device is the key of the device: the linked identity name, which stays
+	// stable when a phone rotates its MAC address, or else the normalized MAC.
+	Device string `json:"device"`
+	// Name is the device name as shown in reports.
+	Name string `json:"name,omitempty"`
+	MAC  string `json:"mac"`
+	// Child is the child the policy document assigns the device to, if any.
+	Child         string `json:"child,omitempty"`
+	ActiveMinutes int    `json:"active_minutes"`
+	QuotaMinutes  int    `json:"quota"`
+	// BankMinutes is the part of the quota that came from banked rollover minutes.
+	BankMinutes int `json:"bank,omitempty"`
+	// BonusMinutes is the part of the quota that came from rewards.
+	BonusMinutes int `json:"bonus,omitempty"`
+	// Active holds the day's activity blocks, e.g. "10:00+02:00/PT45M".
+	Active []string `json:"active,omitempty"`
+}
+
+// PutUsage stores the usage of a device for a day, replacing an earlier
+// record for the same device and day.
+func (s *Store) PutUsage(u DayUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []DayUsage
+	if err := s.readTable(usageTable, &records); err != nil {
+		return err
+	}
+	replaced := false
+	for i, r := range records {
+		if r.Date == u.Date && r.Device == u.Device {
+			records[i] = u
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		records = append(records, u)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Date != records[j].Date {
+			return records[i].Date < records[j].Date
+		}
+		return records[i].Device < records[j].Device
+	})
+	return s.writeTable(usageTable, records)
+}
+
+// Usage returns the records between from and to (inclusive dates), ordered by
+// date. An empty device returns the records of all devices.
+func (s *Store) Usage(device string, from, to time.Time) ([]DayUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []DayUsage
+	if err := s.readTable(usageTable, &records); err != nil {
+		return nil, err
+	}
+	fromKey, toKey := from.Format(DateFormat), to.Format(DateFormat)
+	var result []DayUsage
+	for _, r := range records {
+		if device != "" && r.Device != device {
+			continue
+		}
+		if r.Date >= fromKey && r.Date <= toKey {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
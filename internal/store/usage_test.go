@@ -0,0 +1,47 @@
+package store_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/internal/store"
+)
+
+var _ = Describe("Usage", func() {
+	var s *store.Store
+
+	BeforeEach(func() {
+		var err error
+		s, err = store.Open(GinkgoT().TempDir())
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns nothing for an empty store", func() {
+		records, err := s.Usage("", time.Now().AddDate(0, 0, -7), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(BeEmpty())
+	})
+
+	It("replaces the record of the same device and day", func() {
+		Expect(s.PutUsage(store.DayUsage{Date: "2023-01-02", Device: "iPad", ActiveMinutes: 30})).To(Succeed())
+		Expect(s.PutUsage(store.DayUsage{Date: "2023-01-02", Device: "iPad", ActiveMinutes: 45})).To(Succeed())
+		Expect(s.PutUsage(store.DayUsage{Date: "2023-01-02", Device: "Laptop", ActiveMinutes: 10})).To(Succeed())
+
+		records, err := s.Usage("iPad", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(Equal([]store.DayUsage{{Date: "2023-01-02", Device: "iPad", ActiveMinutes: 45}}))
+	})
+
+	It("filters by inclusive date range", func() {
+		for _, date := range []string{"2023-01-01", "2023-01-02", "2023-01-03", "2023-01-04"} {
+			Expect(s.PutUsage(store.DayUsage{Date: date, Device: "iPad", ActiveMinutes: 15})).To(Succeed())
+		}
+		records, err := s.Usage("", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 3, 23, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Date).To(Equal("2023-01-02"))
+		Expect(records[1].Date).To(Equal("2023-01-03"))
+	})
+})
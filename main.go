@@ -1,6 +1,9 @@
 package main
 
 import (
+	// Embed the time zone database for --timezone, the runtime image has none.
+	_ "time/tzdata"
+
 	"home-gate/cmd"
 )
 
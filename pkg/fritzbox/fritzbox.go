@@ -0,0 +1,251 @@
+// Package fritzbox is the public Fritz!Box client of home-gate. It lists the
+// devices of the home network, reads the online monitor and blocks the
+// devices' user profiles.
+package fritzbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"home-gate/internal/fritzbox"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fritzboxfakes/fake_client.go . Client
+
+// Client talks to one Fritz!Box. Call Connect (or use Dial) before any other
+// method. The Fritz!Box API cannot cancel requests: once ctx is done a method
+// returns ctx.Err() while the request finishes in the background.
+type Client interface {
+	// Connect logs in.
+	Connect(ctx context.Context) error
+	// Devices lists the landevices known to the Fritz!Box.
+	Devices(ctx context.Context) ([]Device, error)
+	// MonitorConfig returns the configuration of the online monitor.
+	MonitorConfig(ctx context.Context) (MonitorConfig, error)
+	// Datasets lists the datasets of the online monitor.
+	Datasets(ctx context.Context) ([]Dataset, error)
+	// MonitorData returns the measurements of a subset of a dataset.
+	MonitorData(ctx context.Context, dataset, subset string) ([]SubsetData, error)
+	// BlockDevice blocks or unblocks the internet access of a user profile.
+	BlockDevice(ctx context.Context, userUID string, block bool) error
+	// Rights returns the access level of the logged-in user per right, e.g.
+	// "BoxAdmin": 2 for write access.
+	Rights(ctx context.Context) (map[string]int, error)
+}
+
+// Device is a landevice known to the Fritz!Box.
+type Device struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+	MAC  string `json:"mac"`
+	// UserUIDs is the user profile the device belongs to, which BlockDevice
+	// blocks.
+	UserUIDs string `json:"user_uids"`
+	Active   bool   `json:"active"`
+	Blocked  bool   `json:"blocked"`
+}
+
+// MonitorConfig is the configuration of the online monitor.
+type MonitorConfig struct {
+	// Devices are the UIDs of the landevices the online monitor shows.
+	Devices []string `json:"devices"`
+}
+
+// Dataset is a series of the online monitor, e.g. the traffic of the last
+// day.
+type Dataset struct {
+	UID         string       `json:"uid"`
+	Type        string       `json:"type"`
+	DataSources []DataSource `json:"data_sources"`
+	Subsets     []Subset     `json:"subsets"`
+}
+
+// DataSource is the traffic of one device and direction in a dataset.
+type DataSource struct {
+	LandeviceUID   string `json:"landevice_uid"`
+	Type           string `json:"type"`
+	DataSourceName string `json:"data_source_name"`
+	Unit           string `json:"unit"`
+}
+
+// Subset is a time span of a dataset and its sample interval in seconds.
+type Subset struct {
+	UID            string  `json:"uid"`
+	Duration       float64 `json:"duration"`
+	SampleInterval float64 `json:"sample_interval"`
+}
+
+// SubsetData are the measurements of a data source, e.g.
+// "rcv_aa11bb22cc33", the oldest first.
+type SubsetData struct {
+	Timestamp      string    `json:"timestamp"`
+	DataSourceName string    `json:"data_source_name"`
+	Measurements   []float64 `json:"measurements"`
+}
+
+// DefaultURL is the address used without WithURL.
+const DefaultURL = fritzbox.DefaultURL
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	url string
+}
+
+// WithURL sets the address of the Fritz!Box, e.g. https://fritz.box.
+func WithURL(url string) Option {
+	return func(o *options) {
+		o.url = url
+	}
+}
+
+// New creates a client for the Fritz!Box without connecting.
+func New(username, password string, opts ...Option) Client {
+	o := options{url: DefaultURL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &client{c: fritzbox.NewWithURL(username, password, o.url)}
+}
+
+// Dial creates a client and logs in. It returns ctx.Err() if ctx is done
+// before the login completes.
+func Dial(ctx context.Context, username, password string, opts ...Option) (Client, error) {
+	c := New(username, password, opts...)
+	if err := c.Connect(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return c, nil
+}
+
+// NormalizeMAC returns the MAC address in lower case without separators, the
+// form used in monitor data source names.
+func NormalizeMAC(mac string) string {
+	return fritzbox.NormalizeMAC(mac)
+}
+
+// IsUnreachable reports whether err means the Fritz!Box could not be reached.
+func IsUnreachable(err error) bool {
+	return fritzbox.IsUnreachable(err)
+}
+
+// IsUnauthorized reports whether err means the Fritz!Box rejected the login.
+func IsUnauthorized(err error) bool {
+	return fritzbox.IsUnauthorized(err)
+}
+
+// client implements Client with the internal Fritz!Box client.
+type client struct {
+	c fritzbox.Client
+}
+
+func (c *client) Connect(ctx context.Context) error {
+	_, err := call(ctx, func() (struct{}, error) {
+		return struct{}{}, c.c.Connect()
+	})
+	return err
+}
+
+func (c *client) Devices(ctx context.Context) ([]Device, error) {
+	landevices, err := call(ctx, c.c.GetLandevices)
+	if err != nil {
+		return nil, err
+	}
+	devices := make([]Device, len(landevices))
+	for i, d := range landevices {
+		devices[i] = Device{
+			UID:      d.UID,
+			Name:     d.FriendlyName,
+			MAC:      d.MAC,
+			UserUIDs: d.UserUIDs,
+			Active:   d.IsActive(),
+			Blocked:  d.IsBlocked(),
+		}
+	}
+	return devices, nil
+}
+
+func (c *client) MonitorConfig(ctx context.Context) (MonitorConfig, error) {
+	config, err := call(ctx, c.c.GetMonitorConfig)
+	if err != nil {
+		return MonitorConfig{}, err
+	}
+	var uids []string
+	for _, uid := range strings.Split(config.DisplayHomenetDevices, ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			uids = append(uids, uid)
+		}
+	}
+	return MonitorConfig{Devices: uids}, nil
+}
+
+func (c *client) Datasets(ctx context.Context) ([]Dataset, error) {
+	datasets, err := call(ctx, c.c.GetMonitorDatasets)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Dataset, len(datasets))
+	for i, ds := range datasets {
+		result[i] = Dataset{UID: ds.UID, Type: ds.Type}
+		for _, src := range ds.DataSources {
+			result[i].DataSources = append(result[i].DataSources, DataSource(src))
+		}
+		for _, sub := range ds.Subsets {
+			result[i].Subsets = append(result[i].Subsets, Subset{UID: sub.UID, Duration: sub.Duration, SampleInterval: sub.SampleInterval})
+		}
+	}
+	return result, nil
+}
+
+func (c *client) MonitorData(ctx context.Context, dataset, subset string) ([]SubsetData, error) {
+	data, err := call(ctx, func() ([]fritzbox.SubsetData, error) {
+		return c.c.GetMonitorData(dataset, subset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SubsetData, len(data))
+	for i, d := range data {
+		result[i] = SubsetData(d)
+	}
+	return result, nil
+}
+
+func (c *client) BlockDevice(ctx context.Context, userUID string, block bool) error {
+	_, err := call(ctx, func() (struct{}, error) {
+		return struct{}{}, c.c.BlockDevice(userUID, block)
+	})
+	return err
+}
+
+func (c *client) Rights(ctx context.Context) (map[string]int, error) {
+	return call(ctx, c.c.Rights)
+}
+
+// call runs f and returns its result, or ctx.Err() once ctx is done.
+func call[T any](ctx context.Context, f func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := f()
+		done <- result{v, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case r := <-done:
+		return r.v, r.err
+	}
+}
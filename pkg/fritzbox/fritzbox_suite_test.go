@@ -0,0 +1,13 @@
+package fritzbox_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFritzbox(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fritzbox Suite")
+}
@@ -0,0 +1,52 @@
+package fritzbox_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/pkg/fritzbox"
+)
+
+var _ = Describe("Dial", func() {
+	It("returns the context's error once it is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := fritzbox.Dial(ctx, "user", "secret")
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("connects to the Fritz!Box at the given URL", func() {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := fritzbox.Dial(context.Background(), "user", "secret", fritzbox.WithURL(server.URL))
+		Expect(err).To(MatchError(ContainSubstring("failed to connect")))
+		Expect(requests).To(BeNumerically(">", 0))
+	})
+})
+
+var _ = Describe("Client", func() {
+	It("does not send requests once the context is done", func() {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client := fritzbox.New("user", "secret", fritzbox.WithURL(server.URL))
+		_, err := client.Devices(ctx)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(client.BlockDevice(ctx, "user1", true)).To(MatchError(context.Canceled))
+		Expect(requests).To(Equal(0))
+	})
+})
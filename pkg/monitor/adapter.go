@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	ifritzbox "home-gate/internal/fritzbox"
+	"home-gate/internal/monitor"
+	"home-gate/pkg/fritzbox"
+)
+
+// Classifier decides which intervals of a device's traffic count as active.
+type Classifier interface {
+	// Classify returns one flag per interval of rcv and snd (Byte/s).
+	Classify(rcv, snd []float64) []bool
+	String() string
+}
+
+// Classifiers picks the classifier of each device by its type, see
+// WithClassifiers.
+type Classifiers struct {
+	// Default classifies devices without a type. Defaults to the threshold
+	// of WithActivityThreshold.
+	Default Classifier
+	// ByType holds the classifier of each device type, e.g. "console".
+	ByType map[string]Classifier
+	// Devices maps device names, UIDs or MAC addresses to a type of ByType.
+	Devices map[string]string
+}
+
+// Thresholds are the receive and send rates (Byte/s) an interval must exceed,
+// in either direction, to count as active.
+type Thresholds struct {
+	Rcv float64 `json:"rcv"`
+	Snd float64 `json:"snd"`
+}
+
+// NewThresholdClassifier counts every interval above t as active.
+func NewThresholdClassifier(t Thresholds) Classifier {
+	return monitor.ThresholdClassifier{Thresholds: monitor.Thresholds(t)}
+}
+
+// NewHysteresisClassifier starts counting intervals as active above on and
+// keeps counting until the traffic falls to off or below.
+func NewHysteresisClassifier(on, off Thresholds) Classifier {
+	return monitor.HysteresisClassifier{On: monitor.Thresholds(on), Off: monitor.Thresholds(off)}
+}
+
+// NewSustainedClassifier counts intervals above t as active once intervals
+// of them follow each other.
+func NewSustainedClassifier(t Thresholds, intervals int) Classifier {
+	return monitor.SustainedClassifier{Thresholds: monitor.Thresholds(t), Intervals: intervals}
+}
+
+// reporter calls a Reporter once a target was enforced.
+type reporter struct {
+	ctx context.Context
+	r   Reporter
+}
+
+func (r reporter) Report(monitor.Report) error {
+	return nil
+}
+
+func (r reporter) Finish(rep monitor.Report) error {
+	report := Report{Name: rep.Target.Name, Router: rep.Target.Router, Now: rep.Now}
+	if len(rep.Target.MACs) > 0 {
+		report.MAC = rep.Target.MACs[0]
+	}
+	if rep.Usage != nil {
+		usage := usageOf(*rep.Usage)
+		report.Usage = &usage
+	}
+	return r.r.Report(r.ctx, report)
+}
+
+// client adapts a Client to the one of the monitor, passing ctx to every
+// request.
+type client struct {
+	ctx context.Context
+	c   fritzbox.Client
+}
+
+func adapt(ctx context.Context, c fritzbox.Client) ifritzbox.Client {
+	return client{ctx: ctx, c: c}
+}
+
+var errUnsupported = errors.New("not supported by fritzbox.Client")
+
+func (c client) Connect() error {
+	return c.c.Connect(c.ctx)
+}
+
+func (c client) RestGet(string) ([]byte, int, error) {
+	return nil, 0, errUnsupported
+}
+
+func (c client) SID() string {
+	return ""
+}
+
+func (c client) GetLandevices() ([]ifritzbox.Landevice, error) {
+	devices, err := c.c.Devices(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	landevices := make([]ifritzbox.Landevice, len(devices))
+	for i, d := range devices {
+		landevices[i] = ifritzbox.Landevice{
+			UID:          d.UID,
+			FriendlyName: d.Name,
+			MAC:          d.MAC,
+			UserUIDs:     d.UserUIDs,
+			Active:       flag(d.Active),
+			Blocked:      flag(d.Blocked),
+		}
+	}
+	return landevices, nil
+}
+
+func (c client) GetMonitorConfig() (ifritzbox.MonitorConfig, error) {
+	config, err := c.c.MonitorConfig(c.ctx)
+	if err != nil {
+		return ifritzbox.MonitorConfig{}, err
+	}
+	return ifritzbox.MonitorConfig{DisplayHomenetDevices: strings.Join(config.Devices, ",")}, nil
+}
+
+func (c client) GetMonitorDatasets() ([]ifritzbox.Dataset, error) {
+	datasets, err := c.c.Datasets(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ifritzbox.Dataset, len(datasets))
+	for i, ds := range datasets {
+		result[i] = ifritzbox.Dataset{UID: ds.UID, Type: ds.Type}
+		for _, src := range ds.DataSources {
+			result[i].DataSources = append(result[i].DataSources, ifritzbox.DataSource(src))
+		}
+		for _, sub := range ds.Subsets {
+			result[i].Subsets = append(result[i].Subsets, ifritzbox.Subset{UID: sub.UID, Duration: sub.Duration, SampleInterval: sub.SampleInterval})
+		}
+	}
+	return result, nil
+}
+
+func (c client) GetMonitorData(dataset, subset string) ([]ifritzbox.SubsetData, error) {
+	data, err := c.c.MonitorData(c.ctx, dataset, subset)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ifritzbox.SubsetData, len(data))
+	for i, d := range data {
+		result[i] = ifritzbox.SubsetData(d)
+	}
+	return result, nil
+}
+
+func (c client) BlockDevice(userUID string, block bool) error {
+	return c.c.BlockDevice(c.ctx, userUID, block)
+}
+
+func (c client) Rights() (map[string]int, error) {
+	return c.c.Rights(c.ctx)
+}
+
+// flag returns the Fritz!Box form of b, "1" or "0".
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,387 @@
+// Package monitor is the public monitoring API of home-gate. It measures how
+// long each device was active today, applies the policy and optionally blocks
+// devices over their quota:
+//
+//	summary, err := monitor.Run(ctx,
+//		monitor.WithCredentials(user, password),
+//		monitor.WithURL("https://fritz.box"),
+//		monitor.WithPolicy("MO-FR60,SA-SU120"),
+//	)
+//
+// Integrations are notified of every device with WithReporter.
+package monitor
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"home-gate/internal/identity"
+	"home-gate/internal/monitor"
+	ipolicy "home-gate/internal/policy"
+	"home-gate/internal/store"
+	"home-gate/pkg/fritzbox"
+	"home-gate/pkg/policy"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o monitorfakes/fake_reporter.go . Reporter
+
+// Periods a run can cover.
+const (
+	PeriodHour = monitor.PeriodHour
+	PeriodDay  = monitor.PeriodDay
+)
+
+// Router is one Fritz!Box of a setup with several, see WithRouters.
+type Router struct {
+	Name     string
+	URL      string
+	Username string
+	Password string
+	// Client replaces the Fritz!Box client, e.g. with a fake; the username
+	// and password are not needed then. Optional.
+	Client fritzbox.Client
+}
+
+// Reporter is notified of every device once it was checked and, with
+// WithEnforcement, blocked or unblocked.
+type Reporter interface {
+	Report(ctx context.Context, r Report) error
+}
+
+// Report is the result of checking one device.
+type Report struct {
+	Name string
+	// MAC is the normalized MAC address of the device.
+	MAC string
+	// Router names the router that saw the device, with several routers.
+	Router string
+	// Usage is nil with PeriodHour, which only reports traffic.
+	Usage *DeviceUsage
+	Now   time.Time
+}
+
+// Option configures a run.
+type Option func(*config)
+
+type config struct {
+	opts      monitor.Options
+	client    fritzbox.Client
+	routers   []Router
+	policy    string
+	section   policySection
+	classes   *Classifiers
+	overrides []policy.Override
+	reporters []Reporter
+	err       error
+}
+
+// policySection is the policy document of WithPolicySection.
+type policySection struct {
+	path, key string
+}
+
+// WithCredentials sets the Fritz!Box login.
+func WithCredentials(username, password string) Option {
+	return func(c *config) {
+		c.opts.Username = username
+		c.opts.Password = password
+	}
+}
+
+// WithURL sets the address of the Fritz!Box. Defaults to
+// fritzbox.DefaultURL.
+func WithURL(url string) Option {
+	return func(c *config) {
+		c.opts.URL = url
+	}
+}
+
+// WithRouters monitors several Fritz!Boxes concurrently instead of the one of
+// WithCredentials. A device seen by more than one router (same MAC or linked
+// identity) has its usage summed before the policy is applied.
+func WithRouters(routers ...Router) Option {
+	return func(c *config) {
+		c.routers = append(c.routers, routers...)
+	}
+}
+
+// WithClient uses an existing client, e.g. from fritzbox.Dial or a fake,
+// instead of connecting with WithCredentials, which are not needed then.
+func WithClient(client fritzbox.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithDevice limits the run to one device, given by MAC address, landevice
+// UID or name. By default all devices of the Fritz!Box online monitor are
+// checked.
+func WithDevice(device string) Option {
+	return func(c *config) {
+		c.opts.Mac = device
+	}
+}
+
+// WithPeriod sets the period, PeriodDay (the default) or PeriodHour. The hour
+// period only reports traffic.
+func WithPeriod(period string) Option {
+	return func(c *config) {
+		c.opts.Period = period
+	}
+}
+
+// WithActivityThreshold counts intervals with more than the given Byte/s in
+// either direction as active.
+func WithActivityThreshold(bytesPerSecond float64) Option {
+	return func(c *config) {
+		c.opts.ActivityThreshold = bytesPerSecond
+	}
+}
+
+// WithClassifier classifies the activity of all devices with classifier.
+func WithClassifier(classifier Classifier) Option {
+	return func(c *config) {
+		c.classes = &Classifiers{Default: classifier}
+	}
+}
+
+// WithClassifiers classifies the activity of each device with the classifier
+// of its type. It replaces WithClassifier.
+func WithClassifiers(classifiers Classifiers) Option {
+	return func(c *config) {
+		c.classes = &classifiers
+	}
+}
+
+// WithAutoThreshold uses the idle baseline learned from the history, see
+// WithDataDir.
+func WithAutoThreshold() Option {
+	return func(c *config) {
+		c.opts.AutoThreshold = true
+	}
+}
+
+// WithPolicy applies a policy string to all devices.
+func WithPolicy(policyStr string) Option {
+	return func(c *config) {
+		c.opts.PolicyString = policyStr
+	}
+}
+
+// WithPolicyFile applies the YAML or JSON policy document at path, see
+// policy.LoadDocument. It takes precedence over WithPolicy.
+func WithPolicyFile(path string) Option {
+	return func(c *config) {
+		c.policy = path
+	}
+}
+
+// WithPolicySection applies the policy document under key of the YAML or JSON
+// file at path, e.g. the "policies" section of the home-gate config file. It
+// takes precedence over WithPolicy; WithPolicyFile takes precedence over it.
+func WithPolicySection(path, key string) Option {
+	return func(c *config) {
+		c.section = policySection{path: path, key: key}
+	}
+}
+
+// WithOverrides replaces the policy on specific dates.
+func WithOverrides(overrides ...policy.Override) Option {
+	return func(c *config) {
+		c.overrides = append(c.overrides, overrides...)
+	}
+}
+
+// WithEnforcement blocks devices over their quota and unblocks them once
+// they are within policy again.
+func WithEnforcement() Option {
+	return func(c *config) {
+		c.opts.Enforce = true
+	}
+}
+
+// WithGracePeriod delays blocking after the quota is reached. Needs
+// WithDataDir.
+func WithGracePeriod(d time.Duration) Option {
+	return func(c *config) {
+		c.opts.GracePeriod = d
+	}
+}
+
+// WithMinBlockDuration keeps a blocked device blocked at least d. Needs
+// WithDataDir.
+func WithMinBlockDuration(d time.Duration) Option {
+	return func(c *config) {
+		c.opts.MinBlockDuration = d
+	}
+}
+
+// WithDataDir keeps the usage history, rewards and enforcement state in dir,
+// as the home-gate command does. Weekly and monthly budgets need it.
+func WithDataDir(dir string) Option {
+	return func(c *config) {
+		history, err := store.Open(dir)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.opts.History = history
+	}
+}
+
+// WithIdentities links devices that rotate their private MAC address, as
+// recorded in the identities file at path by "home-gate devices link". A
+// missing file links no devices.
+func WithIdentities(path string) Option {
+	return func(c *config) {
+		identities, err := identity.Load(path)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.opts.Identities = identities
+	}
+}
+
+// WithAuditActor names who blocked or unblocked a device in the audit log of
+// WithDataDir. Defaults to "policy".
+func WithAuditActor(actor string) Option {
+	return func(c *config) {
+		c.opts.AuditActor = actor
+	}
+}
+
+// WithOutput writes progress and the per-device report to w.
+func WithOutput(w io.Writer) Option {
+	return func(c *config) {
+		c.opts.Out = w
+	}
+}
+
+// WithReporter notifies r of every device, e.g. to send notifications.
+func WithReporter(r Reporter) Option {
+	return func(c *config) {
+		c.reporters = append(c.reporters, r)
+	}
+}
+
+// WithLocation sets the time zone in which days start. Defaults to the local
+// time zone.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.opts.Location = loc
+	}
+}
+
+// WithClock sets the clock deciding what now is. Defaults to the system
+// clock.
+func WithClock(clock policy.Clock) Option {
+	return func(c *config) {
+		c.opts.Clock = clock
+	}
+}
+
+// newConfig applies opts and converts the public types for a run with ctx.
+func newConfig(ctx context.Context, opts []Option) (config, error) {
+	c := config{opts: monitor.Options{Period: PeriodDay}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.err != nil {
+		return c, c.err
+	}
+	if c.client != nil {
+		c.opts.TestClient = adapt(ctx, c.client)
+	}
+	for _, r := range c.routers {
+		router := monitor.Router{Name: r.Name, URL: r.URL, Username: r.Username, Password: r.Password}
+		if r.Client != nil {
+			router.Client = adapt(ctx, r.Client)
+		}
+		c.opts.Routers = append(c.opts.Routers, router)
+	}
+	if c.classes != nil {
+		def := c.classes.Default
+		if def == nil {
+			def = NewThresholdClassifier(Thresholds{Rcv: c.opts.ActivityThreshold, Snd: c.opts.ActivityThreshold})
+		}
+		byType := make(map[string]monitor.Classifier, len(c.classes.ByType))
+		for name, classifier := range c.classes.ByType {
+			byType[name] = classifier
+		}
+		activity, err := monitor.NewClassifiers(def, byType, c.classes.Devices)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Activity = activity
+	}
+	switch {
+	case c.policy != "":
+		doc, err := ipolicy.LoadDocument(c.policy)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Policies = doc
+	case c.section.path != "":
+		doc, err := ipolicy.LoadSection(c.section.path, c.section.key)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Policies = doc
+	}
+	for _, o := range c.overrides {
+		override, err := ipolicy.NewOverride(o.From, o.To, o.Policy, o.Reason)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Overrides = append(c.opts.Overrides, override)
+	}
+	return c, nil
+}
+
+// Run executes a monitoring run.
+func Run(ctx context.Context, opts ...Option) (Summary, error) {
+	c, err := newConfig(ctx, opts)
+	if err != nil {
+		return Summary{Errors: []error{err}}, err
+	}
+	p, err := monitor.NewPipeline(c.opts)
+	if err != nil {
+		return Summary{Errors: []error{err}}, err
+	}
+	for _, r := range c.reporters {
+		p.Reporters = append(p.Reporters, reporter{ctx: ctx, r: r})
+	}
+	summary, err := p.Run(ctx)
+	return summaryOf(summary), err
+}
+
+// Explain evaluates today's usage of one device against the policy without
+// enforcing anything.
+func Explain(ctx context.Context, device string, opts ...Option) (Explanation, error) {
+	c, err := newConfig(ctx, append(opts, WithDevice(device)))
+	if err != nil {
+		return Explanation{}, err
+	}
+	exp, err := monitor.Explain(ctx, c.opts)
+	if err != nil {
+		return Explanation{}, err
+	}
+	return explanationOf(exp), nil
+}
+
+// Diagnose checks that home-gate can work with the Fritz!Box, or with each
+// router of WithRouters: the login, the REST API, the online monitor and,
+// with WithEnforcement, the right to block devices.
+func Diagnose(ctx context.Context, opts ...Option) []Check {
+	c, err := newConfig(ctx, opts)
+	if err != nil {
+		return []Check{{Name: "configuration", Detail: err.Error()}}
+	}
+	var checks []Check
+	for _, check := range monitor.Diagnose(ctx, c.opts) {
+		checks = append(checks, Check(check))
+	}
+	return checks
+}
@@ -0,0 +1,283 @@
+package monitor_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	ifritzbox "home-gate/internal/fritzbox"
+	"home-gate/internal/identity"
+	"home-gate/internal/store"
+	"home-gate/pkg/fritzbox"
+	"home-gate/pkg/fritzbox/fritzboxfakes"
+	"home-gate/pkg/monitor"
+	"home-gate/pkg/monitor/monitorfakes"
+	"home-gate/pkg/policy/policyfakes"
+)
+
+var _ = Describe("Run", func() {
+	var (
+		client *fritzboxfakes.FakeClient
+		clock  *policyfakes.FakeClock
+	)
+
+	BeforeEach(func() {
+		client = &fritzboxfakes.FakeClient{}
+		client.DevicesReturns([]fritzbox.Device{
+			{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", Name: "iPad", UserUIDs: "user1"},
+		}, nil)
+		client.MonitorConfigReturns(fritzbox.MonitorConfig{Devices: []string{"landevice1"}}, nil)
+		// Active in the last 6 intervals before noon.
+		rcv := make([]float64, 96)
+		for i := 90; i < 96; i++ {
+			rcv[i] = 5000
+		}
+		client.MonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: rcv},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, 96)},
+		}, nil)
+		clock = &policyfakes.FakeClock{}
+		clock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+	})
+
+	It("monitors and enforces with functional options", func() {
+		var out bytes.Buffer
+		summary, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithEnforcement(),
+			monitor.WithDataDir(GinkgoT().TempDir()),
+			monitor.WithOutput(&out),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(90))
+		Expect(summary.Devices[0].QuotaMinutes).To(Equal(60))
+		Expect(client.BlockDeviceCallCount()).To(Equal(1))
+		Expect(out.String()).To(ContainSubstring("Exceeded policy"))
+	})
+
+	It("blocks through the client with the run's context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, err := monitor.Run(ctx,
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithEnforcement(),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.BlockDeviceCallCount()).To(Equal(1))
+		blockCtx, uid, block := client.BlockDeviceArgsForCall(0)
+		Expect(blockCtx).To(Equal(ctx))
+		Expect(uid).To(Equal("user1"))
+		Expect(block).To(BeTrue())
+	})
+
+	It("notifies reporters once a device was enforced", func() {
+		reporter := &monitorfakes.FakeReporter{}
+		reporter.ReportStub = func(context.Context, monitor.Report) error {
+			Expect(client.BlockDeviceCallCount()).To(Equal(1))
+			return nil
+		}
+		_, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithEnforcement(),
+			monitor.WithReporter(reporter),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reporter.ReportCallCount()).To(Equal(1))
+		_, report := reporter.ReportArgsForCall(0)
+		Expect(report.Name).To(Equal("iPad"))
+		Expect(report.MAC).To(Equal("aa11bb22cc33"))
+		Expect(report.Usage.DailyActiveMinutes).To(Equal(90))
+	})
+
+	It("requires credentials without a client", func() {
+		_, err := monitor.Run(context.Background(), monitor.WithPolicy("MO-SU60"))
+		Expect(err).To(MatchError(ContainSubstring("username and password are required")))
+	})
+
+	It("does not block without enforcement", func() {
+		_, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithClassifier(monitor.NewThresholdClassifier(monitor.Thresholds{Rcv: 100, Snd: 100})),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.BlockDeviceCallCount()).To(Equal(0))
+	})
+
+	It("classifies each device with the classifier of its type", func() {
+		_, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClassifiers(monitor.Classifiers{
+				ByType:  map[string]monitor.Classifier{"Tablet": monitor.NewThresholdClassifier(monitor.Thresholds{Rcv: 10000, Snd: 10000})},
+				Devices: map[string]string{"iPad": "tablet"},
+			}),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithEnforcement(),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.BlockDeviceCallCount()).To(Equal(0))
+	})
+
+	It("classifies devices without a type with the activity threshold", func() {
+		summary, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClassifiers(monitor.Classifiers{
+				ByType:  map[string]monitor.Classifier{"console": monitor.NewThresholdClassifier(monitor.Thresholds{Rcv: 10000, Snd: 10000})},
+				Devices: map[string]string{"Switch": "console"},
+			}),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices[0].DailyActiveMinutes).To(Equal(90))
+	})
+
+	It("rejects devices of an unknown type", func() {
+		_, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithClassifiers(monitor.Classifiers{Devices: map[string]string{"iPad": "tablet"}}),
+		)
+		Expect(err).To(MatchError(ContainSubstring(`unknown type "tablet"`)))
+	})
+
+	It("names the audit actor of a block", func() {
+		dir := GinkgoT().TempDir()
+		_, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithEnforcement(),
+			monitor.WithDataDir(dir),
+			monitor.WithAuditActor("cron"),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		history, err := store.Open(dir)
+		Expect(err).ToNot(HaveOccurred())
+		entries, err := history.Audit("", time.Time{}, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("block"))
+		Expect(entries[0].Actor).To(Equal("cron"))
+	})
+
+	It("links the devices of an identity", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "identities.json")
+		identities, err := identity.Load(path)
+		Expect(err).ToNot(HaveOccurred())
+		identities.Link("Lena's iPad", ifritzbox.Landevice{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", FriendlyName: "iPad"})
+		Expect(identities.Save()).To(Succeed())
+
+		summary, err := monitor.Run(context.Background(),
+			monitor.WithClient(client),
+			monitor.WithActivityThreshold(100),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+			monitor.WithIdentities(path),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Devices).To(HaveLen(1))
+		Expect(summary.Devices[0].Name).To(Equal("Lena's iPad"))
+	})
+
+	It("stops when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := monitor.Run(ctx, monitor.WithClient(client))
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(client.DevicesCallCount()).To(Equal(0))
+	})
+})
+
+var _ = Describe("Explain", func() {
+	It("explains the decision for one device", func() {
+		client := &fritzboxfakes.FakeClient{}
+		client.DevicesReturns([]fritzbox.Device{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", Name: "iPad"}}, nil)
+		client.MonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: make([]float64, 96)},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, 96)},
+		}, nil)
+		clock := &policyfakes.FakeClock{}
+		clock.NowReturns(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+
+		exp, err := monitor.Explain(context.Background(), "AA:11:BB:22:CC:33",
+			monitor.WithClient(client),
+			monitor.WithPolicy("MO-SU60"),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exp.QuotaMinutes).To(Equal(60))
+		Expect(exp.Decision).To(Equal("within policy, no action"))
+	})
+
+	It("reads the policy section of a config file and lists today's rewards", func() {
+		client := &fritzboxfakes.FakeClient{}
+		client.DevicesReturns([]fritzbox.Device{{UID: "landevice1", MAC: "AA:11:BB:22:CC:33", Name: "iPad"}}, nil)
+		client.MonitorDataReturns([]fritzbox.SubsetData{
+			{DataSourceName: "rcv_aa11bb22cc33", Measurements: make([]float64, 96)},
+			{DataSourceName: "snd_aa11bb22cc33", Measurements: make([]float64, 96)},
+		}, nil)
+		clock := &policyfakes.FakeClock{}
+		now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+		clock.NowReturns(now)
+		dir := GinkgoT().TempDir()
+		config := filepath.Join(dir, "home-gate.yaml")
+		Expect(os.WriteFile(config, []byte("policies:\n  version: 1\n  children:\n    - name: Lena\n      devices: [\"aa:11:bb:22:cc:33\"]\n      policy: MO-FR60\n"), 0o600)).To(Succeed())
+		history, err := store.Open(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(history.AddReward(store.Reward{Time: now.Add(-time.Hour), Child: "Lena", Minutes: 15, Reason: "homework"})).To(Succeed())
+
+		exp, err := monitor.Explain(context.Background(), "AA:11:BB:22:CC:33",
+			monitor.WithClient(client),
+			monitor.WithPolicySection(config, "policies"),
+			monitor.WithDataDir(dir),
+			monitor.WithClock(clock),
+			monitor.WithLocation(time.UTC),
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exp.Child).To(Equal("Lena"))
+		Expect(exp.Rule).To(Equal("MO-FR60"))
+		Expect(exp.RuleRange).To(BeTrue())
+		Expect(exp.Rewards).To(Equal([]monitor.Reward{{Minutes: 15, Reason: "homework"}}))
+		Expect(exp.QuotaMinutes).To(Equal(75))
+	})
+})
+
+var _ = Describe("Diagnose", func() {
+	It("checks a client without credentials", func() {
+		client := &fritzboxfakes.FakeClient{}
+		client.MonitorConfigReturns(fritzbox.MonitorConfig{Devices: []string{"landevice1"}}, nil)
+		client.RightsReturns(map[string]int{"BoxAdmin": 2}, nil)
+
+		checks := monitor.Diagnose(context.Background(), monitor.WithClient(client), monitor.WithEnforcement())
+		Expect(checks).ToNot(BeEmpty())
+		Expect(checks[0].Name).To(Equal("login"))
+		Expect(checks[0].OK).To(BeTrue())
+	})
+})
@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"time"
+
+	"home-gate/internal/monitor"
+)
+
+// Summary holds high-level details about a monitoring run.
+type Summary struct {
+	DevicesChecked int
+	UsersFetched   int
+	Errors         []error
+	StartTime      time.Time
+	Duration       time.Duration
+	Devices        []DeviceUsage `json:"devices"`
+}
+
+// DeviceUsage holds the activity and usage of a device today.
+type DeviceUsage struct {
+	MAC  string `json:"mac"`
+	UID  string `json:"uid,omitempty"`
+	Name string `json:"name"`
+	// Child is set when the policy document assigns the device to a child.
+	Child string `json:"child,omitempty"`
+	// Routers names the routers that saw the device, with several routers.
+	Routers            []string `json:"routers,omitempty"`
+	DailyActiveMinutes int      `json:"daily_active_minutes"`
+	// Precision is the resolution of DailyActiveMinutes as an ISO 8601
+	// duration, PT1M or PT15M.
+	Precision string `json:"precision"`
+	// Active lists the active intervals of the last 12 hours, e.g. "10:15".
+	Active       []string `json:"active"`
+	QuotaMinutes int      `json:"quota"`
+	// WeeklyRemainingMinutes and MonthlyRemainingMinutes are set when the
+	// policy defines the corresponding budget.
+	WeeklyRemainingMinutes  *int `json:"weekly_remaining,omitempty"`
+	MonthlyRemainingMinutes *int `json:"monthly_remaining,omitempty"`
+	// BankMinutes is the rollover balance included in QuotaMinutes.
+	BankMinutes int `json:"bank"`
+	// BonusMinutes is the sum of today's rewards included in QuotaMinutes.
+	BonusMinutes int `json:"bonus"`
+	// SharedMinutes is what the child's other devices used today; they share
+	// the quota with this device.
+	SharedMinutes int `json:"shared,omitempty"`
+}
+
+// UsedMinutes returns the minutes counting against today's quota: the
+// device's own and those of the child's other devices.
+func (u DeviceUsage) UsedMinutes() int {
+	return u.DailyActiveMinutes + u.SharedMinutes
+}
+
+// Explanation details how the policy decision for a device was reached.
+type Explanation struct {
+	Name string `json:"name"`
+	MAC  string `json:"mac"`
+	// Child is the child the policy document assigns the device to, if any.
+	Child string `json:"child,omitempty"`
+	// Rule is the policy rule matching today, e.g. "MO-FR60"; RuleMatched is
+	// false if no rule covers today, in which case the quota is 0 minutes.
+	Rule        string `json:"rule"`
+	RuleMatched bool   `json:"rule_matched"`
+	// RuleRange is true if Rule covers a range of days, e.g. MO-FR.
+	RuleRange bool `json:"rule_range,omitempty"`
+	// Override is the reason of the date-based override in effect, if any.
+	Override     string `json:"override,omitempty"`
+	QuotaMinutes int    `json:"quota"`
+	BankMinutes  int    `json:"bank"`
+	BonusMinutes int    `json:"bonus"`
+	// Rewards are today's rewards summed up in BonusMinutes.
+	Rewards []Reward `json:"rewards,omitempty"`
+	// Classifier describes how intervals were classified as active.
+	Classifier    string           `json:"classifier"`
+	Intervals     []IntervalDetail `json:"intervals"`
+	ActiveMinutes int              `json:"active_minutes"`
+	// SharedMinutes is what the child's other devices used today, see
+	// DeviceUsage.
+	SharedMinutes int `json:"shared,omitempty"`
+	// Precision is the resolution of ActiveMinutes, see DeviceUsage.
+	Precision string `json:"precision"`
+	Blocked   bool   `json:"blocked"`
+	// InWindow is false outside the allowed time windows of the policy.
+	InWindow bool   `json:"in_window"`
+	Decision string `json:"decision"`
+}
+
+// Reward is extra (or, if negative, fewer) minutes granted for today.
+type Reward struct {
+	Minutes int    `json:"minutes"`
+	Reason  string `json:"reason"`
+}
+
+// IntervalDetail is the traffic of one 15-minute interval today.
+type IntervalDetail struct {
+	Start  time.Time `json:"start"`
+	Rcv    float64   `json:"rcv"`
+	Snd    float64   `json:"snd"`
+	Active bool      `json:"active"`
+}
+
+// Check is the result of one check of Diagnose.
+type Check struct {
+	// Router names the router with several, see WithRouters.
+	Router string
+	Name   string
+	OK     bool
+	// Warning marks a failed check that does not prevent monitoring.
+	Warning bool
+	Detail  string
+}
+
+func summaryOf(s monitor.Summary) Summary {
+	summary := Summary{
+		DevicesChecked: s.DevicesChecked,
+		UsersFetched:   s.UsersFetched,
+		Errors:         s.Errors,
+		StartTime:      s.StartTime,
+		Duration:       s.Duration,
+	}
+	for _, u := range s.Devices {
+		summary.Devices = append(summary.Devices, usageOf(u))
+	}
+	return summary
+}
+
+func usageOf(u monitor.DeviceUsage) DeviceUsage {
+	return DeviceUsage{
+		MAC:                     u.MAC,
+		UID:                     u.UID,
+		Name:                    u.Name,
+		Child:                   u.Child,
+		Routers:                 u.Routers,
+		DailyActiveMinutes:      u.DailyActiveMinutes,
+		Precision:               u.Precision,
+		Active:                  u.Active,
+		QuotaMinutes:            u.QuotaMinutes,
+		WeeklyRemainingMinutes:  u.WeeklyRemainingMinutes,
+		MonthlyRemainingMinutes: u.MonthlyRemainingMinutes,
+		BankMinutes:             u.BankMinutes,
+		BonusMinutes:            u.BonusMinutes,
+		SharedMinutes:           u.SharedMinutes,
+	}
+}
+
+func explanationOf(e monitor.Explanation) Explanation {
+	exp := Explanation{
+		Name:          e.Name,
+		MAC:           e.MAC,
+		Child:         e.Child,
+		RuleMatched:   e.RuleMatched,
+		Override:      e.Override,
+		QuotaMinutes:  e.QuotaMinutes,
+		BankMinutes:   e.BankMinutes,
+		Classifier:    e.Classifier,
+		ActiveMinutes: e.ActiveMinutes,
+		SharedMinutes: e.SharedMinutes,
+		Precision:     e.Precision,
+		Blocked:       e.Blocked,
+		InWindow:      e.InWindow,
+		Decision:      e.Decision,
+	}
+	if e.RuleMatched {
+		exp.Rule = e.Rule.String()
+		exp.RuleRange = e.Rule.IsRange()
+	}
+	for _, r := range e.Rewards {
+		exp.BonusMinutes += r.Minutes
+		exp.Rewards = append(exp.Rewards, Reward{Minutes: r.Minutes, Reason: r.Reason})
+	}
+	for _, iv := range e.Intervals {
+		exp.Intervals = append(exp.Intervals, IntervalDetail(iv))
+	}
+	return exp
+}
@@ -0,0 +1,226 @@
+// Package policy is the public policy engine of home-gate. It parses compact
+// policy strings such as "MO-FR60,SA-SU120,WEEK600" and structured policy
+// documents, and computes each day's allowed minutes.
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"home-gate/internal/policy"
+)
+
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o policyfakes/fake_clock.go . Clock
+
+// Clock decides what now is, e.g. a fake in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// Manager answers how many minutes are allowed today and whether a time is
+// within the allowed windows.
+type Manager struct {
+	pm *policy.PolicyManager
+}
+
+// AllowedToday returns the minutes allowed today, after overrides.
+func (m *Manager) AllowedToday() int {
+	return m.pm.AllowedToday()
+}
+
+// IsWithinPolicy reports whether activeMinutes are within today's minutes.
+func (m *Manager) IsWithinPolicy(activeMinutes int) bool {
+	return m.pm.IsWithinPolicy(activeMinutes)
+}
+
+// InWindow reports whether t is within the allowed time windows. It is
+// always true without windows.
+func (m *Manager) InWindow(t time.Time) bool {
+	return m.pm.InWindow(t)
+}
+
+// TodayRule returns the rule matching today, e.g. "MO-FR60", or false if no
+// rule covers today, which then allows 0 minutes.
+func (m *Manager) TodayRule() (string, bool) {
+	rule, ok := m.pm.TodayRule()
+	if !ok {
+		return "", false
+	}
+	return rule.String(), true
+}
+
+// Override replaces the rules on the days From..To, both inclusive, e.g. for
+// the holidays.
+type Override struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Policy is the policy string applying instead.
+	Policy string `json:"policy"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ParseOverride parses "FROM[..TO]=POLICY", e.g.
+// "2026-12-24..2027-01-06=MO-SU180", interpreting dates in loc.
+func ParseOverride(spec string, loc *time.Location) (Override, error) {
+	o, err := policy.ParseOverride(spec, loc)
+	if err != nil {
+		return Override{}, err
+	}
+	return Override{From: o.From, To: o.To, Policy: o.Policy, Reason: o.Reason}, nil
+}
+
+// Option configures a Manager.
+type Option func(*options)
+
+type options struct {
+	clock     Clock
+	loc       *time.Location
+	overrides []Override
+}
+
+// WithClock sets the clock deciding what today is. Defaults to the system
+// clock.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithLocation sets the time zone in which days start. Defaults to the local
+// time zone.
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.loc = loc
+	}
+}
+
+// WithOverrides replaces the rules on specific dates, see ParseOverride.
+func WithOverrides(overrides ...Override) Option {
+	return func(o *options) {
+		o.overrides = append(o.overrides, overrides...)
+	}
+}
+
+// New parses a policy string into a Manager.
+func New(policyStr string, opts ...Option) (*Manager, error) {
+	doc, err := policy.FromString(policyStr)
+	if err != nil {
+		return nil, err
+	}
+	return newManager(*doc.Default, opts)
+}
+
+func newManager(rules policy.Rules, opts []Option) (*Manager, error) {
+	o := options{clock: policy.RealClock{}, loc: time.Local}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	pm, err := rules.Manager(o.clock, o.loc)
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range o.overrides {
+		io, err := policy.NewOverride(override.From, override.To, override.Policy, override.Reason)
+		if err != nil {
+			return nil, fmt.Errorf("override %s: %w", override.From.Format(time.DateOnly), err)
+		}
+		pm.AddOverrides(io)
+	}
+	return &Manager{pm: pm}, nil
+}
+
+// Document is a structured policy document with default rules and rules per
+// child, see Schema.
+type Document struct {
+	doc *policy.Document
+}
+
+// LoadDocument reads and validates a YAML or JSON policy document.
+func LoadDocument(path string) (*Document, error) {
+	doc, err := policy.LoadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{doc: doc}, nil
+}
+
+// Children returns the names of the document's children.
+func (d *Document) Children() []string {
+	var names []string
+	for _, c := range d.doc.Children {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Manager creates a Manager for the rules applying to a device, given by any
+// of its keys (name, linked name, landevice UID or MAC), or to a child by
+// name. It fails if neither a child nor the default rules apply.
+func (d *Document) Manager(key string, opts ...Option) (*Manager, error) {
+	for _, c := range d.doc.Children {
+		if c.Name == key {
+			return newManager(c.Rules, opts)
+		}
+	}
+	_, rules, ok := d.doc.RulesFor(key)
+	if !ok {
+		return nil, fmt.Errorf("no rules apply to %s", key)
+	}
+	return newManager(rules, opts)
+}
+
+// Lint lints the limits of the default rules and of every child, see Lint.
+func (d *Document) Lint() []Issue {
+	var issues []Issue
+	lint := func(child string, rules policy.Rules) {
+		for _, issue := range Lint(rules.Compact()) {
+			issue.Child = child
+			issues = append(issues, issue)
+		}
+	}
+	if d.doc.Default != nil {
+		lint("", *d.doc.Default)
+	}
+	for _, c := range d.doc.Children {
+		lint(c.Name, c.Rules)
+	}
+	return issues
+}
+
+// Severity of a lint issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found while linting a policy.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	// Pos is the byte offset in the policy string.
+	Pos     int    `json:"pos"`
+	Message string `json:"message"`
+	// Child is the child whose rules have the issue, empty for a policy
+	// string and the default rules of a document.
+	Child string `json:"child,omitempty"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: position %d: %s", i.Severity, i.Pos, i.Message)
+}
+
+// Lint reports errors, overlaps and uncovered days of a policy string.
+func Lint(policyStr string) []Issue {
+	var issues []Issue
+	for _, i := range policy.Lint(policyStr) {
+		issues = append(issues, Issue{Severity: Severity(i.Severity), Pos: i.Pos, Message: i.Message})
+	}
+	return issues
+}
+
+// Schema returns the JSON Schema of policy documents.
+func Schema() []byte {
+	return bytes.Clone(policy.Schema)
+}
@@ -0,0 +1,94 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"home-gate/pkg/policy"
+	"home-gate/pkg/policy/policyfakes"
+)
+
+var _ = Describe("New", func() {
+	var clock *policyfakes.FakeClock
+
+	BeforeEach(func() {
+		clock = &policyfakes.FakeClock{}
+		// Saturday in Tokyo, still Friday in UTC.
+		clock.NowReturns(time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC))
+	})
+
+	It("applies the clock and time zone", func() {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		Expect(err).ToNot(HaveOccurred())
+
+		pm, err := policy.New("MO-FR60,SA-SU120", policy.WithClock(clock), policy.WithLocation(tokyo))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pm.AllowedToday()).To(Equal(120))
+
+		pm, err = policy.New("MO-FR60,SA-SU120", policy.WithClock(clock), policy.WithLocation(time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pm.AllowedToday()).To(Equal(60))
+	})
+
+	It("applies overrides", func() {
+		override, err := policy.ParseOverride("2026-10-16=MO-SU180", time.UTC)
+		Expect(err).ToNot(HaveOccurred())
+
+		pm, err := policy.New("MO-FR60", policy.WithClock(clock), policy.WithLocation(time.UTC), policy.WithOverrides(override))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pm.AllowedToday()).To(Equal(180))
+	})
+
+	It("rejects invalid policies", func() {
+		_, err := policy.New("XX60")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Document", func() {
+	var doc *policy.Document
+
+	BeforeEach(func() {
+		path := filepath.Join(GinkgoT().TempDir(), "policy.yaml")
+		Expect(os.WriteFile(path, []byte(`version: 1
+default:
+  policy: MO-FR60
+children:
+  - name: Lena
+    devices: ["Lena's iPad"]
+    policy: MO-SU120,SA180
+`), 0o644)).To(Succeed())
+		var err error
+		doc, err = policy.LoadDocument(path)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("creates managers for children, their devices and others", func() {
+		clock := &policyfakes.FakeClock{}
+		clock.NowReturns(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+		opts := []policy.Option{policy.WithClock(clock), policy.WithLocation(time.UTC)}
+
+		Expect(doc.Children()).To(Equal([]string{"Lena"}))
+		for key, allowed := range map[string]int{"Lena": 180, "Lena's iPad": 180, "TV": 0} {
+			pm, err := doc.Manager(key, opts...)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pm.AllowedToday()).To(Equal(allowed), key)
+		}
+		pm, err := doc.Manager("Lena", opts...)
+		Expect(err).ToNot(HaveOccurred())
+		rule, ok := pm.TodayRule()
+		Expect(ok).To(BeTrue())
+		Expect(rule).To(Equal("SA180"))
+	})
+
+	It("lints the rules of every child", func() {
+		issues := doc.Lint()
+		Expect(issues).ToNot(BeEmpty())
+		Expect(issues).To(ContainElement(HaveField("Child", "Lena")))
+		Expect(issues).To(ContainElement(HaveField("Child", "")))
+	})
+})